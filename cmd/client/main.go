@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"Distributed-Lock-Manager/internal/client"
 )
@@ -12,6 +13,12 @@ import (
 func main() {
 	// Define command-line flag for port
 	port := flag.Int("port", 50051, "The server port")
+	compress := flag.Bool("compress", false, "Gzip requests and replies to save bandwidth")
+	tlsCA := flag.String("tls-ca", "", "PEM CAs trusted to sign the server certificate; enables TLS")
+	tlsServerName := flag.String("tls-server-name", "", "Name to verify the server certificate against; enables TLS")
+	tlsCert := flag.String("tls-cert", "", "PEM client certificate for servers requiring mutual TLS")
+	tlsKey := flag.String("tls-key", "", "PEM private key for -tls-cert")
+	retries := flag.Int("retries", 1, "Tries per idempotent call while the server is unreachable, 1 disables retries")
 	flag.Parse()
 
 	// Default values
@@ -34,7 +41,21 @@ func main() {
 	serverAddr := fmt.Sprintf("localhost:%d", *port)
 
 	// Create a new client with the specified ID and server address
-	c, err := client.NewLockClient(serverAddr, clientID)
+	var opts []client.Option
+	if *compress {
+		opts = append(opts, client.WithCompression())
+	}
+	if *retries > 1 {
+		opts = append(opts, client.WithRetry(*retries, 100*time.Millisecond))
+	}
+	if *tlsCA != "" || *tlsServerName != "" || *tlsCert != "" {
+		cfg, err := client.LoadTLSConfig(*tlsCA, *tlsServerName, *tlsCert, *tlsKey)
+		if err != nil {
+			log.Fatalf("Failed to set up TLS: %v", err)
+		}
+		opts = append(opts, client.WithTLS(cfg))
+	}
+	c, err := client.NewLockClient(serverAddr, clientID, opts...)
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
@@ -44,6 +65,7 @@ func main() {
 	if err := c.Initialize(); err != nil {
 		log.Fatalf("Failed to initialize client: %v", err)
 	}
+	clientID = c.ID()
 	fmt.Printf("Client %d initialized successfully\n", clientID)
 
 	// Step 2: Acquire the lock
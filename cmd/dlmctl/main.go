@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"Distributed-Lock-Manager/internal/client"
+)
+
+const usage = `Usage: dlmctl [flags] <command> [args]
+
+Commands:
+  force-release [-resource name] -reason text   Break a lock whoever holds it
+  quarantine <client id>                        Cut a client off and release its locks
+  unquarantine <client id>                      Restore a quarantined client's access
+  read-only on|off                              Refuse or accept appends, writes and truncates
+  status                                        List every held or waited-for lock; needs no token
+
+Flags:
+`
+
+func main() {
+	host := flag.String("host", "localhost", "The server host")
+	port := flag.Int("port", 50051, "The server port")
+	adminToken := flag.String("admin-token", os.Getenv("DLM_ADMIN_TOKEN"), "The server's admin token (env DLM_ADMIN_TOKEN)")
+	tlsCA := flag.String("tls-ca", "", "PEM CAs trusted to sign the server certificate; enables TLS")
+	tlsServerName := flag.String("tls-server-name", "", "Name to verify the server certificate against; enables TLS")
+	tlsCert := flag.String("tls-cert", "", "PEM client certificate for servers requiring mutual TLS")
+	tlsKey := flag.String("tls-key", "", "PEM private key for -tls-cert")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *adminToken == "" && args[0] != "status" {
+		log.Fatalf("Admin commands need -admin-token")
+	}
+
+	opts := []client.Option{client.WithAdminToken(*adminToken)}
+	if *tlsCA != "" || *tlsServerName != "" || *tlsCert != "" {
+		cfg, err := client.LoadTLSConfig(*tlsCA, *tlsServerName, *tlsCert, *tlsKey)
+		if err != nil {
+			log.Fatalf("Failed to set up TLS: %v", err)
+		}
+		opts = append(opts, client.WithTLS(cfg))
+	}
+	// Admin RPCs act on other clients, so this one never initializes an ID of
+	// its own, and only disconnects at the end: closing would release
+	// whatever a real client 0 holds
+	c, err := client.NewLockClient(fmt.Sprintf("%s:%d", *host, *port), 0, opts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Disconnect()
+
+	switch cmd, rest := args[0], args[1:]; cmd {
+	case "force-release":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		resource := fs.String("resource", "", "The lock to break; the global lock if empty")
+		reason := fs.String("reason", "", "Why the lock is being broken, recorded in the server log")
+		fs.Parse(rest)
+		if *reason == "" {
+			log.Fatalf("force-release needs -reason")
+		}
+		if err := c.ForceRelease(*resource, *reason); err != nil {
+			log.Fatalf("Failed to force release: %v", err)
+		}
+		fmt.Println("Lock released")
+	case "quarantine", "unquarantine":
+		if len(rest) != 1 {
+			log.Fatalf("%s needs a client ID", cmd)
+		}
+		id, err := strconv.Atoi(rest[0])
+		if err != nil {
+			log.Fatalf("Invalid client ID %q: %v", rest[0], err)
+		}
+		if cmd == "quarantine" {
+			err = c.Quarantine(int32(id))
+		} else {
+			err = c.Unquarantine(int32(id))
+		}
+		if err != nil {
+			log.Fatalf("Failed to %s client %d: %v", cmd, id, err)
+		}
+		fmt.Printf("Client %d %sd\n", id, cmd)
+	case "read-only":
+		if len(rest) != 1 || (rest[0] != "on" && rest[0] != "off") {
+			log.Fatalf("read-only needs on or off")
+		}
+		if err := c.SetReadOnly(rest[0] == "on"); err != nil {
+			log.Fatalf("Failed to set read-only %s: %v", rest[0], err)
+		}
+		fmt.Printf("Read-only %s\n", rest[0])
+	case "status":
+		locks, err := c.AllLocks()
+		if err != nil {
+			log.Fatalf("Failed to get lock status: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RESOURCE\tHOLDER\tTOKEN\tREADERS\tWAITERS")
+		for _, l := range locks {
+			holder := "-"
+			if l.Holder != -1 {
+				holder = strconv.Itoa(int(l.Holder))
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", l.Resource, holder, l.Token, l.Readers, l.Waiters)
+		}
+		w.Flush()
+	default:
+		log.Fatalf("Unknown command %q", cmd)
+	}
+}
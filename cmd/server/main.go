@@ -1,37 +1,341 @@
 package main
 
 import (
-    "flag"
-    "log"
-    "net"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-    "Distributed-Lock-Manager/internal/server"
-    pb "Distributed-Lock-Manager/proto"
+	"Distributed-Lock-Manager/internal/file_manager"
+	"Distributed-Lock-Manager/internal/lock_manager"
+	"Distributed-Lock-Manager/internal/server"
+	pb "Distributed-Lock-Manager/proto"
 
-    "google.golang.org/grpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
+// envString returns the value of the environment variable key, or def if unset
+func envString(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt returns the integer value of the environment variable key, or def if unset or invalid
+func envInt(key string, def int) int {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err == nil {
+			return n
+		}
+		log.Printf("Ignoring invalid %s=%q: %v", key, v, err)
+	}
+	return def
+}
+
+// parseLeases parses a comma-separated list of resource=duration pairs
+func parseLeases(s string) (map[string]time.Duration, error) {
+	leases := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		resource, value, ok := strings.Cut(pair, "=")
+		if !ok || resource == "" {
+			return nil, fmt.Errorf("%q is not resource=duration", pair)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("lease of %s: %v", resource, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("lease of %s must be positive, got %v", resource, d)
+		}
+		leases[resource] = d
+	}
+	return leases, nil
+}
+
 func main() {
-    // Define a flag for the address with a default value of ":50051"
-    address := flag.String("address", ":50051", "Address to listen on")
-    flag.Parse()
-
-    // Initialize the files
-    server.CreateFiles()
-
-    // Set up TCP listener using the specified address
-    lis, err := net.Listen("tcp", *address)
-    if err != nil {
-        log.Fatalf("Failed to listen on %s: %v", *address, err)
-    }
-
-    // Create gRPC server
-    s := grpc.NewServer()
-    pb.RegisterLockServiceServer(s, server.NewLockServer())
-
-    // Log the address the server is listening on
-    log.Printf("Server listening at %v", lis.Addr())
-    if err := s.Serve(lis); err != nil {
-        log.Fatalf("Failed to serve: %v", err)
-    }
-}
\ No newline at end of file
+	// Flags fall back to DLM_* environment variables, then to the built-in defaults
+	port := flag.Int("port", envInt("DLM_PORT", 50051), "Port to listen on (env DLM_PORT)")
+	dataDir := flag.String("data-dir", envString("DLM_DATA_DIR", file_manager.DefaultDataDir), "Directory holding the data files (env DLM_DATA_DIR)")
+	syncWrites := flag.Bool("sync", false, "Fsync every data file write before acknowledging it")
+	priorityAging := flag.Duration("priority-aging", lock_manager.DefaultPriorityAging, "Raise a waiting acquire's priority by one each time it has waited this long, 0 for strict priorities")
+	slowWait := flag.Duration("slow-wait", server.DefaultSlowWaitThreshold, "Log a warning when an acquire waits longer than this for its lock, 0 disables")
+	breakDeadlocks := flag.Bool("break-deadlocks", false, "Answer DEADLOCK to an acquire that would complete a cycle of clients waiting for each other, instead of only logging it")
+	antiAffinity := flag.Bool("anti-affinity", false, "Prefer granting a released lock to a waiter other than its last holder")
+	fileCount := flag.Int("files", envInt("DLM_FILE_COUNT", file_manager.DefaultFileCount), "Number of data files, file_0 to file_<n-1> (env DLM_FILE_COUNT)")
+	filePattern := flag.String("file-pattern", envString("DLM_FILE_PATTERN", ""), "Accept data file names matching this regular expression instead of file_0 to file_<n-1> (env DLM_FILE_PATTERN)")
+	ensureNewline := flag.Bool("ensure-newline", false, "End every appended or written content in exactly one newline, adding or trimming as needed; leave off for binary data")
+	stampAppends := flag.Bool("stamp-appends", false, "Prefix every append with an RFC 3339 timestamp and the writing client's ID")
+	readOnly := flag.Bool("read-only", false, "Start read-only: refuse appends, writes and truncates until an admin turns it off with admin_set_read_only")
+	createOnAppend := flag.Bool("create-on-append", false, "Don't create the data files at startup; each is created, mode 0644, by its first append")
+	seedFile := flag.String("seed-file", "", "Write this file's content into each data file created at startup; existing files are left alone")
+	maxOpenFiles := flag.Int("max-open-files", envInt("DLM_MAX_OPEN_FILES", file_manager.DefaultMaxOpenFiles), "Keep at most this many data files open between appends, 0 for no limit (env DLM_MAX_OPEN_FILES)")
+	fileRetries := flag.Int("file-retries", envInt("DLM_FILE_RETRIES", file_manager.DefaultTransientRetries), "Retry an append's open or write this many times after a transient error such as too many open files, 0 disables (env DLM_FILE_RETRIES)")
+	maxFileSize := flag.Int64("max-file-size", int64(envInt("DLM_MAX_FILE_SIZE", 0)), "Refuse appends that would make a data file bigger than this many bytes, 0 for no limit (env DLM_MAX_FILE_SIZE)")
+	maxAppendBytes := flag.Int("max-append-bytes", envInt("DLM_MAX_APPEND_BYTES", server.DefaultMaxAppendBytes), "Refuse a single append carrying more than this many bytes, 0 for no limit (env DLM_MAX_APPEND_BYTES)")
+	maxRecvMsgBytes := flag.Int("max-recv-msg-bytes", envInt("DLM_MAX_RECV_MSG_BYTES", server.DefaultMaxMsgBytes), "Largest gRPC message the server accepts, such as an append with its content (env DLM_MAX_RECV_MSG_BYTES)")
+	maxSendMsgBytes := flag.Int("max-send-msg-bytes", envInt("DLM_MAX_SEND_MSG_BYTES", server.DefaultMaxMsgBytes), "Largest gRPC message the server sends, such as a file read (env DLM_MAX_SEND_MSG_BYTES)")
+	maxWaiters := flag.Int("max-waiters", envInt("DLM_MAX_WAITERS", 0), "Answer SERVER_BUSY instead of queueing once this many acquires are waiting, 0 for no limit (env DLM_MAX_WAITERS)")
+	appendRate := flag.Float64("append-rate", 0, "Answer RATE_LIMITED to a client appending more often than this many times a second, 0 disables")
+	appendBurst := flag.Int("append-burst", 1, "Appends a client may make in a burst before -append-rate applies")
+	stateFile := flag.String("state-file", envString("DLM_STATE_FILE", ""), "Save lock ownership here and reload it on restart (env DLM_STATE_FILE)")
+	persistPolicy := flag.String("persist-policy", "async", "What a grant does when persisting it takes longer than -persist-threshold: async grants anyway, reject answers SERVER_BUSY; always reject with -backups")
+	persistThreshold := flag.Duration("persist-threshold", lock_manager.DefaultPersistThreshold, "How long a grant waits for its state to be saved or replicated before -persist-policy applies")
+	backups := flag.String("backups", envString("DLM_BACKUPS", ""), "Comma-separated host:port of backup servers to replicate lock state to (env DLM_BACKUPS)")
+	backupRole := flag.Bool("backup", false, "Run as a backup: take lock state from a primary and serve nothing until promoted")
+	adminToken := flag.String("admin-token", envString("DLM_ADMIN_TOKEN", ""), "Shared token enabling admin RPCs such as restore (env DLM_ADMIN_TOKEN)")
+	lease := flag.Duration("lease", 0, "Release a client's locks after this long without a keep_alive (0 disables)")
+	resourceLeases := flag.String("resource-lease", "", "Comma-separated resource=duration leases, such as file_99=10m, overriding -lease for those locks")
+	keepaliveTime := flag.Duration("keepalive-time", server.DefaultKeepaliveTime, "Ping a client connection that has been idle this long, to find clients that vanished")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", server.DefaultKeepaliveTimeout, "Close a client connection whose keepalive ping goes unanswered this long")
+	healthPort := flag.Int("health-port", envInt("DLM_HEALTH_PORT", 0), "Serve /livez and /readyz probes over HTTP on this port, 0 disables (env DLM_HEALTH_PORT)")
+	tlsCert := flag.String("tls-cert", envString("DLM_TLS_CERT", ""), "PEM certificate to serve TLS with; insecure if unset (env DLM_TLS_CERT)")
+	tlsKey := flag.String("tls-key", envString("DLM_TLS_KEY", ""), "PEM private key for -tls-cert (env DLM_TLS_KEY)")
+	tlsClientCA := flag.String("tls-client-ca", envString("DLM_TLS_CLIENT_CA", ""), "Require client certificates signed by these PEM CAs (env DLM_TLS_CLIENT_CA)")
+	queueSample := flag.Duration("queue-sample-interval", server.DefaultQueueSampleInterval, "How often to sample the waiter queue for metrics, 0 disables")
+	metricsPort := flag.Int("metrics-port", envInt("DLM_METRICS_PORT", 0), "Serve Prometheus metrics at /metrics on this port, 0 disables (env DLM_METRICS_PORT)")
+	memoryLimit := flag.Int("memory-limit-mb", envInt("DLM_MEMORY_LIMIT_MB", 0), "Refuse new acquires while heap in use is at or above this many MiB, 0 disables (env DLM_MEMORY_LIMIT_MB)")
+	auditLog := flag.String("audit-log", envString("DLM_AUDIT_LOG", ""), "Append a JSON line per lock and file change to this file (env DLM_AUDIT_LOG)")
+	logFormat := flag.String("log-format", envString("DLM_LOG_FORMAT", "text"), "Log output format: text or json (env DLM_LOG_FORMAT)")
+	flag.Parse()
+
+	// Route every log line, including the standard logger's, through one structured handler
+	var handler slog.Handler
+	switch *logFormat {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, nil)
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, nil)
+	default:
+		log.Fatalf("Invalid log format %q: must be text or json", *logFormat)
+	}
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+
+	// Resolve the data directory once so it doesn't depend on the working directory later
+	resolvedDir, err := file_manager.ResolveDataDir(*dataDir)
+	if err != nil {
+		log.Fatalf("Invalid data directory: %v", err)
+	}
+	*dataDir = resolvedDir
+
+	// Initialize the files
+	if *fileCount < 1 {
+		log.Fatalf("Invalid file count %d: must be at least 1", *fileCount)
+	}
+	if *maxOpenFiles < 0 {
+		log.Fatalf("Invalid max open files %d: must not be negative", *maxOpenFiles)
+	}
+	if *fileRetries < 0 {
+		log.Fatalf("Invalid file retries %d: must not be negative", *fileRetries)
+	}
+	if *maxFileSize < 0 {
+		log.Fatalf("Invalid max file size %d: must not be negative", *maxFileSize)
+	}
+	if *maxAppendBytes < 0 {
+		log.Fatalf("Invalid max append bytes %d: must not be negative", *maxAppendBytes)
+	}
+	if *maxWaiters < 0 {
+		log.Fatalf("Invalid max waiters %d: must not be negative", *maxWaiters)
+	}
+	if *appendRate < 0 {
+		log.Fatalf("Invalid append rate %v: must not be negative", *appendRate)
+	}
+	if *persistThreshold <= 0 {
+		log.Fatalf("Invalid persist threshold %v: must be positive", *persistThreshold)
+	}
+	var policy lock_manager.PersistPolicy
+	switch *persistPolicy {
+	case "async":
+		policy = lock_manager.PersistAsync
+	case "reject":
+		policy = lock_manager.PersistReject
+	default:
+		log.Fatalf("Invalid persist policy %q: must be async or reject", *persistPolicy)
+	}
+	var seed []byte
+	if *seedFile != "" {
+		if *filePattern != "" || *createOnAppend {
+			log.Fatalf("Invalid seed file: -seed-file only applies to files created at startup, so can't be used with -file-pattern or -create-on-append")
+		}
+		var err error
+		if seed, err = os.ReadFile(*seedFile); err != nil {
+			log.Fatalf("Failed to read seed file: %v", err)
+		}
+	}
+	var validator file_manager.FilenameValidator
+	if *filePattern != "" {
+		var err error
+		if validator, err = file_manager.MatchFilenames(*filePattern); err != nil {
+			log.Fatalf("Invalid file pattern: %v", err)
+		}
+	} else if *createOnAppend {
+		log.Printf("Data files will be created on first append")
+	} else if err := server.CreateSeededFiles(*dataDir, *fileCount, seed); err != nil {
+		log.Fatalf("Failed to create data files: %v", err)
+	}
+
+	// Set up TCP listener using the specified port
+	address := fmt.Sprintf(":%d", *port)
+	lis, err := net.Listen("tcp", address)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		log.Fatalf("Failed to listen on %s: port %d is already in use, probably by another server still running; stop it or choose another -port", address, *port)
+	} else if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", address, err)
+	}
+
+	opts := []server.Option{server.WithFileCount(*fileCount), server.WithMaxOpenFiles(*maxOpenFiles), server.WithFileRetries(*fileRetries), server.WithMaxAppendBytes(*maxAppendBytes), server.WithPriorityAging(*priorityAging), server.WithSlowWaitThreshold(*slowWait), server.WithPersistPolicy(*persistThreshold, policy), server.WithLogger(logger)}
+	if *syncWrites {
+		opts = append(opts, server.WithSyncWrites())
+	}
+	if *antiAffinity {
+		opts = append(opts, server.WithAntiAffinity())
+	}
+	if *readOnly {
+		opts = append(opts, server.WithReadOnly())
+	}
+	if *breakDeadlocks {
+		opts = append(opts, server.WithDeadlockBreaking())
+	}
+	if validator != nil {
+		opts = append(opts, server.WithFilenameValidator(validator))
+	}
+	if *ensureNewline {
+		opts = append(opts, server.WithTransforms(server.EnsureNewlineTransform()))
+	}
+	if *stampAppends {
+		opts = append(opts, server.WithAppendStamps())
+	}
+	if *maxFileSize > 0 {
+		opts = append(opts, server.WithMaxFileSize(*maxFileSize))
+	}
+	if *maxWaiters > 0 {
+		opts = append(opts, server.WithMaxWaiters(*maxWaiters))
+	}
+	if *appendRate > 0 {
+		opts = append(opts, server.WithRateLimit(*appendRate, *appendBurst))
+	}
+	if *lease > 0 {
+		opts = append(opts, server.WithLease(*lease))
+	}
+	if *resourceLeases != "" {
+		leases, err := parseLeases(*resourceLeases)
+		if err != nil {
+			log.Fatalf("Invalid resource lease: %v", err)
+		}
+		opts = append(opts, server.WithResourceLeases(leases))
+	}
+	if *stateFile != "" {
+		opts = append(opts, server.WithStateFile(*stateFile))
+	}
+	if *adminToken != "" {
+		opts = append(opts, server.WithAdminToken(*adminToken))
+	}
+	if (*backups != "" || *backupRole) && *adminToken == "" {
+		log.Fatalf("Replication needs -admin-token, shared by the primary and its backups")
+	}
+	if *backups != "" {
+		opts = append(opts, server.WithBackups(strings.Split(*backups, ","), grpc.WithTransportCredentials(insecure.NewCredentials())))
+	}
+	if *backupRole {
+		opts = append(opts, server.WithBackupRole())
+	}
+	if *auditLog != "" {
+		sink, err := server.NewFileAuditSink(*auditLog)
+		if err != nil {
+			log.Fatalf("Failed to set up audit log: %v", err)
+		}
+		defer sink.Close()
+		opts = append(opts, server.WithAuditSink(sink, server.DefaultAuditBuffer))
+	}
+	if *memoryLimit > 0 {
+		opts = append(opts, server.WithMemoryLimit(uint64(*memoryLimit)<<20, nil))
+	}
+	var registry *prometheus.Registry
+	if *metricsPort > 0 {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		opts = append(opts, server.WithMetrics(registry), server.WithQueueSampleInterval(*queueSample))
+	}
+	ls := server.NewLockServer(*dataDir, opts...)
+
+	// Create gRPC server, with TLS when a certificate is configured
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(ls.UnaryInterceptor(), ls.RecoveryInterceptor()),
+		grpc.MaxRecvMsgSize(*maxRecvMsgBytes),
+		grpc.MaxSendMsgSize(*maxSendMsgBytes),
+	}
+	serverOpts = append(serverOpts, server.KeepaliveOptions(*keepaliveTime, *keepaliveTimeout)...)
+	if *tlsCert != "" || *tlsKey != "" {
+		creds, err := server.LoadTLSCredentials(*tlsCert, *tlsKey, *tlsClientCA)
+		if err != nil {
+			log.Fatalf("Failed to set up TLS: %v", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	} else {
+		if *tlsClientCA != "" {
+			log.Fatalf("-tls-client-ca requires -tls-cert and -tls-key")
+		}
+		log.Printf("Warning: serving without TLS; set -tls-cert and -tls-key outside local development")
+	}
+	s := grpc.NewServer(serverOpts...)
+	pb.RegisterLockServiceServer(s, ls)
+	ls.RegisterHealth(s, server.DefaultHealthInterval)
+
+	if registry != nil {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+			addr := fmt.Sprintf(":%d", *metricsPort)
+			log.Printf("Metrics listening at %s", addr)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				log.Printf("Metrics listener failed: %v", err)
+			}
+		}()
+	}
+
+	if *healthPort > 0 {
+		go func() {
+			addr := fmt.Sprintf(":%d", *healthPort)
+			log.Printf("Health probes listening at %s", addr)
+			if err := http.ListenAndServe(addr, ls.HealthHandler()); err != nil {
+				log.Printf("Health probe listener failed: %v", err)
+			}
+		}()
+	}
+
+	// On SIGINT or SIGTERM, turn away waiting acquires, let in-flight RPCs
+	// finish, then flush and close the data files
+	stopped := make(chan struct{})
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-sigs
+		log.Printf("Received %v, shutting down gracefully", sig)
+		signal.Stop(sigs) // A second signal kills the process
+		ls.GracefulStop(s)
+		close(stopped)
+	}()
+
+	// Log the address the server is listening on
+	log.Printf("Server listening at %v (data dir %s)", lis.Addr(), *dataDir)
+	if err := s.Serve(lis); err != nil {
+		log.Fatalf("Failed to serve: %v", err)
+	}
+	<-stopped
+	log.Printf("Server stopped")
+}
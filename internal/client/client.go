@@ -1,59 +1,344 @@
 package client
 
 import (
+	"archive/tar"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"net"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	pb "Distributed-Lock-Manager/proto"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
 )
 
+// MetricsFunc receives the RPC method name (e.g. "lock_acquire"), how long the
+// call took as seen by the client, and the error it returned, if any
+type MetricsFunc func(method string, duration time.Duration, err error)
+
+// adminTokenHeader is the metadata key the server reads the admin token from
+const adminTokenHeader = "x-admin-token"
+
+// AssignID, passed to NewLockClient as the client ID, has the server assign a
+// unique ID when the client is initialized
+const AssignID = -1
+
+// DefaultTimeout bounds each RPC a client helper makes, unless changed with
+// WithTimeout, so an unresponsive server fails the call instead of hanging it
+const DefaultTimeout = 5 * time.Second
+
+// DefaultAcquireTimeout bounds a blocking acquire, which may legitimately wait
+// behind other holders for longer than an ordinary call
+const DefaultAcquireTimeout = 10 * time.Second
+
+// DefaultMaxMsgBytes is the largest gRPC message a client sends or accepts
+// unless changed with WithMaxMsgBytes. It matches the server's default.
+const DefaultMaxMsgBytes = 16 << 20
+
+// restoreChunkSize bounds the file data sent in one restore message
+const restoreChunkSize = 64 * 1024
+
+// fileSizePollMin and fileSizePollMax bound the wait between checks in WaitForFileSize
+const (
+	fileSizePollMin = 10 * time.Millisecond
+	fileSizePollMax = 250 * time.Millisecond
+)
+
+// metricsBufferSize bounds the number of observations waiting to be delivered
+const metricsBufferSize = 256
+
+// observation is a single RPC latency sample queued for the metrics callback
+type observation struct {
+	method   string
+	duration time.Duration
+	err      error
+}
+
 // LockClient wraps the gRPC client functionality
 type LockClient struct {
 	conn   *grpc.ClientConn
 	client pb.LockServiceClient
 	id     int32
+	pooled bool // The connection belongs to a ClientPool, which closes it
+
+	fencingToken atomic.Uint64 // Token from the most recent exclusive acquire
+	adminToken   string        // Sent with admin RPCs
+	compress     bool          // Gzip every request
+	checksums    bool          // Send a CRC-32C with appended and written content
+	tlsConfig    *tls.Config   // nil for an insecure connection
+	maxAttempts  int           // Tries per idempotent RPC; 0 or 1 disables retries
+	retryDelay   time.Duration // Delay before the first retry, doubled on each further one
+	lockOrder    []string      // Declared to the server by Initialize
+	priority     int32         // Sent with every blocking acquire
+	renewal      *renewal      // Keeps the lease alive while locks are held; nil if off
+	held         heldLocks     // Locks acquired and not yet released
+
+	// dialer opens connections in place of TCP, nil to dial TCP
+	dialer func(context.Context, string) (net.Conn, error)
+
+	maxMsgBytes int // Largest gRPC message sent or accepted
+
+	timeout        time.Duration // Deadline for each RPC a helper makes
+	acquireTimeout time.Duration // Deadline for a blocking acquire, waiting included
+
+	verbose      *log.Logger // Traces every RPC attempt; nil if off
+	metrics      MetricsFunc
+	observations chan observation // Feeds the metrics callback without blocking RPCs
+	stopMetrics  chan struct{}    // Closed to stop delivering metrics
+	droppedObs   atomic.Uint64    // Observations discarded because the callback fell behind
+}
+
+// Option configures optional LockClient settings
+type Option func(*LockClient)
+
+// WithMetrics registers a callback invoked after every RPC with its latency.
+// The callback runs on a separate goroutine; samples are dropped rather than
+// slowing down RPCs if it falls behind (see DroppedMetrics), and a panicking
+// callback loses only the sample it was given.
+func WithMetrics(fn MetricsFunc) Option {
+	return func(c *LockClient) {
+		c.metrics = fn
+	}
+}
+
+// WithAdminToken sets the shared admin token sent with admin RPCs such as Restore
+func WithAdminToken(token string) Option {
+	return func(c *LockClient) {
+		c.adminToken = token
+	}
+}
+
+// WithCompression gzips every request, and has the server gzip its replies,
+// trading CPU for bandwidth on large appends over slow links
+func WithCompression() Option {
+	return func(c *LockClient) {
+		c.compress = true
+	}
+}
+
+// WithLockOrder declares, when Initialize is called, the order in which this
+// client takes the named locks. The server then rejects acquires that break
+// the order with LOCK_ORDER_VIOLATION instead of letting them deadlock.
+func WithLockOrder(resources ...string) Option {
+	return func(c *LockClient) {
+		c.lockOrder = resources
+	}
+}
+
+// WithChecksums sends a CRC-32C of the content with every append and write.
+// The server refuses content that doesn't match it with CHECKSUM_MISMATCH
+// rather than writing data damaged on the way.
+func WithChecksums() Option {
+	return func(c *LockClient) {
+		c.checksums = true
+	}
+}
+
+// castagnoli is the CRC-32C table content checksums are computed with
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// checksum returns the CRC-32C of content to send with it, or nil if the
+// client doesn't send checksums
+func (c *LockClient) checksum(content []byte) *uint32 {
+	if !c.checksums {
+		return nil
+	}
+	sum := crc32.Checksum(content, castagnoli)
+	return &sum
+}
+
+// WithPriority has the client's blocking acquires queue ahead of waiters with
+// lower priority. The default is 0; the server raises a waiter's priority the
+// longer it waits, so lower-priority clients still get their turn.
+func WithPriority(priority int32) Option {
+	return func(c *LockClient) {
+		c.priority = priority
+	}
+}
+
+// WithDialer connects through dial instead of TCP, for example to an in-memory
+// listener in tests. dial is passed the address given to NewLockClient.
+func WithDialer(dial func(ctx context.Context, addr string) (net.Conn, error)) Option {
+	return func(c *LockClient) {
+		c.dialer = dial
+	}
+}
+
+// WithMaxMsgBytes changes the largest gRPC message the client sends or accepts
+// from DefaultMaxMsgBytes. An append bigger than the server's
+// -max-recv-msg-bytes still fails with ResourceExhausted.
+func WithMaxMsgBytes(n int) Option {
+	return func(c *LockClient) {
+		c.maxMsgBytes = n
+	}
+}
+
+// WithTimeout sets the deadline for each RPC the client helpers make, blocking
+// acquires included, in place of DefaultTimeout and DefaultAcquireTimeout. A
+// call that runs out of time fails with a DeadlineExceeded error.
+func WithTimeout(d time.Duration) Option {
+	return func(c *LockClient) {
+		c.timeout = d
+		c.acquireTimeout = d
+	}
 }
 
 // NewLockClient creates a new client connected to the server
-func NewLockClient(serverAddr string, clientID int32) (*LockClient, error) {
+func NewLockClient(serverAddr string, clientID int32, opts ...Option) (*LockClient, error) {
+	c := &LockClient{id: clientID, timeout: DefaultTimeout, acquireTimeout: DefaultAcquireTimeout, maxMsgBytes: DefaultMaxMsgBytes}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	creds := insecure.NewCredentials()
+	if c.tlsConfig != nil {
+		creds = credentials.NewTLS(c.tlsConfig)
+	}
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(c.maxMsgBytes), grpc.MaxCallSendMsgSize(c.maxMsgBytes)),
+	}
+	if c.dialer != nil {
+		dialOpts = append(dialOpts, grpc.WithContextDialer(c.dialer))
+	}
+	if c.compress {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	}
+	// Metrics wrap retries, so they report the latency the caller saw
+	var interceptors []grpc.UnaryClientInterceptor
+	if c.metrics != nil {
+		c.observations = make(chan observation, metricsBufferSize)
+		c.stopMetrics = make(chan struct{})
+		go c.deliverMetrics()
+		interceptors = append(interceptors, c.metricsInterceptor)
+	}
+	if c.maxAttempts > 1 {
+		interceptors = append(interceptors, c.retryInterceptor)
+		dialOpts = append(dialOpts, c.retryDialOptions()...)
+	}
+	// Tracing sits inside retries, so every attempt gets its own line
+	if c.verbose != nil {
+		interceptors = append(interceptors, c.traceInterceptor)
+	}
+	if len(interceptors) > 0 {
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(interceptors...))
+	}
+
 	// Establish a connection to the server
-	conn, err := grpc.Dial(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(serverAddr, dialOpts...)
 	if err != nil {
+		if c.stopMetrics != nil {
+			close(c.stopMetrics)
+		}
 		return nil, fmt.Errorf("failed to connect to server: %v", err)
 	}
 
 	// Create a gRPC client instance
-	client := pb.NewLockServiceClient(conn)
+	c.conn = conn
+	c.client = pb.NewLockServiceClient(conn)
+	return c, nil
+}
+
+// metricsInterceptor times each unary RPC and queues the result for the metrics callback
+func (c *LockClient) metricsInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	// Never block the caller on a slow metrics consumer
+	select {
+	case c.observations <- observation{method: path.Base(method), duration: time.Since(start), err: err}:
+	default:
+		c.droppedObs.Add(1)
+	}
+	return err
+}
+
+// DroppedMetrics returns how many RPC observations were discarded because the
+// metrics callback couldn't keep up
+func (c *LockClient) DroppedMetrics() uint64 {
+	return c.droppedObs.Load()
+}
+
+// deliverMetrics passes queued observations to the metrics callback until the client closes
+func (c *LockClient) deliverMetrics() {
+	for {
+		select {
+		case o := <-c.observations:
+			c.observe(o)
+		case <-c.stopMetrics:
+			return
+		}
+	}
+}
 
-	return &LockClient{
-		conn:   conn,
-		client: client,
-		id:     clientID,
-	}, nil
+// observe passes o to the metrics callback, keeping the delivery goroutine alive if it panics
+func (c *LockClient) observe(o observation) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.droppedObs.Add(1)
+		}
+	}()
+	c.metrics(o.method, o.duration, o.err)
 }
 
-// Initialize initializes the client with the server
+// Initialize initializes the client with the server. A client created with
+// AssignID as its ID takes the unique ID the server assigns, so it must be
+// initialized before making any other call.
 func (c *LockClient) Initialize() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
-	_, err := c.client.ClientInit(ctx, &pb.Int{Rc: c.id})
+	resp, err := c.client.ClientInit(ctx, &pb.InitArgs{Rc: c.id, LockOrder: c.lockOrder})
 	if err != nil {
 		return fmt.Errorf("ClientInit failed: %v", err)
 	}
+	if c.id == AssignID {
+		c.id = resp.Rc
+	}
 	return nil
 }
 
-// AcquireLock attempts to acquire the lock
+// ID returns the client's ID, as assigned by the server if it asked for one
+func (c *LockClient) ID() int32 {
+	return c.id
+}
+
+// AcquireLock attempts to acquire the global lock
 func (c *LockClient) AcquireLock() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return c.AcquireResource("")
+}
+
+// AcquireResource attempts to acquire the lock on the named resource exclusively.
+// An empty resource name refers to the global lock.
+func (c *LockClient) AcquireResource(resource string) error {
+	return c.acquire(resource, pb.LockMode_EXCLUSIVE)
+}
+
+// AcquireShared attempts to acquire the lock on the named resource in shared
+// (read) mode, alongside other readers but excluding writers
+func (c *LockClient) AcquireShared(resource string) error {
+	return c.acquire(resource, pb.LockMode_SHARED)
+}
+
+func (c *LockClient) acquire(resource string, mode pb.LockMode) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.acquireTimeout)
 	defer cancel()
 
-	lockArgs := &pb.LockArgs{ClientId: c.id}
+	lockArgs := &pb.LockArgs{ClientId: c.id, Resource: resource, Mode: mode, Priority: c.priority}
 	resp, err := c.client.LockAcquire(ctx, lockArgs)
 	if err != nil {
 		return fmt.Errorf("LockAcquire failed: %v", err)
@@ -61,6 +346,97 @@ func (c *LockClient) AcquireLock() error {
 	if resp.Status != pb.Status_SUCCESS {
 		return fmt.Errorf("LockAcquire failed with status: %v", resp.Status)
 	}
+	c.recordToken(resp)
+	c.acquired(resource, mode)
+	return nil
+}
+
+// recordToken remembers the fencing token carried by a successful exclusive acquire
+func (c *LockClient) recordToken(resp *pb.Response) {
+	if resp.FencingToken != 0 {
+		c.fencingToken.Store(resp.FencingToken)
+	}
+}
+
+// FencingToken returns the token the server issued with the most recent
+// exclusive acquire, or 0 if none. Pass it along with writes to other systems
+// so they can reject a stale holder whose lock was since given to someone else.
+func (c *LockClient) FencingToken() uint64 {
+	return c.fencingToken.Load()
+}
+
+// TryAcquireLock attempts to acquire the global lock without waiting.
+// It returns false if the lock is currently held by another client.
+func (c *LockClient) TryAcquireLock() (bool, error) {
+	return c.TryAcquireResource("")
+}
+
+// TryAcquireResource attempts to acquire the lock on the named resource without waiting
+func (c *LockClient) TryAcquireResource(resource string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	lockArgs := &pb.LockArgs{ClientId: c.id, Resource: resource}
+	resp, err := c.client.LockTryAcquire(ctx, lockArgs)
+	if err != nil {
+		return false, fmt.Errorf("LockTryAcquire failed: %v", err)
+	}
+	switch resp.Status {
+	case pb.Status_SUCCESS:
+		c.recordToken(resp)
+		c.acquired(resource, pb.LockMode_EXCLUSIVE)
+		return true, nil
+	case pb.Status_LOCK_BUSY:
+		return false, nil
+	default:
+		return false, fmt.Errorf("LockTryAcquire failed with status: %v", resp.Status)
+	}
+}
+
+// CompareAndAcquireLock takes over the lock from expectedHolder.
+// It returns false if expectedHolder no longer holds the lock.
+func (c *LockClient) CompareAndAcquireLock(expectedHolder int32) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	casArgs := &pb.CasArgs{ExpectedHolder: expectedHolder, NewHolder: c.id}
+	resp, err := c.client.LockCompareAndAcquire(ctx, casArgs)
+	if err != nil {
+		return false, fmt.Errorf("LockCompareAndAcquire failed: %v", err)
+	}
+	switch resp.Status {
+	case pb.Status_SUCCESS:
+		c.recordToken(resp)
+		c.acquired("", pb.LockMode_EXCLUSIVE)
+		return true, nil
+	case pb.Status_PRECONDITION_FAILED:
+		return false, nil
+	default:
+		return false, fmt.Errorf("LockCompareAndAcquire failed with status: %v", resp.Status)
+	}
+}
+
+// TransferLock hands the global lock, which this client must hold, directly to
+// another client, so no queued client can take it in between
+func (c *LockClient) TransferLock(to int32) error {
+	return c.TransferResource("", to)
+}
+
+// TransferResource hands the lock on the named resource, which this client must
+// hold exclusively, directly to another client
+func (c *LockClient) TransferResource(resource string, to int32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	transferArgs := &pb.TransferArgs{FromClientId: c.id, ToClientId: to, Resource: resource}
+	resp, err := c.client.LockTransfer(ctx, transferArgs)
+	if err != nil {
+		return fmt.Errorf("LockTransfer failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("LockTransfer failed with status: %v", resp.Status)
+	}
+	c.released(resource, pb.LockMode_EXCLUSIVE)
 	return nil
 }
 
@@ -70,14 +446,16 @@ func (c *LockClient) AcquireLockWithRetry(maxAttempts int) error {
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		// Create context with timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 
 		// Attempt to acquire lock
-		lockArgs := &pb.LockArgs{ClientId: c.id}
+		lockArgs := &pb.LockArgs{ClientId: c.id, Priority: c.priority}
 		resp, err := c.client.LockAcquire(ctx, lockArgs)
 		cancel()
 
 		if err == nil && resp.Status == pb.Status_SUCCESS {
+			c.recordToken(resp)
+			c.acquired("", pb.LockMode_EXCLUSIVE)
 			return nil
 		}
 
@@ -99,15 +477,18 @@ func (c *LockClient) AcquireLockWithRetry(maxAttempts int) error {
 	return fmt.Errorf("failed to acquire lock after %d attempts: %v", maxAttempts, lastErr)
 }
 
-// AppendFile appends data to a file
+// AppendFile appends data to a file. Each call carries a fresh request ID, so
+// the server applies it at most once even if it is retried.
 func (c *LockClient) AppendFile(filename string, content []byte) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
 	fileArgs := &pb.FileArgs{
-		Filename: filename,
-		Content:  content,
-		ClientId: c.id,
+		Filename:  filename,
+		Content:   content,
+		Crc32C:    c.checksum(content),
+		ClientId:  c.id,
+		RequestId: uuid.NewString(),
 	}
 	resp, err := c.client.FileAppend(ctx, fileArgs)
 	if err != nil {
@@ -119,30 +500,656 @@ func (c *LockClient) AppendFile(filename string, content []byte) error {
 	return nil
 }
 
-// ReleaseLock releases the lock
+// AppendFileAt appends data to a file only if the file is exactly offset bytes
+// long, so clients coordinating on an append-only log can detect a lost update.
+// It returns false and the file's actual size if the offset was stale; after a
+// successful append, size is the file's new size, ready for the next call.
+func (c *LockClient) AppendFileAt(filename string, content []byte, offset int64) (ok bool, size int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	fileArgs := &pb.FileArgs{
+		Filename:       filename,
+		Content:        content,
+		Crc32C:         c.checksum(content),
+		ClientId:       c.id,
+		RequestId:      uuid.NewString(),
+		ExpectedOffset: &offset,
+	}
+	resp, err := c.client.FileAppend(ctx, fileArgs)
+	if err != nil {
+		return false, 0, fmt.Errorf("FileAppend failed: %v", err)
+	}
+	switch resp.Status {
+	case pb.Status_SUCCESS:
+		return true, resp.Size, nil
+	case pb.Status_OFFSET_MISMATCH:
+		return false, resp.Size, nil
+	}
+	return false, 0, fmt.Errorf("FileAppend failed with status: %v", resp.Status)
+}
+
+// AppendRecord appends data to a file like AppendFile and returns the offset
+// the data landed at, for clients keeping an index into an append-only log
+func (c *LockClient) AppendRecord(filename string, content []byte) (offset int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	fileArgs := &pb.FileArgs{
+		Filename:  filename,
+		Content:   content,
+		Crc32C:    c.checksum(content),
+		ClientId:  c.id,
+		RequestId: uuid.NewString(),
+	}
+	resp, err := c.client.FileAppend(ctx, fileArgs)
+	if err != nil {
+		return 0, fmt.Errorf("FileAppend failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return 0, fmt.Errorf("FileAppend failed with status: %v", resp.Status)
+	}
+	return resp.Offset, nil
+}
+
+// AppendFiles appends to several files in a single RPC, in filename order.
+// The client must hold the lock for every file, or the global lock. Nothing
+// is written if any filename or permission is bad; if a write itself fails,
+// the error names the file and the files before it in order stay written.
+func (c *LockClient) AppendFiles(entries map[string][]byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	filenames := make([]string, 0, len(entries))
+	for filename := range entries {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	args := &pb.BatchArgs{ClientId: c.id, RequestId: uuid.NewString()}
+	for _, filename := range filenames {
+		args.Entries = append(args.Entries, &pb.BatchEntry{Filename: filename, Content: entries[filename]})
+	}
+	resp, err := c.client.FileAppendBatch(ctx, args)
+	if err != nil {
+		return fmt.Errorf("FileAppendBatch failed: %v", err)
+	}
+	switch {
+	case resp.Status == pb.Status_SUCCESS:
+	case resp.Status == pb.Status_SERVER_BUSY || int(resp.FailedEntry) >= len(filenames):
+		return fmt.Errorf("FileAppendBatch failed with status: %v", resp.Status)
+	default:
+		return fmt.Errorf("FileAppendBatch failed with status: %v at %s", resp.Status, filenames[resp.FailedEntry])
+	}
+	return nil
+}
+
+// WriteFile replaces the contents of a file atomically. Like AppendFile, it
+// requires the file's lock or the global lock, held exclusively.
+func (c *LockClient) WriteFile(filename string, content []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	fileArgs := &pb.FileArgs{
+		Filename: filename,
+		Content:  content,
+		Crc32C:   c.checksum(content),
+		ClientId: c.id,
+	}
+	resp, err := c.client.FileWrite(ctx, fileArgs)
+	if err != nil {
+		return fmt.Errorf("FileWrite failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("FileWrite failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// TruncateFile empties a file in place. Like AppendFile, it requires the
+// file's lock or the global lock, held exclusively.
+func (c *LockClient) TruncateFile(filename string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	fileArgs := &pb.FileArgs{Filename: filename, ClientId: c.id}
+	resp, err := c.client.FileTruncate(ctx, fileArgs)
+	if err != nil {
+		return fmt.Errorf("FileTruncate failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("FileTruncate failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// ReadFile returns the contents of a file. The client must hold the file's
+// lock or the global lock, in either shared or exclusive mode.
+func (c *LockClient) ReadFile(filename string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	fileArgs := &pb.FileArgs{
+		Filename: filename,
+		ClientId: c.id,
+	}
+	resp, err := c.client.FileRead(ctx, fileArgs)
+	if err != nil {
+		return nil, fmt.Errorf("FileRead failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return nil, fmt.Errorf("FileRead failed with status: %v", resp.Status)
+	}
+	return resp.Content, nil
+}
+
+// FileStats returns a file's size, modification time and the client that last
+// appended to it. No lock is needed.
+func (c *LockClient) FileStats(filename string) (*pb.FileStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.FileStats(ctx, &pb.FileArgs{Filename: filename, ClientId: c.id})
+	if err != nil {
+		return nil, fmt.Errorf("FileStats failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return nil, fmt.Errorf("FileStats failed with status: %v", resp.Status)
+	}
+	return resp, nil
+}
+
+// FileSize returns a file's current size, for a client resuming an interrupted
+// upload to pass to AppendFileAt. No lock is needed, so the size may change
+// straight after unless the caller holds the file's lock.
+func (c *LockClient) FileSize(filename string) (int64, error) {
+	st, err := c.FileStats(filename)
+	if err != nil {
+		return 0, err
+	}
+	return st.Size, nil
+}
+
+// Stat returns a file's metadata like FileStats, and also has the server read
+// the file to count its lines and checksum it (SHA-256). No lock is needed.
+func (c *LockClient) Stat(filename string) (*pb.FileStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.FileStats(ctx, &pb.FileArgs{Filename: filename, ClientId: c.id, Scan: true})
+	if err != nil {
+		return nil, fmt.Errorf("Stat failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return nil, fmt.Errorf("Stat failed with status: %v", resp.Status)
+	}
+	return resp, nil
+}
+
+// ListFiles returns every data file on the server with its size, in file
+// number order. Files that haven't been written yet are left out.
+func (c *LockClient) ListFiles() ([]*pb.FileEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.ListFiles(ctx, &pb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("ListFiles failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return nil, fmt.Errorf("ListFiles failed with status: %v", resp.Status)
+	}
+	return resp.Entries, nil
+}
+
+// ValidateFilenames asks the server which of filenames it would accept for
+// appends, without writing anything. It returns one check per name, in order,
+// with the reason for each refusal.
+func (c *LockClient) ValidateFilenames(filenames []string) ([]*pb.FilenameCheck, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.ValidateFilenames(ctx, &pb.FilenamesArgs{Filenames: filenames})
+	if err != nil {
+		return nil, fmt.Errorf("ValidateFilenames failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return nil, fmt.Errorf("ValidateFilenames failed with status: %v", resp.Status)
+	}
+	return resp.Results, nil
+}
+
+// WaitForFileSize blocks until filename is at least minSize bytes long,
+// returning ctx.Err() if ctx is done first. The server has no way to push file
+// changes, so it polls file_stats, starting at fileSizePollMin between checks
+// and backing off to fileSizePollMax while the file stays short. No lock is needed.
+func (c *LockClient) WaitForFileSize(filename string, minSize int64, ctx context.Context) error {
+	delay := fileSizePollMin
+	for {
+		resp, err := c.client.FileStats(ctx, &pb.FileArgs{Filename: filename, ClientId: c.id})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("FileStats failed: %v", err)
+		}
+		if resp.Status != pb.Status_SUCCESS {
+			return fmt.Errorf("FileStats failed with status: %v", resp.Status)
+		}
+		if resp.Size >= minSize {
+			return nil
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay = min(delay*2, fileSizePollMax)
+	}
+}
+
+// ReleaseLock releases the global lock
 func (c *LockClient) ReleaseLock() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return c.ReleaseResource("")
+}
+
+// ReleaseResource releases the exclusive lock on the named resource
+func (c *LockClient) ReleaseResource(resource string) error {
+	return c.release(resource, pb.LockMode_EXCLUSIVE)
+}
+
+// ReleaseShared releases a shared lock on the named resource
+func (c *LockClient) ReleaseShared(resource string) error {
+	return c.release(resource, pb.LockMode_SHARED)
+}
+
+func (c *LockClient) release(resource string, mode pb.LockMode) error {
+	// A lock missing locally may still have been handed over by another
+	// client's TransferResource, so the server has the final say
+	known := c.held.has(resource, mode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
-	lockArgs := &pb.LockArgs{ClientId: c.id}
+	lockArgs := &pb.LockArgs{ClientId: c.id, Resource: resource, Mode: mode}
 	resp, err := c.client.LockRelease(ctx, lockArgs)
 	if err != nil {
 		return fmt.Errorf("LockRelease failed: %v", err)
 	}
+	// Whatever the status, the server says the client doesn't hold the lock now
+	c.released(resource, mode)
+	if !known && resp.Status == pb.Status_PERMISSION_DENIED {
+		return fmt.Errorf("LockRelease of %q failed: %w", resource, ErrNotHeld)
+	}
 	if resp.Status != pb.Status_SUCCESS {
 		return fmt.Errorf("LockRelease failed with status: %v", resp.Status)
 	}
 	return nil
 }
 
-// Close closes the client connection
+// ReleaseAll releases every lock the client holds, in either mode, and
+// returns how many there were. Useful on shutdown after taking several.
+func (c *LockClient) ReleaseAll() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.LockReleaseAll(ctx, &pb.Int{Rc: c.id})
+	if err != nil {
+		return 0, fmt.Errorf("LockReleaseAll failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return 0, fmt.Errorf("LockReleaseAll failed with status: %v", resp.Status)
+	}
+	c.releasedAll()
+	return int(resp.Released), nil
+}
+
+// WithLock acquires the global lock, runs fn and releases the lock again. The
+// lock is released even if fn panics, and the panic then carries on. fn's
+// error is returned, or the release's if fn succeeded.
+func (c *LockClient) WithLock(fn func() error) (err error) {
+	if err := c.AcquireLock(); err != nil {
+		return err
+	}
+	defer func() {
+		if rerr := c.ReleaseLock(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+	return fn()
+}
+
+// Backup writes a tar archive of every data file on the server to w. The
+// server takes a consistent snapshot, so no append lands partway through.
+func (c *LockClient) Backup(w io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.client.BackupStream(ctx, &pb.BackupArgs{Consistent: true})
+	if err != nil {
+		return fmt.Errorf("BackupStream failed: %v", err)
+	}
+
+	tw := tar.NewWriter(w)
+	current := ""
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("BackupStream failed: %v", err)
+		}
+
+		// The first chunk of each file starts a new archive entry
+		if chunk.Filename != current {
+			hdr := &tar.Header{
+				Name:    chunk.Filename,
+				Mode:    0644,
+				Size:    chunk.Size,
+				ModTime: time.Now(),
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return fmt.Errorf("writing backup archive: %v", err)
+			}
+			current = chunk.Filename
+		}
+		if _, err := tw.Write(chunk.Data); err != nil {
+			return fmt.Errorf("writing backup archive: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("writing backup archive: %v", err)
+	}
+	return nil
+}
+
+// adminContext attaches the admin token to ctx
+func (c *LockClient) adminContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, adminTokenHeader, c.adminToken)
+}
+
+// Ping checks that the server is up and can write to its data directory
+func (c *LockClient) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.Ping(ctx, &pb.Empty{})
+	if err != nil {
+		return fmt.Errorf("Ping failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("Ping failed with status: %v (%s)", resp.Status, resp.Reason)
+	}
+	return nil
+}
+
+// Promote turns the backup server the client is connected to into the
+// primary, taking over the lock state last replicated to it. Requires the
+// client to be configured WithAdminToken.
+func (c *LockClient) Promote() error {
+	ctx, cancel := context.WithTimeout(c.adminContext(context.Background()), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.Promote(ctx, &pb.Empty{})
+	if err != nil {
+		return fmt.Errorf("Promote failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("Promote failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// Quarantine cuts off the client with the given ID: the server releases its
+// locks and refuses its RPCs until Unquarantine. Requires the client to be
+// configured WithAdminToken.
+func (c *LockClient) Quarantine(clientID int32) error {
+	ctx, cancel := context.WithTimeout(c.adminContext(context.Background()), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.AdminQuarantineClient(ctx, &pb.Int{Rc: clientID})
+	if err != nil {
+		return fmt.Errorf("AdminQuarantineClient failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("AdminQuarantineClient failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// Unquarantine restores the access of a client cut off with Quarantine.
+// Requires the client to be configured WithAdminToken.
+func (c *LockClient) Unquarantine(clientID int32) error {
+	ctx, cancel := context.WithTimeout(c.adminContext(context.Background()), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.AdminUnquarantineClient(ctx, &pb.Int{Rc: clientID})
+	if err != nil {
+		return fmt.Errorf("AdminUnquarantineClient failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("AdminUnquarantineClient failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// ForceRelease breaks the named lock ("" for the global lock) whoever holds
+// it, logging reason on the server. Meant for operators freeing a lock left
+// behind by a crashed client. Requires the client to be configured WithAdminToken.
+func (c *LockClient) ForceRelease(resource, reason string) error {
+	ctx, cancel := context.WithTimeout(c.adminContext(context.Background()), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.AdminForceRelease(ctx, &pb.AdminArgs{Resource: resource, Reason: reason})
+	if err != nil {
+		return fmt.Errorf("AdminForceRelease failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("AdminForceRelease failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// SetReadOnly turns the server's read-only mode on or off. While it is on,
+// appends, writes and truncates fail with READ_ONLY; locks and reads carry on
+// working. Requires the client to be configured WithAdminToken.
+func (c *LockClient) SetReadOnly(readOnly bool) error {
+	ctx, cancel := context.WithTimeout(c.adminContext(context.Background()), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.AdminSetReadOnly(ctx, &pb.ReadOnlyArgs{ReadOnly: readOnly})
+	if err != nil {
+		return fmt.Errorf("AdminSetReadOnly failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("AdminSetReadOnly failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// Restore uploads a tar archive produced by Backup and has the server write its
+// files. Files that already have content are only overwritten if force is set.
+// Requires the client to be configured WithAdminToken.
+func (c *LockClient) Restore(r io.Reader, force bool) error {
+	ctx, cancel := context.WithCancel(c.adminContext(context.Background()))
+	defer cancel()
+
+	stream, err := c.client.RestoreStream(ctx)
+	if err != nil {
+		return fmt.Errorf("RestoreStream failed: %v", err)
+	}
+
+	// Send force on its own so it arrives first even for an empty archive
+	if err := stream.Send(&pb.RestoreArgs{Force: force}); err != nil {
+		return fmt.Errorf("RestoreStream failed: %v", err)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading backup archive: %v", err)
+		}
+
+		// Every file sends at least one chunk, so empty files are restored too
+		sent := false
+		for {
+			// gRPC may hold on to a sent message, so each chunk gets its own buffer
+			buf := make([]byte, restoreChunkSize)
+			n, err := io.ReadFull(tr, buf)
+			if n > 0 || !sent {
+				chunk := &pb.BackupChunk{Filename: hdr.Name, Size: hdr.Size, Data: buf[:n]}
+				if err := stream.Send(&pb.RestoreArgs{Chunk: chunk}); err != nil {
+					return fmt.Errorf("RestoreStream failed: %v", err)
+				}
+				sent = true
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("reading backup archive: %v", err)
+			}
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("RestoreStream failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("RestoreStream failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// LockStatus reports who holds the global lock and how many clients wait for it
+func (c *LockClient) LockStatus() (*pb.LockStatusResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.GetLockStatus(ctx, &pb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("GetLockStatus failed: %v", err)
+	}
+	return resp, nil
+}
+
+// AllLocks returns every lock that is held or waited for, sorted by resource,
+// as one snapshot: the holder, fencing token, readers and waiters of each
+func (c *LockClient) AllLocks() ([]*pb.LockEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.GetAllLocks(ctx, &pb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("GetAllLocks failed: %v", err)
+	}
+	return resp.Entries, nil
+}
+
+// QueuePosition reports this client's place in the queue for the named lock
+// (empty for the global lock), counting from 1 at the head, and false if it
+// isn't waiting for that lock. Call it from another goroutine while an acquire blocks.
+func (c *LockClient) QueuePosition(resource string) (int32, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.GetQueuePosition(ctx, &pb.LockArgs{ClientId: c.id, Resource: resource})
+	if err != nil {
+		return 0, false, fmt.Errorf("GetQueuePosition failed: %v", err)
+	}
+	return resp.Position, resp.Status == pb.Status_SUCCESS, nil
+}
+
+// VerifyToken asks the server whether token is still this client's fencing
+// token for the named lock (empty for the global lock), i.e. whether the lock
+// hasn't been released or taken over since it was issued
+func (c *LockClient) VerifyToken(resource string, token uint64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.VerifyToken(ctx, &pb.TokenArgs{ClientId: c.id, Token: token, Resource: resource})
+	if err != nil {
+		return false, fmt.Errorf("VerifyToken failed: %v", err)
+	}
+	return resp.Valid, nil
+}
+
+// KeepAlive tells the server this client is still alive, extending the lease on its locks
+func (c *LockClient) KeepAlive() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	resp, err := c.client.KeepAlive(ctx, &pb.Int{Rc: c.id})
+	if err != nil {
+		return fmt.Errorf("KeepAlive failed: %v", err)
+	}
+	if resp.Status != pb.Status_SUCCESS {
+		return fmt.Errorf("KeepAlive failed with status: %v", resp.Status)
+	}
+	return nil
+}
+
+// StartHeartbeat calls KeepAlive every interval in the background until the
+// returned stop function is called. Failed heartbeats are retried on the next tick.
+func (c *LockClient) StartHeartbeat(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.KeepAlive()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// Close closes the client connection. For a handle from a ClientPool it only
+// ends the client's session; the pool keeps the shared connection open.
 func (c *LockClient) Close() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	c.releasedAll() // The server releases everything the client held
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
 	_, err := c.client.ClientClose(ctx, &pb.Int{Rc: c.id})
 	if err != nil {
 		return fmt.Errorf("ClientClose failed: %v", err)
 	}
-	return c.conn.Close()
+	if c.pooled {
+		return nil
+	}
+	return c.closeConn()
+}
+
+// Disconnect closes the connection without ending the client's session: the
+// server isn't told, so locks held under the client's ID stay held and its
+// lock order is kept. Tools that act on other clients, such as dlmctl, use it
+// so that closing can't release a real client's locks. For a handle from a
+// ClientPool it does nothing.
+func (c *LockClient) Disconnect() error {
+	if c.pooled {
+		return nil
+	}
+	return c.closeConn()
+}
+
+// closeConn closes the connection and stops delivering metrics
+func (c *LockClient) closeConn() error {
+	err := c.conn.Close()
+	if c.stopMetrics != nil {
+		close(c.stopMetrics)
+	}
+	return err
 }
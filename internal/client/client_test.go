@@ -0,0 +1,1707 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Distributed-Lock-Manager/internal/file_manager"
+	"Distributed-Lock-Manager/internal/server"
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+// startTestServer runs a lock server on a random local port and returns its address
+func startTestServer(t *testing.T, opts ...server.Option) string {
+	return startTestServerIn(t, t.TempDir(), opts...)
+}
+
+// startTestServerIn is startTestServer with the data directory chosen by the caller
+func startTestServerIn(t *testing.T, dataDir string, opts ...server.Option) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ls := server.NewLockServer(dataDir, opts...)
+	s := grpc.NewServer()
+	pb.RegisterLockServiceServer(s, ls)
+	go s.Serve(lis)
+
+	t.Cleanup(func() {
+		s.Stop()
+		ls.Cleanup()
+	})
+	return lis.Addr().String()
+}
+
+// metricsRecorder collects observations passed to a MetricsFunc
+type metricsRecorder struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+}
+
+func (r *metricsRecorder) record(method string, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.durations[method] = duration
+}
+
+func (r *metricsRecorder) get(method string) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.durations[method]
+	return d, ok
+}
+
+func TestMetricsCallback(t *testing.T) {
+	addr := startTestServer(t)
+	rec := &metricsRecorder{durations: make(map[string]time.Duration)}
+
+	c, err := NewLockClient(addr, 1, WithMetrics(rec.record))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := c.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	if err := c.AppendFile("file_0", []byte("metrics\n")); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	if err := c.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock failed: %v", err)
+	}
+
+	// Callbacks are delivered asynchronously, so poll for each method
+	methods := []string{"client_init", "lock_acquire", "file_append", "lock_release"}
+	for _, method := range methods {
+		deadline := time.Now().Add(time.Second)
+		d, ok := rec.get(method)
+		for !ok && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+			d, ok = rec.get(method)
+		}
+		if !ok {
+			t.Errorf("No metrics recorded for %s", method)
+		} else if d <= 0 {
+			t.Errorf("Expected non-zero duration for %s, got %v", method, d)
+		}
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	addr := startTestServer(t)
+	var trace bytes.Buffer
+	c, err := NewLockClient(addr, 7, WithVerbose(&trace))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	if err := c.AppendFile("file_99", []byte("x\n")); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	c.Stat("file_100")
+
+	lines := strings.Split(strings.TrimSpace(trace.String()), "\n")
+	patterns := []string{
+		`lock_acquire client=7 elapsed=[0-9.]+[µnm]?s status=SUCCESS$`,
+		`file_append client=7 elapsed=[0-9.]+[µnm]?s status=SUCCESS$`,
+		`file_stats client=7 elapsed=[0-9.]+[µnm]?s status=FILE_ERROR$`,
+	}
+	if len(lines) != len(patterns) {
+		t.Fatalf("Expected %d trace lines, got %q", len(patterns), lines)
+	}
+	for i, pattern := range patterns {
+		if !regexp.MustCompile(`^\[LockClient\] .* ` + pattern).MatchString(lines[i]) {
+			t.Errorf("Trace line %d = %q, want a match for %q", i, lines[i], pattern)
+		}
+	}
+}
+
+func TestBlockedMetricsCallbackDropsSamples(t *testing.T) {
+	addr := startTestServer(t)
+
+	// A callback that never returns until the test ends
+	unblock := make(chan struct{})
+	defer close(unblock)
+	stuck := func(method string, duration time.Duration, err error) { <-unblock }
+
+	c, err := NewLockClient(addr, 1, WithMetrics(stuck))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	// More calls than the observation buffer holds, all of which must still succeed
+	for i := 0; i < metricsBufferSize+10; i++ {
+		if _, err := c.LockStatus(); err != nil {
+			t.Fatalf("LockStatus %d failed with a blocked metrics callback: %v", i, err)
+		}
+	}
+	if c.DroppedMetrics() == 0 {
+		t.Errorf("Expected observations to be dropped while the callback is blocked")
+	}
+}
+
+func TestWriteFileReplacesContent(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.AcquireResource("file_4"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := c.AppendFile("file_4", []byte("old\n")); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	if err := c.WriteFile("file_4", []byte("new\n")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	got, err := c.ReadFile("file_4")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(got) != "new\n" {
+		t.Errorf("Expected only the written content, got %q", got)
+	}
+}
+
+func TestAppendFileAt(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.AcquireResource("file_5"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	ok, size, err := c.AppendFileAt("file_5", []byte("one\n"), 0)
+	if err != nil || !ok || size != 4 {
+		t.Fatalf("Expected append at offset 0 to succeed with size 4, got %v, %d, %v", ok, size, err)
+	}
+	ok, size, err = c.AppendFileAt("file_5", []byte("two\n"), 0)
+	if err != nil || ok || size != 4 {
+		t.Errorf("Expected stale offset to be rejected with size 4, got %v, %d, %v", ok, size, err)
+	}
+}
+
+func TestAppendRecordOffsets(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.AcquireResource("file_2"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	// Each record starts where the last one ended
+	var want int64
+	for _, record := range []string{"a\n", "longer record\n", "tail\n"} {
+		offset, err := c.AppendRecord("file_2", []byte(record))
+		if err != nil {
+			t.Fatalf("AppendRecord failed: %v", err)
+		}
+		if offset != want {
+			t.Errorf("Record %q landed at %d, want %d", record, offset, want)
+		}
+		want += int64(len(record))
+	}
+
+	content, err := c.ReadFile("file_2")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if int64(len(content)) != want {
+		t.Errorf("Expected a %d byte file, got %d", want, len(content))
+	}
+}
+
+func TestWatchLock(t *testing.T) {
+	addr := startTestServer(t)
+	watcher, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	defer watcher.Close()
+	c, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := watcher.WatchLock(ctx)
+	if err != nil {
+		t.Fatalf("WatchLock failed: %v", err)
+	}
+
+	before := time.Now()
+	if err := c.AcquireResource("file_4"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := c.ReleaseResource("file_4"); err != nil {
+		t.Fatalf("ReleaseResource failed: %v", err)
+	}
+
+	for _, want := range []pb.LockEventType{pb.LockEventType_ACQUIRED, pb.LockEventType_RELEASED} {
+		select {
+		case ev := <-events:
+			if ev.Type != want || ev.Resource != "file_4" || ev.ClientId != 2 || ev.Mode != pb.LockMode_EXCLUSIVE {
+				t.Errorf("Expected %v of file_4 by client 2, got %v", want, ev)
+			}
+			if at := time.Unix(0, ev.TimeUnixNano); at.Before(before.Add(-time.Second)) || at.After(time.Now().Add(time.Second)) {
+				t.Errorf("Event time %v is out of range", at)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for the %v event", want)
+		}
+	}
+
+	// Cancelling ends the subscription
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("Expected no more events after cancelling")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Channel wasn't closed after cancelling")
+	}
+}
+
+func TestWatchLockResyncsAfterRestart(t *testing.T) {
+	dataDir := t.TempDir()
+	serve := func(lis net.Listener) (*grpc.Server, *server.LockServer) {
+		ls := server.NewLockServer(dataDir)
+		s := grpc.NewServer()
+		pb.RegisterLockServiceServer(s, ls)
+		go s.Serve(lis)
+		return s, ls
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	s, ls := serve(lis)
+
+	watcher, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	defer watcher.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := watcher.WatchLock(ctx)
+	if err != nil {
+		t.Fatalf("WatchLock failed: %v", err)
+	}
+
+	// Kill the server, then bring a new one up on the same address
+	s.Stop()
+	ls.Cleanup()
+	time.Sleep(300 * time.Millisecond)
+	if lis, err = net.Listen("tcp", addr); err != nil {
+		t.Fatalf("Failed to listen again on %s: %v", addr, err)
+	}
+	s, ls = serve(lis)
+	defer func() {
+		s.Stop()
+		ls.Cleanup()
+	}()
+
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("Channel was closed instead of reconnecting")
+		}
+		if ev.Type != pb.LockEventType_RESYNC || ev.Resource != "global" || ev.Status == nil || ev.ClientId != ev.Status.Holder {
+			t.Fatalf("Expected a RESYNC of the global lock first, got %v", ev)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the RESYNC event")
+	}
+
+	// Live events follow the RESYNC
+	c, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+	if err := c.AcquireResource("file_4"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := c.ReleaseResource("file_4"); err != nil {
+		t.Fatalf("ReleaseResource failed: %v", err)
+	}
+	for _, want := range []pb.LockEventType{pb.LockEventType_ACQUIRED, pb.LockEventType_RELEASED} {
+		select {
+		case ev := <-events:
+			if ev.Type != want || ev.Resource != "file_4" || ev.ClientId != 2 {
+				t.Errorf("Expected %v of file_4 by client 2, got %v", want, ev)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for the %v event", want)
+		}
+	}
+}
+
+func TestAllLocks(t *testing.T) {
+	addr := startTestServer(t)
+	var clients []*LockClient
+	for id := int32(1); id <= 3; id++ {
+		c, err := NewLockClient(addr, id)
+		if err != nil {
+			t.Fatalf("Failed to create client %d: %v", id, err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+
+	if err := clients[0].AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	if err := clients[1].AcquireResource("file_7"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := clients[2].AcquireResource("file_3"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := clients[0].AcquireShared("file_5"); err != nil {
+		t.Fatalf("AcquireShared failed: %v", err)
+	}
+
+	locks, err := clients[0].AllLocks()
+	if err != nil {
+		t.Fatalf("AllLocks failed: %v", err)
+	}
+	want := []struct {
+		resource string
+		holder   int32
+		readers  int32
+	}{
+		{"file_3", 3, 0},
+		{"file_5", -1, 1},
+		{"file_7", 2, 0},
+		{"global", 1, 0},
+	}
+	if len(locks) != len(want) {
+		t.Fatalf("Expected %d locks, got %v", len(want), locks)
+	}
+	for i, w := range want {
+		l := locks[i]
+		if l.Resource != w.resource || l.Holder != w.holder || l.Readers != w.readers || l.Waiters != 0 {
+			t.Errorf("Expected %s held by %d with %d readers, got %v", w.resource, w.holder, w.readers, l)
+		}
+		if (l.Token != 0) != (w.holder != -1) {
+			t.Errorf("Expected %s to have a token only while held exclusively, got %d", w.resource, l.Token)
+		}
+	}
+}
+
+func TestDisconnectKeepsSession(t *testing.T) {
+	addr := startTestServer(t)
+	holder, err := NewLockClient(addr, 0)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer holder.Close()
+	if err := holder.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+
+	// A tool sharing the ID looks around and leaves, as dlmctl does
+	tool, err := NewLockClient(addr, 0)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := tool.AllLocks(); err != nil {
+		t.Fatalf("AllLocks failed: %v", err)
+	}
+	if err := tool.Disconnect(); err != nil {
+		t.Fatalf("Disconnect failed: %v", err)
+	}
+
+	st, err := holder.LockStatus()
+	if err != nil {
+		t.Fatalf("LockStatus failed: %v", err)
+	}
+	if st.Holder != 0 {
+		t.Errorf("Expected client 0 to keep the lock after the tool disconnected, held by %d", st.Holder)
+	}
+}
+
+func TestTruncateFile(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.AcquireResource("file_6"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := c.AppendFile("file_6", []byte("to be removed\n")); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	if err := c.TruncateFile("file_6"); err != nil {
+		t.Fatalf("TruncateFile failed: %v", err)
+	}
+	stats, err := c.FileStats("file_6")
+	if err != nil {
+		t.Fatalf("FileStats failed: %v", err)
+	}
+	if stats.Size != 0 {
+		t.Errorf("Expected size 0 after truncate, got %d", stats.Size)
+	}
+}
+
+func TestStat(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	content := []byte("first record\nsecond record\n")
+	if err := c.AcquireResource("file_7"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := c.AppendFile("file_7", content); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	c.ReleaseResource("file_7")
+
+	// No lock is needed to stat
+	stats, err := c.Stat("file_7")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if stats.Size != int64(len(content)) {
+		t.Errorf("Expected size %d, got %d", len(content), stats.Size)
+	}
+	if stats.LineCount != 2 {
+		t.Errorf("Expected 2 lines, got %d", stats.LineCount)
+	}
+	if sum := sha256.Sum256(content); !bytes.Equal(stats.Sha256, sum[:]) {
+		t.Errorf("Checksum mismatch: got %x, want %x", stats.Sha256, sum)
+	}
+	if stats.LastWriter != 1 {
+		t.Errorf("Expected last writer 1, got %d", stats.LastWriter)
+	}
+	if stats.ModTimeUnixNano == 0 {
+		t.Error("Expected a modification time")
+	}
+
+	if _, err := c.Stat("file_100"); err == nil {
+		t.Error("Stat of an invalid filename should fail")
+	}
+}
+
+func TestFileSize(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+	if err := c.AcquireResource("file_4"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	defer c.ReleaseResource("file_4")
+
+	// Resume an upload where the file ends
+	first := []byte("part one\n")
+	if err := c.AppendFile("file_4", first); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	size, err := c.FileSize("file_4")
+	if err != nil {
+		t.Fatalf("FileSize failed: %v", err)
+	}
+	if size != int64(len(first)) {
+		t.Fatalf("Expected size %d, got %d", len(first), size)
+	}
+	ok, size, err := c.AppendFileAt("file_4", []byte("part two\n"), size)
+	if err != nil || !ok {
+		t.Fatalf("AppendFileAt at the reported size failed: %v %v", ok, err)
+	}
+	if got, err := c.FileSize("file_4"); err != nil || got != size {
+		t.Errorf("Expected size %d after resuming, got %d (%v)", size, got, err)
+	}
+
+	if _, err := c.FileSize("file_100"); err == nil {
+		t.Error("FileSize of an invalid filename should fail")
+	}
+}
+
+func TestAssignedID(t *testing.T) {
+	addr := startTestServer(t)
+
+	var clients []*LockClient
+	for i := 0; i < 2; i++ {
+		c, err := NewLockClient(addr, AssignID)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		defer c.Close()
+		if err := c.Initialize(); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+		clients = append(clients, c)
+	}
+	if clients[0].ID() == AssignID || clients[0].ID() == clients[1].ID() {
+		t.Fatalf("Expected distinct assigned IDs, got %d and %d", clients[0].ID(), clients[1].ID())
+	}
+
+	// Calls use the assigned ID: the second client can't take the first's lock
+	if err := clients[0].AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	if ok, err := clients[1].TryAcquireResource(""); err != nil || ok {
+		t.Errorf("Second client shouldn't get the lock held by the first: %v %v", ok, err)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	want := map[string]int64{"file_2": 0, "file_11": 0}
+	for filename := range want {
+		for _, record := range []string{"first\n", "second record\n"} {
+			if err := c.AppendFile(filename, []byte(record)); err != nil {
+				t.Fatalf("AppendFile %s failed: %v", filename, err)
+			}
+			want[filename] += int64(len(record))
+		}
+	}
+
+	entries, err := c.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	got := make(map[string]int64)
+	var names []string
+	for _, e := range entries {
+		got[e.Name] = e.Size
+		names = append(names, e.Name)
+		if e.ModTimeUnixNano == 0 {
+			t.Errorf("%s has no modification time", e.Name)
+		}
+	}
+	for filename, size := range want {
+		if got[filename] != size {
+			t.Errorf("%s listed at %d bytes, want %d", filename, got[filename], size)
+		}
+	}
+	// Listed in file number order, not name order
+	if i2, i11 := slices.Index(names, "file_2"), slices.Index(names, "file_11"); i2 > i11 {
+		t.Errorf("Expected file_2 before file_11, got %v", names)
+	}
+}
+
+func TestValidateFilenames(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	// No lock is held; the check needs none
+	names := []string{"file_0", "file_99", "file_100", "../file_0", "", "notes.txt", "file_0/../file_1"}
+	want := []bool{true, true, false, false, false, false, false}
+	checks, err := c.ValidateFilenames(names)
+	if err != nil {
+		t.Fatalf("ValidateFilenames failed: %v", err)
+	}
+	if len(checks) != len(names) {
+		t.Fatalf("Expected %d results, got %d", len(names), len(checks))
+	}
+	for i, check := range checks {
+		if check.Name != names[i] || check.Ok != want[i] {
+			t.Errorf("Result %d: got %q ok=%v, want %q ok=%v", i, check.Name, check.Ok, names[i], want[i])
+		}
+		if check.Ok != (check.Reason == "") {
+			t.Errorf("%q: ok=%v but reason %q", check.Name, check.Ok, check.Reason)
+		}
+	}
+
+	// Nothing was written
+	entries, err := c.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no files after a dry run, got %v", entries)
+	}
+}
+
+func TestPing(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Ping(); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+}
+
+func TestAppendFiles(t *testing.T) {
+	dataDir := t.TempDir()
+	addr := startTestServerIn(t, dataDir)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	entries := map[string][]byte{}
+	for i := 10; i < 15; i++ {
+		entries[fmt.Sprintf("file_%d", i)] = []byte(fmt.Sprintf("batch %d\n", i))
+	}
+	if err := c.AppendFiles(entries); err != nil {
+		t.Fatalf("AppendFiles failed: %v", err)
+	}
+	for filename, want := range entries {
+		got, err := os.ReadFile(filepath.Join(dataDir, filename))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", filename, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: expected %q, got %q", filename, want, got)
+		}
+	}
+
+	// The error names the entry that was rejected
+	err = c.AppendFiles(map[string][]byte{"file_1": []byte("x"), "not_a_file": []byte("x")})
+	if err == nil || !strings.Contains(err.Error(), "not_a_file") {
+		t.Errorf("Expected an error naming not_a_file, got %v", err)
+	}
+}
+
+func TestWaitForFileSize(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := server.CreateFiles(dataDir, file_manager.DefaultFileCount); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
+	addr := startTestServerIn(t, dataDir)
+	producer, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create producer: %v", err)
+	}
+	defer producer.Close()
+	consumer, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create consumer: %v", err)
+	}
+	defer consumer.Close()
+
+	// Nothing appends, so the wait runs out
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := consumer.WaitForFileSize("file_6", 10, ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded waiting for an idle file, got %v", err)
+	}
+
+	waited := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		waited <- consumer.WaitForFileSize("file_6", 10, ctx)
+	}()
+
+	// Below the threshold the consumer keeps waiting
+	if err := producer.AcquireResource("file_6"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := producer.AppendFile("file_6", []byte("12345")); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	select {
+	case err := <-waited:
+		t.Fatalf("Wait returned at 5 of 10 bytes: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := producer.AppendFile("file_6", []byte("67890")); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Errorf("WaitForFileSize failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Consumer was not unblocked once the file reached 10 bytes")
+	}
+}
+
+func TestLeaseExpiresWithoutHeartbeat(t *testing.T) {
+	lease := 200 * time.Millisecond
+	addr := startTestServer(t, server.WithLease(lease))
+
+	holder, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	other, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer other.Close()
+
+	if err := holder.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+
+	// While the holder heartbeats, the lock outlives several lease periods
+	stop := holder.StartHeartbeat(lease / 4)
+	time.Sleep(3 * lease)
+	if ok, err := other.TryAcquireLock(); err != nil || ok {
+		t.Fatalf("Expected lock to stay held while heartbeating, got ok=%v err=%v", ok, err)
+	}
+
+	// Once the holder goes silent, the other client gets the lock
+	stop()
+	start := time.Now()
+	if err := other.AcquireLock(); err != nil {
+		t.Fatalf("Expected lock after the holder's lease expired: %v", err)
+	}
+	if waited := time.Since(start); waited < lease/2 {
+		t.Errorf("Lock was handed over after %v, before the lease could expire", waited)
+	}
+	if err := other.ReleaseLock(); err != nil {
+		t.Errorf("ReleaseLock failed: %v", err)
+	}
+}
+
+func TestLeaseRenewal(t *testing.T) {
+	lease := 200 * time.Millisecond
+	addr := startTestServer(t, server.WithLease(lease))
+
+	var renewals atomic.Int32
+	holder, err := NewLockClient(addr, 1, WithLeaseRenewal(lease, nil), WithMetrics(func(method string, _ time.Duration, _ error) {
+		if method == "keep_alive" {
+			renewals.Add(1)
+		}
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer holder.Close()
+	other, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer other.Close()
+
+	// The holder never calls KeepAlive itself, yet keeps the lock for several lease periods
+	if err := holder.AcquireResource("file_1"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	time.Sleep(4 * lease)
+	if ok, err := other.TryAcquireResource("file_1"); err != nil || ok {
+		t.Fatalf("Expected the renewed lock to stay held, got ok=%v err=%v", ok, err)
+	}
+	if renewals.Load() < 4 {
+		t.Errorf("Expected at least 4 renewals in 4 lease periods, got %d", renewals.Load())
+	}
+
+	// Released, there's nothing left to renew
+	if err := holder.ReleaseResource("file_1"); err != nil {
+		t.Fatalf("ReleaseResource failed: %v", err)
+	}
+	time.Sleep(lease / 2) // Let any renewal in flight be counted
+	before := renewals.Load()
+	time.Sleep(2 * lease)
+	if after := renewals.Load(); after != before {
+		t.Errorf("Expected renewals to stop after release, got %d more", after-before)
+	}
+
+	// A client whose renewals keep failing, here because its server is gone,
+	// is told it may have lost its locks
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ls := server.NewLockServer(t.TempDir(), server.WithLease(lease))
+	defer ls.Cleanup()
+	gs := grpc.NewServer()
+	pb.RegisterLockServiceServer(gs, ls)
+	go gs.Serve(lis)
+
+	lost := make(chan error, 1)
+	cut, err := NewLockClient(lis.Addr().String(), 3, WithLeaseRenewal(lease, func(err error) { lost <- err }))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer cut.Close()
+	if err := cut.AcquireResource("file_2"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	gs.Stop()
+	select {
+	case err := <-lost:
+		if err == nil {
+			t.Error("Expected the lost-lease callback to get the renewal error")
+		}
+	case <-time.After(10 * lease):
+		t.Fatal("Expected the lost-lease callback after renewals kept failing")
+	}
+}
+
+func TestLockStatus(t *testing.T) {
+	addr := startTestServer(t)
+
+	holder, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer holder.Close()
+	waiter, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer waiter.Close()
+
+	status, err := holder.LockStatus()
+	if err != nil {
+		t.Fatalf("LockStatus failed: %v", err)
+	}
+	if status.Holder != -1 || status.Waiters != 0 {
+		t.Fatalf("Expected a free lock, got holder=%d waiters=%d", status.Holder, status.Waiters)
+	}
+
+	if err := holder.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	acquired := make(chan error, 1)
+	go func() { acquired <- waiter.AcquireLock() }()
+
+	// Poll until the second client shows up in the queue
+	deadline := time.Now().Add(time.Second)
+	for {
+		status, err = holder.LockStatus()
+		if err != nil {
+			t.Fatalf("LockStatus failed: %v", err)
+		}
+		if status.Waiters == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status.Holder != 1 || status.Waiters != 1 {
+		t.Fatalf("Expected holder=1 waiters=1, got holder=%d waiters=%d", status.Holder, status.Waiters)
+	}
+
+	if err := holder.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock failed: %v", err)
+	}
+	if err := <-acquired; err != nil {
+		t.Fatalf("Waiter failed to acquire: %v", err)
+	}
+	if status, err = holder.LockStatus(); err != nil || status.Holder != 2 || status.Waiters != 0 {
+		t.Errorf("Expected holder=2 waiters=0, got %+v (err %v)", status, err)
+	}
+	waiter.ReleaseLock()
+}
+
+func TestBackupRestoresIdenticalFiles(t *testing.T) {
+	addr := startTestServer(t)
+
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	// Populate a few files, one larger than a single backup chunk
+	want := map[string][]byte{
+		"file_0":  []byte("first file\n"),
+		"file_7":  bytes.Repeat([]byte("0123456789abcdef"), 10000),
+		"file_42": []byte("line one\nline two\n"),
+	}
+	if err := c.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	for name, content := range want {
+		if err := c.AppendFile(name, content); err != nil {
+			t.Fatalf("AppendFile %s failed: %v", name, err)
+		}
+	}
+	if err := c.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock failed: %v", err)
+	}
+
+	var archive bytes.Buffer
+	if err := c.Backup(&archive); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// Restore the archive into a fresh directory
+	restoreDir := t.TempDir()
+	tr := tar.NewReader(&archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Reading archive failed: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Reading %s from archive failed: %v", hdr.Name, err)
+		}
+		if err := os.WriteFile(filepath.Join(restoreDir, hdr.Name), content, 0644); err != nil {
+			t.Fatalf("Restoring %s failed: %v", hdr.Name, err)
+		}
+	}
+
+	entries, err := os.ReadDir(restoreDir)
+	if err != nil {
+		t.Fatalf("Failed to list restored files: %v", err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("Expected %d restored files, got %d", len(want), len(entries))
+	}
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(restoreDir, name))
+		if err != nil {
+			t.Errorf("Restored %s missing: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("Restored %s differs: got %d bytes, want %d", name, len(got), len(content))
+		}
+	}
+}
+
+func TestForceRelease(t *testing.T) {
+	addr := startTestServer(t, server.WithAdminToken("secret"))
+	stuck, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer stuck.Close()
+	next, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer next.Close()
+	admin, err := NewLockClient(addr, 0, WithAdminToken("secret"))
+	if err != nil {
+		t.Fatalf("Failed to create admin client: %v", err)
+	}
+	defer admin.Close()
+
+	if err := stuck.AcquireResource("file_5"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if ok, err := next.TryAcquireResource("file_5"); err != nil || ok {
+		t.Fatalf("Expected the lock to be busy while client 1 holds it, got %v, %v", ok, err)
+	}
+
+	// Only an admin can break the lock
+	if err := next.ForceRelease("file_5", "impatient"); err == nil {
+		t.Error("Expected force release without the admin token to fail")
+	}
+	if err := admin.ForceRelease("file_5", "client 1 crashed"); err != nil {
+		t.Fatalf("ForceRelease failed: %v", err)
+	}
+	if err := next.AcquireResource("file_5"); err != nil {
+		t.Fatalf("Acquire after force release failed: %v", err)
+	}
+	if err := stuck.AppendFile("file_5", []byte("late write\n")); err == nil {
+		t.Error("Expected the old holder's append to be refused")
+	}
+}
+
+func TestReleaseAll(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+	other, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer other.Close()
+
+	for _, resource := range []string{"file_1", "file_2"} {
+		if err := c.AcquireResource(resource); err != nil {
+			t.Fatalf("AcquireResource(%s) failed: %v", resource, err)
+		}
+	}
+	if err := c.AcquireShared("file_3"); err != nil {
+		t.Fatalf("AcquireShared failed: %v", err)
+	}
+
+	// A waiter on one of the locks is woken by the release
+	acquired := make(chan error, 1)
+	go func() { acquired <- other.AcquireResource("file_1") }()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if _, queued, err := other.QueuePosition("file_1"); err == nil && queued {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Client 2 never queued for file_1")
+		}
+	}
+
+	released, err := c.ReleaseAll()
+	if err != nil {
+		t.Fatalf("ReleaseAll failed: %v", err)
+	}
+	if released != 3 {
+		t.Errorf("Expected 3 locks released, got %d", released)
+	}
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("Waiting acquire failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the waiter to get file_1 once it was released")
+	}
+	if ok, err := other.TryAcquireResource("file_2"); err != nil || !ok {
+		t.Errorf("Expected file_2 to be free, got %v, %v", ok, err)
+	}
+
+	if released, err := c.ReleaseAll(); err != nil || released != 0 {
+		t.Errorf("Expected a second ReleaseAll to release nothing, got %d, %v", released, err)
+	}
+}
+
+func TestIsHeld(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if c.IsHeld() {
+		t.Error("A new client shouldn't think it holds the lock")
+	}
+	if err := c.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	if !c.IsHeld() {
+		t.Error("Expected IsHeld after acquiring")
+	}
+	if c.IsResourceHeld("file_1") {
+		t.Error("Holding the global lock shouldn't count as holding file_1")
+	}
+	if err := c.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock failed: %v", err)
+	}
+	if c.IsHeld() {
+		t.Error("Expected IsHeld to be false after releasing")
+	}
+
+	// A second release is caught
+	if err := c.ReleaseLock(); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Expected ErrNotHeld from a double release, got %v", err)
+	}
+
+	// Shared locks and transfers are tracked too
+	if err := c.AcquireShared("file_1"); err != nil {
+		t.Fatalf("AcquireShared failed: %v", err)
+	}
+	if !c.IsResourceHeld("file_1") {
+		t.Error("Expected IsResourceHeld for a shared lock")
+	}
+	if err := c.ReleaseResource("file_1"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Expected ErrNotHeld releasing a shared lock as exclusive, got %v", err)
+	}
+	if err := c.ReleaseShared("file_1"); err != nil {
+		t.Errorf("ReleaseShared failed: %v", err)
+	}
+	if err := c.AcquireResource("file_2"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := c.TransferResource("file_2", 2); err != nil {
+		t.Fatalf("TransferResource failed: %v", err)
+	}
+	if c.IsResourceHeld("file_2") {
+		t.Error("Expected a transferred lock to be no longer held")
+	}
+}
+
+func TestRecipientReleasesTransferredLock(t *testing.T) {
+	addr := startTestServer(t)
+	from, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer from.Close()
+	to, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer to.Close()
+
+	if err := from.AcquireResource("file_3"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+	if err := from.TransferResource("file_3", 2); err != nil {
+		t.Fatalf("TransferResource failed: %v", err)
+	}
+
+	// The recipient never acquired the lock itself, but can still release it
+	if err := to.ReleaseResource("file_3"); err != nil {
+		t.Fatalf("Recipient's ReleaseResource failed: %v", err)
+	}
+	locks, err := to.AllLocks()
+	if err != nil {
+		t.Fatalf("AllLocks failed: %v", err)
+	}
+	for _, l := range locks {
+		if l.Resource == "file_3" && l.Holder != -1 {
+			t.Errorf("Expected file_3 to be free after the recipient released it, held by %d", l.Holder)
+		}
+	}
+	if err := to.ReleaseResource("file_3"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Expected ErrNotHeld from a second release, got %v", err)
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	addr := startTestServer(t)
+	c, err := NewLockClient(addr, 1)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+	other, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer other.Close()
+
+	released := func() {
+		t.Helper()
+		ok, err := other.TryAcquireLock()
+		if err != nil || !ok {
+			t.Fatalf("Expected the lock to have been released, got %v, %v", ok, err)
+		}
+		if err := other.ReleaseLock(); err != nil {
+			t.Fatalf("ReleaseLock failed: %v", err)
+		}
+	}
+
+	if err := c.WithLock(func() error {
+		return c.AppendFile("file_0", []byte("inside\n"))
+	}); err != nil {
+		t.Fatalf("WithLock failed: %v", err)
+	}
+	released()
+
+	errFailed := errors.New("callback failed")
+	if err := c.WithLock(func() error { return errFailed }); !errors.Is(err, errFailed) {
+		t.Errorf("Expected the callback's error, got %v", err)
+	}
+	released()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the callback's panic to carry on, recovered %v", r)
+			}
+		}()
+		c.WithLock(func() error { panic("boom") })
+		t.Error("Expected WithLock to panic")
+	}()
+	released()
+}
+
+func TestRestoreFromBackup(t *testing.T) {
+	dataDir := t.TempDir()
+	addr := startTestServerIn(t, dataDir, server.WithAdminToken("secret"))
+
+	c, err := NewLockClient(addr, 1, WithAdminToken("secret"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	want := map[string][]byte{
+		"file_3":  []byte("restore me\n"),
+		"file_9":  bytes.Repeat([]byte("x"), 3*restoreChunkSize+17),
+		"file_10": {},
+	}
+	if err := c.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	for name, content := range want {
+		if len(content) == 0 {
+			// Empty appends are refused, so create the empty file with a write
+			if err := c.WriteFile(name, content); err != nil {
+				t.Fatalf("WriteFile %s failed: %v", name, err)
+			}
+			continue
+		}
+		if err := c.AppendFile(name, content); err != nil {
+			t.Fatalf("AppendFile %s failed: %v", name, err)
+		}
+	}
+
+	var archive bytes.Buffer
+	if err := c.Backup(&archive); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// Restoring over files that still have content needs force
+	if err := c.Restore(bytes.NewReader(archive.Bytes()), false); err == nil {
+		t.Error("Expected restore over existing content to be refused without force")
+	}
+
+	// Wipe the data directory and restore into it
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to list data dir: %v", err)
+	}
+	for _, e := range entries {
+		if err := os.Remove(filepath.Join(dataDir, e.Name())); err != nil {
+			t.Fatalf("Failed to wipe %s: %v", e.Name(), err)
+		}
+	}
+	if err := c.Restore(bytes.NewReader(archive.Bytes()), false); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	for name, content := range want {
+		got, err := c.ReadFile(name)
+		if err != nil {
+			t.Errorf("ReadFile %s after restore failed: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("Restored %s differs: got %d bytes, want %d", name, len(got), len(content))
+		}
+	}
+
+	// Appends after a restore land on the restored file, not a stale handle
+	if err := c.AppendFile("file_3", []byte("more\n")); err != nil {
+		t.Fatalf("AppendFile after restore failed: %v", err)
+	}
+	if got, _ := c.ReadFile("file_3"); string(got) != "restore me\nmore\n" {
+		t.Errorf("Unexpected content after restore and append: %q", got)
+	}
+	c.ReleaseLock()
+
+	// Without the admin token the restore is refused
+	anon, err := NewLockClient(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer anon.Close()
+	if err := anon.Restore(bytes.NewReader(archive.Bytes()), true); err == nil {
+		t.Error("Expected restore without the admin token to be refused")
+	}
+}
+
+// methodKey tags a stats context with the RPC's full method name
+type methodKey struct{}
+
+// payloadSizer is a server stats handler recording the wire size of each file_append request
+type payloadSizer struct {
+	mu    sync.Mutex
+	sizes []int
+}
+
+func (p *payloadSizer) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, methodKey{}, info.FullMethodName)
+}
+
+func (p *payloadSizer) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	in, ok := s.(*stats.InPayload)
+	if !ok || !strings.HasSuffix(ctx.Value(methodKey{}).(string), "/file_append") {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sizes = append(p.sizes, in.WireLength)
+}
+
+func (p *payloadSizer) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (p *payloadSizer) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+func (p *payloadSizer) last() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sizes[len(p.sizes)-1]
+}
+
+func TestCompression(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	dataDir := t.TempDir()
+	sizer := &payloadSizer{}
+	ls := server.NewLockServer(dataDir)
+	s := grpc.NewServer(grpc.StatsHandler(sizer))
+	pb.RegisterLockServiceServer(s, ls)
+	go s.Serve(lis)
+	defer func() {
+		s.Stop()
+		ls.Cleanup()
+	}()
+
+	payload := bytes.Repeat([]byte("highly compressible line\n"), 4096)
+	appendWith := func(id int32, filename string, opts ...Option) int {
+		c, err := NewLockClient(lis.Addr().String(), id, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		defer c.Close()
+		if err := c.AcquireResource(filename); err != nil {
+			t.Fatalf("AcquireResource failed: %v", err)
+		}
+		defer c.ReleaseResource(filename)
+		if err := c.AppendFile(filename, payload); err != nil {
+			t.Fatalf("AppendFile failed: %v", err)
+		}
+		return sizer.last()
+	}
+
+	plain := appendWith(1, "file_1")
+	compressed := appendWith(2, "file_2", WithCompression())
+	if compressed >= plain/2 {
+		t.Errorf("Expected compressed append to be much smaller on the wire: %d bytes vs %d uncompressed", compressed, plain)
+	}
+
+	// The server decompresses transparently, so the bytes on disk are identical
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_2"))
+	if err != nil {
+		t.Fatalf("Failed to read file_2: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("Compressed append wrote %d bytes, want %d", len(got), len(payload))
+	}
+}
+
+func TestMessageSizeLimits(t *testing.T) {
+	// Serves with grpc.NewServer(opts...) and no append limit of its own
+	serve := func(opts ...grpc.ServerOption) string {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		ls := server.NewLockServer(t.TempDir(), server.WithMaxAppendBytes(0))
+		s := grpc.NewServer(opts...)
+		pb.RegisterLockServiceServer(s, ls)
+		go s.Serve(lis)
+		t.Cleanup(func() {
+			s.Stop()
+			ls.Cleanup()
+		})
+		return lis.Addr().String()
+	}
+	payload := bytes.Repeat([]byte("x"), 5<<20)
+	appendTo := func(addr string, opts ...Option) error {
+		c, err := NewLockClient(addr, 1, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		defer c.Close()
+		if err := c.AcquireResource("file_0"); err != nil {
+			t.Fatalf("AcquireResource failed: %v", err)
+		}
+		defer c.ReleaseResource("file_0")
+		return c.AppendFile("file_0", payload)
+	}
+
+	raised := serve(grpc.MaxRecvMsgSize(server.DefaultMaxMsgBytes))
+	if err := appendTo(raised); err != nil {
+		t.Errorf("Expected a 5 MiB append to succeed with the limit raised, got %v", err)
+	}
+
+	// gRPC's own 4 MiB limit, on either side, refuses it cleanly
+	if err := appendTo(serve()); err == nil || !strings.Contains(err.Error(), "ResourceExhausted") {
+		t.Errorf("Expected ResourceExhausted from a server at gRPC's default limit, got %v", err)
+	}
+	if err := appendTo(raised, WithMaxMsgBytes(4<<20)); err == nil || !strings.Contains(err.Error(), "ResourceExhausted") {
+		t.Errorf("Expected ResourceExhausted from a client at gRPC's default limit, got %v", err)
+	}
+}
+
+// flakyAppendServer answers the first `failures` appends with status, then succeeds
+type flakyAppendServer struct {
+	pb.UnimplementedLockServiceServer
+	status   pb.Status
+	failures int32
+	calls    atomic.Int32
+}
+
+func (f *flakyAppendServer) FileAppend(ctx context.Context, args *pb.FileArgs) (*pb.Response, error) {
+	if f.calls.Add(1) <= f.failures {
+		return &pb.Response{Status: f.status}, nil
+	}
+	return &pb.Response{Status: pb.Status_SUCCESS}, nil
+}
+
+func TestTimeoutWithUnresponsiveServer(t *testing.T) {
+	// A listener that accepts connections but never answers, like a hung server
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer lis.Close()
+	var held []net.Conn
+	var mu sync.Mutex
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			held = append(held, conn)
+			mu.Unlock()
+		}
+	}()
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range held {
+			conn.Close()
+		}
+	}()
+
+	const timeout = 200 * time.Millisecond
+	c, err := NewLockClient(lis.Addr().String(), 1, WithTimeout(timeout))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	calls := map[string]func() error{
+		"Initialize":  c.Initialize,
+		"AcquireLock": c.AcquireLock,
+		"AppendFile":  func() error { return c.AppendFile("file_0", []byte("x")) },
+		"ReleaseLock": c.ReleaseLock,
+	}
+	for name, call := range calls {
+		start := time.Now()
+		err := call()
+		if err == nil {
+			t.Errorf("%s succeeded against a server that never answers", name)
+		}
+		if elapsed := time.Since(start); elapsed > timeout+time.Second {
+			t.Errorf("%s took %v to fail, want about %v", name, elapsed, timeout)
+		}
+	}
+}
+
+// corruptingConn flips a bit in the first occurrence of target it writes,
+// standing in for a network that damages data without breaking the framing
+type corruptingConn struct {
+	net.Conn
+	target []byte
+	done   atomic.Bool
+}
+
+func (c *corruptingConn) Write(b []byte) (int, error) {
+	if i := bytes.Index(b, c.target); i >= 0 && c.done.CompareAndSwap(false, true) {
+		b = bytes.Clone(b)
+		b[i] ^= 0x20
+	}
+	return c.Conn.Write(b)
+}
+
+func TestChecksumsCatchCorruption(t *testing.T) {
+	dataDir := t.TempDir()
+	addr := startTestServerIn(t, dataDir)
+	record := []byte("critical record\n")
+	conn := &corruptingConn{target: record}
+	dial := func(ctx context.Context, addr string) (net.Conn, error) {
+		c, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		conn.Conn = c
+		return conn, err
+	}
+
+	c, err := NewLockClient(addr, 1, WithChecksums(), WithDialer(dial))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+	if err := c.AcquireResource("file_0"); err != nil {
+		t.Fatalf("AcquireResource failed: %v", err)
+	}
+
+	// The first send is damaged on the way and refused; the resend goes through
+	if err := c.AppendFile("file_0", record); err == nil || !strings.Contains(err.Error(), "CHECKSUM_MISMATCH") {
+		t.Fatalf("Expected the corrupted append to fail with CHECKSUM_MISMATCH, got %v", err)
+	}
+	if !conn.done.Load() {
+		t.Fatal("The append wasn't corrupted on the way")
+	}
+	if err := c.AppendFile("file_0", record); err != nil {
+		t.Fatalf("Resent append failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file_0: %v", err)
+	}
+	if !bytes.Equal(got, record) {
+		t.Errorf("Expected only the intact record in file_0, got %q", got)
+	}
+}
+
+func TestRetryOnlyRetryableErrors(t *testing.T) {
+	for _, tc := range []struct {
+		status    pb.Status
+		wantCalls int32
+		wantErr   bool
+	}{
+		{pb.Status_RETRYABLE_ERROR, 3, false},
+		{pb.Status_PERMANENT_ERROR, 1, true},
+	} {
+		t.Run(tc.status.String(), func(t *testing.T) {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed to listen: %v", err)
+			}
+			flaky := &flakyAppendServer{status: tc.status, failures: 2}
+			s := grpc.NewServer()
+			pb.RegisterLockServiceServer(s, flaky)
+			go s.Serve(lis)
+			defer s.Stop()
+
+			c, err := NewLockClient(lis.Addr().String(), 1, WithRetry(5, time.Millisecond))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			defer c.Close()
+			err = c.AppendFile("file_0", []byte("x"))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("AppendFile returned %v, want error: %v", err, tc.wantErr)
+			}
+			if got := flaky.calls.Load(); got != tc.wantCalls {
+				t.Errorf("Server saw %d appends, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryAcrossServerRestart(t *testing.T) {
+	dataDir := t.TempDir()
+	serve := func(lis net.Listener) (*grpc.Server, *server.LockServer) {
+		ls := server.NewLockServer(dataDir)
+		s := grpc.NewServer()
+		pb.RegisterLockServiceServer(s, ls)
+		go s.Serve(lis)
+		return s, ls
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	s, ls := serve(lis)
+
+	c, err := NewLockClient(addr, 1, WithRetry(8, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	// Kill the server, then bring a new one up on the same address a little later
+	s.Stop()
+	ls.Cleanup()
+
+	// Lock calls are not idempotent, so they fail straight away instead of retrying
+	if _, err := c.TryAcquireLock(); err == nil {
+		t.Errorf("Expected TryAcquireLock to fail while the server is down")
+	}
+
+	restarted := make(chan func(), 1)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("Failed to listen again on %s: %v", addr, err)
+			restarted <- func() {}
+			return
+		}
+		s, ls := serve(lis)
+		restarted <- func() {
+			s.Stop()
+			ls.Cleanup()
+		}
+	}()
+	defer func() { (<-restarted)() }()
+
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("Initialize did not recover after the restart: %v", err)
+	}
+	if _, err := c.LockStatus(); err != nil {
+		t.Fatalf("LockStatus did not recover after the restart: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"errors"
+	"sync"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+// ErrNotHeld is returned by a release of a lock that neither the client nor
+// the server knows it to hold, such as a second release of the same lock
+var ErrNotHeld = errors.New("lock not held by this client")
+
+// heldLocks is the set of locks a client has acquired and not yet released.
+// It is the client's own view: a lock lost to an expired lease stays in it,
+// and a lock handed over by another client's TransferResource never enters it,
+// so releases still ask the server about locks missing from it.
+type heldLocks struct {
+	mu    sync.Mutex
+	locks map[heldLock]struct{}
+}
+
+func (h *heldLocks) add(resource string, mode pb.LockMode) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.locks == nil {
+		h.locks = make(map[heldLock]struct{})
+	}
+	h.locks[heldLock{resource, mode}] = struct{}{}
+}
+
+// remove drops a lock from the set, reporting whether it was there
+func (h *heldLocks) remove(resource string, mode pb.LockMode) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.locks[heldLock{resource, mode}]
+	delete(h.locks, heldLock{resource, mode})
+	return ok
+}
+
+func (h *heldLocks) clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clear(h.locks)
+}
+
+func (h *heldLocks) has(resource string, mode pb.LockMode) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.locks[heldLock{resource, mode}]
+	return ok
+}
+
+// IsHeld reports whether the client holds the global lock, as far as it knows:
+// it has acquired the lock and not since released or transferred it
+func (c *LockClient) IsHeld() bool {
+	return c.IsResourceHeld("")
+}
+
+// IsResourceHeld reports whether the client holds the lock on the named
+// resource, in either mode, as far as it knows
+func (c *LockClient) IsResourceHeld(resource string) bool {
+	return c.held.has(resource, pb.LockMode_EXCLUSIVE) || c.held.has(resource, pb.LockMode_SHARED)
+}
+
+// acquired records that the client now holds a lock
+func (c *LockClient) acquired(resource string, mode pb.LockMode) {
+	c.held.add(resource, mode)
+	c.renewal.acquired(c, resource, mode)
+}
+
+// released records that the client no longer holds a lock
+func (c *LockClient) released(resource string, mode pb.LockMode) {
+	c.held.remove(resource, mode)
+	c.renewal.released(resource, mode)
+}
+
+// releasedAll records that the client holds no locks any more
+func (c *LockClient) releasedAll() {
+	c.held.clear()
+	c.renewal.releasedAll()
+}
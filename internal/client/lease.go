@@ -0,0 +1,125 @@
+package client
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+// DefaultRenewalFailures is how many lease renewals in a row must fail before
+// the client reports that it may have lost its locks
+const DefaultRenewalFailures = 3
+
+// WithLeaseRenewal has the client keep its lease alive by itself while it
+// holds any lock, from the first acquire until it has released them all or is
+// closed. lease must match the server's -lease. Renewals are sent about every
+// lease/3, each interval moved by up to a fifth either way so that clients
+// which took their locks together don't renew in lockstep. Once
+// DefaultRenewalFailures renewals in a row have failed, onLost, if set, is
+// called with the last error from the renewal goroutine: the lease may have
+// run out and the locks gone to someone else. Renewal carries on regardless.
+func WithLeaseRenewal(lease time.Duration, onLost func(error)) Option {
+	return func(c *LockClient) {
+		c.renewal = &renewal{interval: lease / 3, onLost: onLost}
+	}
+}
+
+// heldLock is a lock the client believes it holds
+type heldLock struct {
+	resource string
+	mode     pb.LockMode
+}
+
+// renewal keeps a client's lease alive while it holds locks. A nil *renewal
+// does nothing, so the client can report every acquire and release to it.
+type renewal struct {
+	interval time.Duration
+	onLost   func(error)
+
+	mu   sync.Mutex
+	held map[heldLock]struct{}
+	stop chan struct{} // Closed to stop the renewal goroutine; nil while none runs
+}
+
+// clone returns a renewal with the same settings and no locks held
+func (r *renewal) clone() *renewal {
+	if r == nil {
+		return nil
+	}
+	return &renewal{interval: r.interval, onLost: r.onLost}
+}
+
+// acquired records that c holds a lock, starting renewals if it's the first
+func (r *renewal) acquired(c *LockClient, resource string, mode pb.LockMode) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.held == nil {
+		r.held = make(map[heldLock]struct{})
+	}
+	r.held[heldLock{resource, mode}] = struct{}{}
+	if r.stop == nil {
+		r.stop = make(chan struct{})
+		go r.run(c, r.stop)
+	}
+}
+
+// released records that a lock is no longer held, stopping renewals if it was the last
+func (r *renewal) released(resource string, mode pb.LockMode) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.held, heldLock{resource, mode})
+	if len(r.held) == 0 {
+		r.halt()
+	}
+}
+
+// releasedAll records that no locks are held any more and stops renewals
+func (r *renewal) releasedAll() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.held)
+	r.halt()
+}
+
+// halt stops the renewal goroutine, if one runs. Must be called with r.mu held.
+func (r *renewal) halt() {
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
+
+// run renews c's lease until stop is closed
+func (r *renewal) run(c *LockClient, stop chan struct{}) {
+	failures := 0
+	for {
+		// Somewhere between 80% and 120% of the interval
+		wait := r.interval*4/5 + time.Duration(rand.Int63n(int64(r.interval*2/5)+1))
+		select {
+		case <-time.After(wait):
+		case <-stop:
+			return
+		}
+
+		err := c.KeepAlive()
+		if err == nil {
+			failures = 0
+			continue
+		}
+		failures++
+		if failures == DefaultRenewalFailures && r.onLost != nil {
+			r.onLost(err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mutexAttemptTimeout bounds a single lock_acquire made by DistributedMutex;
+// Lock keeps waiting across attempts
+const mutexAttemptTimeout = 10 * time.Second
+
+// mutexBusyDelay is how long DistributedMutex waits before asking again after
+// the server was busy or unreachable
+const mutexBusyDelay = 100 * time.Millisecond
+
+// DistributedMutex is an exclusive lock on one named resource (empty for the
+// global lock) that can be used like a sync.Mutex. While it is held, a
+// background heartbeat keeps the lease alive. Lock and Unlock panic on errors
+// they can't wait out, as sync.Mutex does on misuse; use LockContext and
+// TryLock to handle errors instead.
+type DistributedMutex struct {
+	client    *LockClient
+	resource  string
+	heartbeat time.Duration
+
+	mu       sync.Mutex // Protects held and stopBeat
+	held     bool
+	stopBeat func()
+}
+
+// NewDistributedMutex returns a mutex for resource acquired through c. While
+// held it calls KeepAlive every heartbeat, which must be shorter than the
+// server's lease; 0 disables the heartbeat.
+func NewDistributedMutex(c *LockClient, resource string, heartbeat time.Duration) *DistributedMutex {
+	return &DistributedMutex{client: c, resource: resource, heartbeat: heartbeat}
+}
+
+// Lock blocks until the lock is acquired, waiting out server timeouts, busy
+// servers and lost connections. It panics if the server refuses the lock
+// outright, for example because it would break the client's lock order.
+func (m *DistributedMutex) Lock() {
+	if err := m.LockContext(context.Background()); err != nil {
+		panic(fmt.Sprintf("distributed mutex %q: %v", m.resource, err))
+	}
+}
+
+// LockContext is Lock, returning an error instead of panicking, and giving up
+// when ctx is done
+func (m *DistributedMutex) LockContext(ctx context.Context) error {
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, mutexAttemptTimeout)
+		lockArgs := &pb.LockArgs{ClientId: m.client.id, Resource: m.resource, Priority: m.client.priority}
+		resp, err := m.client.client.LockAcquire(attemptCtx, lockArgs)
+		cancel()
+
+		var delay time.Duration
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err == nil && resp.Status == pb.Status_SUCCESS:
+			m.client.recordToken(resp)
+			m.client.acquired(m.resource, pb.LockMode_EXCLUSIVE)
+			m.locked()
+			return nil
+		case err == nil && resp.Status == pb.Status_TIMEOUT:
+		case err == nil && (resp.Status == pb.Status_SERVER_BUSY || resp.Status == pb.Status_UNAVAILABLE):
+			delay = mutexBusyDelay
+		case err == nil:
+			return fmt.Errorf("LockAcquire failed with status: %v", resp.Status)
+		case status.Code(err) == codes.DeadlineExceeded:
+		case status.Code(err) == codes.Unavailable:
+			delay = mutexBusyDelay
+		default:
+			return fmt.Errorf("LockAcquire failed: %v", err)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryLock acquires the lock only if it is free right now, reporting whether it did
+func (m *DistributedMutex) TryLock() (bool, error) {
+	ok, err := m.client.TryAcquireResource(m.resource)
+	if ok {
+		m.locked()
+	}
+	return ok, err
+}
+
+// locked records that the lock is held and starts the heartbeat
+func (m *DistributedMutex) locked() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.held = true
+	if m.heartbeat > 0 {
+		m.stopBeat = m.client.StartHeartbeat(m.heartbeat)
+	}
+}
+
+// Unlock stops the heartbeat and releases the lock. Like sync.Mutex it panics
+// if the mutex isn't locked, and it also panics if the release fails, since
+// the lock may then have been lost while the caller believed it held it.
+func (m *DistributedMutex) Unlock() {
+	m.mu.Lock()
+	if !m.held {
+		m.mu.Unlock()
+		panic(fmt.Sprintf("distributed mutex %q: unlock of unlocked mutex", m.resource))
+	}
+	m.held = false
+	if m.stopBeat != nil {
+		m.stopBeat()
+		m.stopBeat = nil
+	}
+	m.mu.Unlock()
+
+	if err := m.client.ReleaseResource(m.resource); err != nil {
+		panic(fmt.Sprintf("distributed mutex %q: %v", m.resource, err))
+	}
+}
+
+var _ sync.Locker = (*DistributedMutex)(nil)
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"Distributed-Lock-Manager/internal/server"
+)
+
+func TestDistributedMutexExcludesClients(t *testing.T) {
+	addr := startTestServer(t, server.WithLease(200*time.Millisecond))
+	var mutexes []*DistributedMutex
+	for id := int32(1); id <= 2; id++ {
+		c, err := NewLockClient(addr, id)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		defer c.Close()
+		mutexes = append(mutexes, NewDistributedMutex(c, "file_0", 50*time.Millisecond))
+	}
+
+	// An unsynchronized read-modify-write, guarded only by the distributed mutex
+	var inside, maxInside, counter int
+	var stats sync.Mutex
+	var wg sync.WaitGroup
+	for _, m := range mutexes {
+		wg.Add(1)
+		go func(l sync.Locker) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				l.Lock()
+				stats.Lock()
+				inside++
+				maxInside = max(maxInside, inside)
+				n := counter
+				stats.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				stats.Lock()
+				counter = n + 1
+				inside--
+				stats.Unlock()
+				l.Unlock()
+			}
+		}(m)
+	}
+	wg.Wait()
+
+	if maxInside != 1 {
+		t.Errorf("Expected at most one client in the critical section, saw %d", maxInside)
+	}
+	if counter != 40 {
+		t.Errorf("Expected 40 increments, got %d", counter)
+	}
+}
+
+func TestDistributedMutexHeldPastLease(t *testing.T) {
+	addr := startTestServer(t, server.WithLease(100*time.Millisecond))
+	c1, _ := NewLockClient(addr, 1)
+	defer c1.Close()
+	c2, _ := NewLockClient(addr, 2)
+	defer c2.Close()
+
+	// The heartbeat keeps the lock well past the lease
+	m1 := NewDistributedMutex(c1, "", 20*time.Millisecond)
+	m1.Lock()
+	time.Sleep(300 * time.Millisecond)
+	m2 := NewDistributedMutex(c2, "", 0)
+	if ok, err := m2.TryLock(); ok || err != nil {
+		t.Errorf("TryLock should fail while another client holds the mutex, got %v, %v", ok, err)
+	}
+
+	// LockContext gives up with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := m2.LockContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected LockContext to time out, got %v", err)
+	}
+
+	m1.Unlock()
+	if ok, err := m2.TryLock(); !ok || err != nil {
+		t.Errorf("TryLock should succeed once the mutex is free, got %v, %v", ok, err)
+	}
+	m2.Unlock()
+}
+
+func TestDistributedMutexUnlockOfUnlockedPanics(t *testing.T) {
+	addr := startTestServer(t)
+	c, _ := NewLockClient(addr, 1)
+	defer c.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Unlock of an unlocked mutex to panic")
+		}
+	}()
+	NewDistributedMutex(c, "", 0).Unlock()
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ClientPool serves many logical clients, each with its own client ID, over a
+// fixed number of shared gRPC connections, for callers that would otherwise
+// open a connection per lock user
+type ClientPool struct {
+	conns []*LockClient // One per connection; handles borrow its connection and settings
+
+	mu      sync.Mutex
+	handles map[int32]*LockClient
+	next    int // Connection the next new handle is given
+}
+
+// NewClientPool opens size connections to the server, each configured with
+// opts, and spreads the handles returned by Get across them
+func NewClientPool(serverAddr string, size int, opts ...Option) (*ClientPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("invalid pool size %d: must be at least 1", size)
+	}
+
+	p := &ClientPool{handles: make(map[int32]*LockClient)}
+	for i := 0; i < size; i++ {
+		c, err := NewLockClient(serverAddr, AssignID, opts...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.conns = append(p.conns, c)
+	}
+	return p, nil
+}
+
+// Get returns the handle for clientID, creating it on first use; every call
+// with the same ID returns the same handle. With AssignID it returns a new
+// handle each time, whose ID is set when it is initialized. Closing a handle
+// ends that client's session but leaves the shared connection open.
+func (p *ClientPool) Get(clientID int32) *LockClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h, ok := p.handles[clientID]; ok {
+		return h
+	}
+	base := p.conns[p.next%len(p.conns)]
+	p.next++
+	h := &LockClient{
+		conn:        base.conn,
+		client:      base.client,
+		id:          clientID,
+		pooled:      true,
+		adminToken:  base.adminToken,
+		compress:    base.compress,
+		tlsConfig:   base.tlsConfig,
+		maxAttempts: base.maxAttempts,
+		retryDelay:  base.retryDelay,
+		lockOrder:   base.lockOrder,
+		checksums:   base.checksums,
+		priority:    base.priority,
+		renewal:     base.renewal.clone(),
+
+		timeout:        base.timeout,
+		acquireTimeout: base.acquireTimeout,
+	}
+	if clientID != AssignID {
+		p.handles[clientID] = h
+	}
+	return h
+}
+
+// Close closes the shared connections. Handles still in use fail from then on;
+// close them first to release their locks promptly.
+func (p *ClientPool) Close() error {
+	var firstErr error
+	for _, c := range p.conns {
+		if err := c.closeConn(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClientPool(t *testing.T) {
+	addr := startTestServer(t)
+	if _, err := NewClientPool(addr, 0); err == nil {
+		t.Error("Expected an error for an empty pool")
+	}
+	pool, err := NewClientPool(addr, 2)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	if pool.Get(1) != pool.Get(1) {
+		t.Error("Expected the same handle for the same client ID")
+	}
+
+	// Eight logical clients take turns on one file over two connections
+	const clients = 8
+	var wg sync.WaitGroup
+	tokens := make([]uint64, clients)
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h := pool.Get(int32(i + 1))
+			if err := h.AcquireResource("file_0"); err != nil {
+				t.Errorf("Client %d failed to acquire: %v", i+1, err)
+				return
+			}
+			tokens[i] = h.FencingToken()
+			if err := h.AppendFile("file_0", []byte(fmt.Sprintf("client %d\n", i+1))); err != nil {
+				t.Errorf("Client %d failed to append: %v", i+1, err)
+			}
+			if err := h.ReleaseResource("file_0"); err != nil {
+				t.Errorf("Client %d failed to release: %v", i+1, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Every client got its own grant and its record in
+	seen := make(map[uint64]bool)
+	for i, token := range tokens {
+		if token == 0 || seen[token] {
+			t.Errorf("Client %d has fencing token %d, want a unique nonzero one", i+1, token)
+		}
+		seen[token] = true
+	}
+	reader := pool.Get(1)
+	if err := reader.AcquireShared("file_0"); err != nil {
+		t.Fatalf("Failed to acquire for reading: %v", err)
+	}
+	content, err := reader.ReadFile("file_0")
+	if err != nil {
+		t.Fatalf("Failed to read back: %v", err)
+	}
+	for i := 1; i <= clients; i++ {
+		if !strings.Contains(string(content), fmt.Sprintf("client %d\n", i)) {
+			t.Errorf("Missing record from client %d in %q", i, content)
+		}
+	}
+
+	// Closing a handle leaves the shared connection usable by the others
+	if err := pool.Get(1).Close(); err != nil {
+		t.Fatalf("Closing a handle failed: %v", err)
+	}
+	if _, err := pool.Get(2).LockStatus(); err != nil {
+		t.Errorf("Other handles should still work after one is closed: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"path"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// idempotentMethods are the RPCs that can safely be sent again after a failure
+// whose outcome is unknown. Lock calls are deliberately absent: a retried
+// acquire could take effect twice. Appends are safe because AppendFile and
+// AppendFiles tag each call with a request ID the server deduplicates on, and
+// writing the same content, or truncating, twice leaves the file as doing it once.
+var idempotentMethods = map[string]bool{
+	"client_init":       true,
+	"file_append":       true,
+	"file_write":        true,
+	"file_truncate":     true,
+	"file_append_batch": true,
+	"get_lock_status":   true,
+	"get_all_locks":     true,
+	"keep_alive":        true,
+	"file_read":         true,
+	"file_stats":        true,
+	"verify_token":      true,
+}
+
+// WithRetry retries idempotent RPCs (client_init, file_append,
+// file_append_batch, file_write, file_truncate, get_lock_status, get_all_locks,
+// keep_alive, file_read, file_stats, verify_token) that fail because the server is unreachable or
+// that it answers with RETRYABLE_ERROR, up to maxAttempts tries in total,
+// waiting baseDelay, then twice that, and so on, with jitter. The connection is
+// re-established in the background with the same base delay, so a restarted
+// server is picked up quickly.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(c *LockClient) {
+		c.maxAttempts = maxAttempts
+		c.retryDelay = baseDelay
+	}
+}
+
+// retryDialOptions returns the dial options that make reconnects follow the retry delay
+func (c *LockClient) retryDialOptions() []grpc.DialOption {
+	cfg := backoff.DefaultConfig
+	cfg.BaseDelay = c.retryDelay
+	return []grpc.DialOption{grpc.WithConnectParams(grpc.ConnectParams{Backoff: cfg})}
+}
+
+// retryInterceptor re-sends idempotent RPCs that failed with codes.Unavailable
+// or that the server answered with RETRYABLE_ERROR
+func (c *LockClient) retryInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !idempotentMethods[path.Base(method)] {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	var err error
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		if attempt > 0 {
+			// Exponential backoff with jitter: somewhere between half and all of the delay
+			delay := c.retryDelay << (attempt - 1)
+			delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return err
+			}
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if !retryable(reply, err) {
+			return err
+		}
+	}
+	return err
+}
+
+// retryable reports whether an RPC outcome is worth another try: the server
+// was unreachable, or it reported a failure that may clear up
+func retryable(reply interface{}, err error) bool {
+	if err != nil {
+		return status.Code(err) == codes.Unavailable
+	}
+	r, ok := reply.(interface{ GetStatus() pb.Status })
+	return ok && r.GetStatus() == pb.Status_RETRYABLE_ERROR
+}
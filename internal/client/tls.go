@@ -0,0 +1,47 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// WithTLS connects over TLS using cfg instead of an insecure connection
+func WithTLS(cfg *tls.Config) Option {
+	return func(c *LockClient) {
+		c.tlsConfig = cfg
+	}
+}
+
+// LoadTLSConfig builds a client TLS configuration. caFile holds the PEM CAs
+// trusted to sign the server's certificate; if empty, the system roots are used.
+// serverName overrides the name checked against the certificate. certFile and
+// keyFile, if both set, are presented to servers that require mutual TLS.
+func LoadTLSConfig(caFile, serverName, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
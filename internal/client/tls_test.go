@@ -0,0 +1,129 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Distributed-Lock-Manager/internal/server"
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+)
+
+// writeSelfSignedCert writes a self-signed certificate for localhost, usable by
+// both servers and clients, and returns the certificate and key paths
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSRoundTrip(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+
+	for _, mutual := range []bool{false, true} {
+		name := "server-side"
+		clientCA := ""
+		if mutual {
+			name, clientCA = "mutual", certFile
+		}
+
+		t.Run(name, func(t *testing.T) {
+			creds, err := server.LoadTLSCredentials(certFile, keyFile, clientCA)
+			if err != nil {
+				t.Fatalf("LoadTLSCredentials failed: %v", err)
+			}
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed to listen: %v", err)
+			}
+			ls := server.NewLockServer(t.TempDir())
+			s := grpc.NewServer(grpc.Creds(creds))
+			pb.RegisterLockServiceServer(s, ls)
+			go s.Serve(lis)
+			defer func() {
+				s.Stop()
+				ls.Cleanup()
+			}()
+
+			clientCert, clientKey := "", ""
+			if mutual {
+				clientCert, clientKey = certFile, keyFile
+			}
+			cfg, err := LoadTLSConfig(certFile, "localhost", clientCert, clientKey)
+			if err != nil {
+				t.Fatalf("LoadTLSConfig failed: %v", err)
+			}
+			c, err := NewLockClient(lis.Addr().String(), 1, WithTLS(cfg))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			defer c.Close()
+
+			if err := c.Initialize(); err != nil {
+				t.Fatalf("Initialize over TLS failed: %v", err)
+			}
+			if err := c.AcquireLock(); err != nil {
+				t.Fatalf("AcquireLock over TLS failed: %v", err)
+			}
+			if err := c.AppendFile("file_0", []byte("over tls\n")); err != nil {
+				t.Fatalf("AppendFile over TLS failed: %v", err)
+			}
+			if err := c.ReleaseLock(); err != nil {
+				t.Fatalf("ReleaseLock over TLS failed: %v", err)
+			}
+
+			// A client that skips TLS can't talk to the server
+			plain, err := NewLockClient(lis.Addr().String(), 2)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			defer plain.conn.Close()
+			if err := plain.Initialize(); err == nil {
+				t.Error("Expected an insecure client to be rejected by a TLS server")
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"io"
+	"log"
+	"path"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+)
+
+// WithVerbose writes a line to w for every RPC attempt the client makes, with
+// the method, the client ID in the request, how long it took and the status
+// or error it ended with. Retried calls get a line per attempt.
+func WithVerbose(w io.Writer) Option {
+	return func(c *LockClient) {
+		c.verbose = log.New(w, "[LockClient] ", log.LstdFlags|log.Lmicroseconds)
+	}
+}
+
+// traceInterceptor logs each unary RPC to the verbose logger
+func (c *LockClient) traceInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	elapsed := time.Since(start)
+
+	name, clientID := path.Base(method), requestClientID(req)
+	if err != nil {
+		c.verbose.Printf("%s client=%d elapsed=%v error=%v", name, clientID, elapsed, err)
+	} else if r, ok := reply.(interface{ GetStatus() pb.Status }); ok {
+		c.verbose.Printf("%s client=%d elapsed=%v status=%v", name, clientID, elapsed, r.GetStatus())
+	} else {
+		c.verbose.Printf("%s client=%d elapsed=%v ok", name, clientID, elapsed)
+	}
+	return err
+}
+
+// requestClientID returns the client a request is made for. Connections in a
+// ClientPool carry requests from many clients, so the request is the only
+// reliable place to find it.
+func requestClientID(req interface{}) int32 {
+	switch r := req.(type) {
+	case interface{ GetClientId() int32 }:
+		return r.GetClientId()
+	case *pb.Int:
+		return r.Rc
+	case *pb.CasArgs:
+		return r.NewHolder
+	case *pb.TransferArgs:
+		return r.FromClientId
+	}
+	return -1
+}
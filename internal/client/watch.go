@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Bounds of the wait, doubling from one attempt to the next, before WatchLock
+// tries again to reopen a lost stream
+const (
+	watchBaseDelay = 100 * time.Millisecond
+	watchMaxDelay  = 5 * time.Second
+)
+
+// WatchLock subscribes to lock events: every grant, release and lease expiry
+// on any lock from the moment it returns, in order. If the stream is lost, for
+// example because the server restarted or the caller fell too far behind, it
+// is reopened with backoff, and a RESYNC event carrying the global lock's
+// current status is handed on before the live events resume: events in
+// between are lost, so state built from earlier events should be refetched.
+// The channel is closed when ctx is done or the server refuses the subscription.
+func (c *LockClient) WatchLock(ctx context.Context) (<-chan *pb.LockEvent, error) {
+	stream, cancel, err := c.subscribe(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("WatchLock failed: %v", err)
+	}
+
+	events := make(chan *pb.LockEvent)
+	go func() {
+		defer close(events)
+		defer func() { cancel() }()
+		send := func(ev *pb.LockEvent) bool {
+			select {
+			case events <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			ev, err := stream.Recv()
+			if err == nil {
+				if !send(ev) {
+					return
+				}
+				continue
+			}
+			cancel()
+			if !resubscribable(ctx, err) {
+				return
+			}
+			next, nextCancel, resync, err := c.resubscribe(ctx)
+			if err != nil {
+				return
+			}
+			stream, cancel = next, nextCancel
+			if !send(resync) {
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// subscribe opens a watch stream, returning once the server has subscribed it
+func (c *LockClient) subscribe(ctx context.Context) (grpc.ServerStreamingClient[pb.LockEvent], context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.client.WatchLock(ctx, &pb.Empty{})
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	// The server sends headers once subscribed, so every event after this returns is seen
+	if _, err := stream.Header(); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return stream, cancel, nil
+}
+
+// resubscribe reopens a lost watch stream, backing off between attempts, and
+// returns it with the RESYNC event to hand on first. The status is fetched
+// after subscribing, so no change is missed between the two. It gives up only
+// when ctx is done or the server refuses the subscription.
+func (c *LockClient) resubscribe(ctx context.Context) (grpc.ServerStreamingClient[pb.LockEvent], context.CancelFunc, *pb.LockEvent, error) {
+	delay := watchBaseDelay
+	for {
+		// Somewhere between half and all of the delay, so watchers don't all come back at once
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, nil, nil, ctx.Err()
+		}
+		delay = min(2*delay, watchMaxDelay)
+
+		stream, cancel, err := c.subscribe(ctx)
+		if err == nil {
+			var st *pb.LockStatusResponse
+			if st, err = c.LockStatus(); err == nil {
+				return stream, cancel, &pb.LockEvent{
+					Type:         pb.LockEventType_RESYNC,
+					Resource:     "global",
+					ClientId:     st.Holder,
+					TimeUnixNano: time.Now().UnixNano(),
+					Status:       st,
+				}, nil
+			}
+			cancel()
+		}
+		if !resubscribable(ctx, err) {
+			return nil, nil, nil, err
+		}
+	}
+}
+
+// resubscribable reports whether a watch stream that failed with err is worth
+// reopening: not once ctx is done, nor if the server refused the subscription
+func resubscribable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unimplemented, codes.PermissionDenied, codes.Unauthenticated:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package file_manager
+
+import (
+	"errors"
+	"syscall"
+)
+
+// ErrInvalidFilename is returned for names outside file_0 to file_<fileCount-1>,
+// or refused by the validator set with WithFilenameValidator
+var ErrInvalidFilename = errors.New("invalid filename")
+
+// ErrOffsetMismatch is returned by AppendAtAs when the file isn't the expected size
+var ErrOffsetMismatch = errors.New("file size doesn't match expected offset")
+
+// ErrFileTooLarge is returned when a write would take a file past the size set with WithMaxFileSize
+var ErrFileTooLarge = errors.New("file would exceed the maximum size")
+
+// transientErrnos are failures that can clear up on their own: interrupted or
+// would-block calls, exhausted file descriptors, and a full disk that may be
+// cleaned up
+var transientErrnos = []syscall.Errno{
+	syscall.EAGAIN,
+	syscall.EINTR,
+	syscall.EBUSY,
+	syscall.EMFILE,
+	syscall.ENFILE,
+	syscall.ENOSPC,
+}
+
+// IsTransient reports whether err, returned by a file operation, is worth
+// retrying later. Invalid filenames, offset mismatches, oversized files,
+// permission problems and anything not known to be temporary count as permanent.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrInvalidFilename) || errors.Is(err, ErrOffsetMismatch) || errors.Is(err, ErrFileTooLarge) {
+		return false
+	}
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
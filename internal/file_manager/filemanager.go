@@ -1,131 +1,923 @@
 package file_manager
 
 import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
+// DefaultDataDir is the directory files are stored in when none is configured
+const DefaultDataDir = "data"
+
+// DefaultFileCount is the number of managed files, file_0 to file_99, when none is configured
+const DefaultFileCount = 100
+
+// DefaultMaxOpenFiles is how many append handles are kept open when no limit is configured
+const DefaultMaxOpenFiles = 64
+
+// DefaultTransientRetries is how many times an append retries opening or
+// writing its file after a transient error before failing
+const DefaultTransientRetries = 3
+
+// DefaultRetryBackoff is the wait before the first retry of a transient error; it doubles each retry
+const DefaultRetryBackoff = 5 * time.Millisecond
+
 // FileManager handles all file-related operations
 type FileManager struct {
-	openFiles   map[string]*os.File    // Tracks open file handles
-	fileLocks   map[string]*sync.Mutex // Per-file mutexes for concurrency
-	mu          sync.Mutex             // Protects maps
+	openFiles   map[string]File          // Tracks open file handles
+	openOrder   *list.List               // Paths in openFiles, most recently used at the front
+	openElems   map[string]*list.Element // Each path's element in openOrder
+	maxOpen     int                      // Least recently used handles are closed beyond this; 0 means no limit
+	maxSize     int64                    // Writes that would make a file bigger fail; 0 means no limit
+	fileLocks   map[string]*sync.Mutex   // Per-file mutexes for concurrency
+	lastWriters map[string]writerInfo    // Most recent successful append per file
+	mu          sync.Mutex               // Protects maps
 	logger      *log.Logger
-	syncEnabled bool // Toggle for fsync after writes
+	syncEnabled bool               // Toggle for fsync after writes
+	fs          FS                 // Where the data files live
+	syncFile    func(f File) error // Syncs a file to disk; replaced by tests
+	retries     int                // Retries of an open or write that failed transiently
+	backoff     time.Duration      // Wait before the first of those retries
+	dataDir     string             // Directory holding the managed files
+	fileCount   int                // Files are named file_0 to file_<fileCount-1>
+	validator   FilenameValidator  // Replaces the file_N scheme; nil to keep it
+	stamp       bool               // Prefix each append with the time and the client
+	seed        []byte             // Content CreateFiles gives the files it creates
+}
+
+// writerInfo records which client last appended to a file and when
+type writerInfo struct {
+	clientID int32
+	at       time.Time
+}
+
+// ErrWouldOverwrite is returned by RestoreFiles when a file to restore already
+// has content and force wasn't set
+var ErrWouldOverwrite = errors.New("file already has content")
+
+// Stats describes a managed file
+type Stats struct {
+	Size       int64
+	ModTime    time.Time // Modification time reported by the filesystem
+	LastWriter int32     // Client that last appended through this manager, -1 if unknown
+	LastWrite  time.Time // Time of that append, zero if unknown
+
+	// Only filled in by Scan
+	Lines  int64  // Newline-terminated lines, plus a trailing partial line if any
+	SHA256 []byte // Checksum of the content
+}
+
+// Option configures optional FileManager settings
+type Option func(*FileManager)
+
+// WithDataDir sets the directory the file manager reads and writes files in
+func WithDataDir(dir string) Option {
+	return func(fm *FileManager) {
+		fm.dataDir = dir
+	}
+}
+
+// WithLogger replaces the file manager's default stdout logger
+func WithLogger(l *log.Logger) Option {
+	return func(fm *FileManager) {
+		fm.logger = l
+	}
+}
+
+// WithFileCount sets how many files are managed, file_0 to file_<n-1>
+func WithFileCount(n int) Option {
+	return func(fm *FileManager) {
+		fm.fileCount = n
+	}
+}
+
+// WithSeed makes CreateFiles write content into each file it creates, instead
+// of leaving it empty. Files that already exist are left as they are.
+func WithSeed(content []byte) Option {
+	return func(fm *FileManager) {
+		fm.seed = content
+	}
+}
+
+// WithMaxOpenFiles caps how many append handles are kept open between appends.
+// When the cap is exceeded the least recently used handle is closed, and
+// reopened on its file's next append. 0 keeps every handle open.
+func WithMaxOpenFiles(n int) Option {
+	return func(fm *FileManager) {
+		fm.maxOpen = n
+	}
+}
+
+// WithTransientRetries sets how many times an append retries opening or writing
+// its file after a transient error (see IsTransient), waiting backoff before
+// the first retry and twice as long before each one after. 0 fails straight away.
+func WithTransientRetries(n int, backoff time.Duration) Option {
+	return func(fm *FileManager) {
+		fm.retries = n
+		fm.backoff = backoff
+	}
+}
+
+// WithMaxFileSize refuses appends and writes that would make a file larger
+// than n bytes with ErrFileTooLarge. 0 removes the limit.
+func WithMaxFileSize(n int64) Option {
+	return func(fm *FileManager) {
+		fm.maxSize = n
+	}
+}
+
+// StampLayout is the layout of the time WithAppendStamps puts before each
+// append: RFC 3339 in UTC with milliseconds, so stamps are all the same width
+const StampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// WithAppendStamps prefixes every append with the time it was written and the
+// client that wrote it, as "<time> client=<id> ", so all writers produce
+// uniform records. The stamp is taken under the per-file lock, so the times in
+// a file never go backwards. Only the start of each append is stamped, not
+// every line in it, and writes aren't stamped at all.
+func WithAppendStamps() Option {
+	return func(fm *FileManager) {
+		fm.stamp = true
+	}
+}
+
+// FilenameValidator decides which names are valid file names, returning an
+// error saying why a name is refused. Names with path separators or ".." are
+// refused before a validator sees them, so it need not guard against traversal.
+type FilenameValidator func(filename string) error
+
+// WithFilenameValidator replaces the file_0 to file_<n-1> naming scheme with
+// validate. Every file a validator accepts lives directly in the data directory.
+func WithFilenameValidator(validate FilenameValidator) Option {
+	return func(fm *FileManager) {
+		fm.validator = validate
+	}
+}
+
+// MatchFilenames returns a validator accepting names that match pattern in
+// full, such as `log_[a-z]+`
+func MatchFilenames(pattern string) (FilenameValidator, error) {
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("filename pattern: %w", err)
+	}
+	return func(filename string) error {
+		if !re.MatchString(filename) {
+			return fmt.Errorf("doesn't match %s", pattern)
+		}
+		return nil
+	}, nil
 }
 
 // NewFileManager initializes a new file manager
-func NewFileManager(syncEnabled bool) *FileManager {
-	return &FileManager{
-		openFiles:   make(map[string]*os.File),
+func NewFileManager(syncEnabled bool, opts ...Option) *FileManager {
+	fm := &FileManager{
+		openFiles:   make(map[string]File),
+		openOrder:   list.New(),
+		openElems:   make(map[string]*list.Element),
+		maxOpen:     DefaultMaxOpenFiles,
 		fileLocks:   make(map[string]*sync.Mutex),
+		lastWriters: make(map[string]writerInfo),
 		logger:      log.New(os.Stdout, "[FileManager] ", log.LstdFlags),
 		syncEnabled: syncEnabled,
+		fs:          OSFS{},
+		syncFile:    File.Sync,
+		retries:     DefaultTransientRetries,
+		backoff:     DefaultRetryBackoff,
+		dataDir:     DefaultDataDir,
+		fileCount:   DefaultFileCount,
+	}
+	for _, opt := range opts {
+		opt(fm)
 	}
+
+	// Pin the data directory to an absolute path so later working directory
+	// changes can't redirect where files are written
+	if abs, err := ResolveDataDir(fm.dataDir); err == nil {
+		fm.dataDir = abs
+	} else {
+		fm.logger.Printf("Warning: couldn't resolve data directory %s: %v", fm.dataDir, err)
+	}
+	return fm
 }
 
-// AppendToFile appends content to a file
-func (fm *FileManager) AppendToFile(filename string, content []byte) error {
-	fm.logger.Printf("Attempting to append to %s", filename)
+// ResolveDataDir returns the absolute, cleaned form of dir. Symlinks are kept
+// as-is so a linked data directory is followed on every access.
+func ResolveDataDir(dir string) (string, error) {
+	if dir == "" {
+		dir = DefaultDataDir
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolve data directory %q: %w", dir, err)
+	}
+	return abs, nil
+}
+
+// CheckWritable proves the data directory accepts writes by creating and
+// removing a file in it, creating the directory first if it's missing
+func (fm *FileManager) CheckWritable() error {
+	if err := fm.fs.MkdirAll(fm.dataDir, 0755); err != nil {
+		return err
+	}
+	f, err := fm.fs.CreateTemp(fm.dataDir, ".ready-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	fm.fs.Remove(f.Name())
+	return nil
+}
+
+// DataDir returns the directory the file manager stores files in
+func (fm *FileManager) DataDir() string {
+	return fm.dataDir
+}
+
+// SyncEnabled reports whether writes are fsynced before they are acknowledged
+func (fm *FileManager) SyncEnabled() bool {
+	return fm.syncEnabled
+}
+
+// FileCount returns the number of files the file manager manages
+func (fm *FileManager) FileCount() int {
+	return fm.fileCount
+}
+
+// ValidatePath checks that filename is a plain name whose path stays directly
+// inside dataDir: it must have no path separators or "..", survive
+// filepath.Clean unchanged, and resolve to a child of dataDir. This holds
+// whatever naming scheme is in use, so a relaxed scheme can't open up path
+// traversal.
+func ValidatePath(dataDir, filename string) error {
+	if filename == "" || filename == "." || strings.ContainsAny(filename, "/\\\x00") || strings.Contains(filename, "..") {
+		return fmt.Errorf("%w: must be a plain name without path separators or ..", ErrInvalidFilename)
+	}
+	if filepath.Clean(filename) != filename {
+		return fmt.Errorf("%w: not a clean path", ErrInvalidFilename)
+	}
+	dir := filepath.Clean(dataDir)
+	if filepath.Dir(filepath.Join(dir, filename)) != dir {
+		return fmt.Errorf("%w: resolves outside the data directory", ErrInvalidFilename)
+	}
+	return nil
+}
+
+// ValidateFilename reports whether appends and writes to filename would be
+// accepted, returning an ErrInvalidFilename error saying why not, without
+// touching the file
+func (fm *FileManager) ValidateFilename(filename string) error {
+	return fm.validateFilename(filename)
+}
+
+// validateFilename checks that filename names a file directly inside the data
+// directory and is accepted by the validator, or is one of "file_0" to
+// "file_<fileCount-1>" if there is none
+func (fm *FileManager) validateFilename(filename string) error {
+	if err := ValidatePath(fm.dataDir, filename); err != nil {
+		return err
+	}
+	if fm.validator != nil {
+		if err := fm.validator(filename); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFilename, err)
+		}
+		return nil
+	}
 
-	// Validate filename (must be "file_0" to "file_99")
 	if !strings.HasPrefix(filename, "file_") {
-		fm.logger.Printf("File append failed: invalid filename format %s", filename)
-		return fmt.Errorf("invalid filename format")
+		return fmt.Errorf("%w format", ErrInvalidFilename)
 	}
 
 	numStr := strings.TrimPrefix(filename, "file_")
 	num, err := strconv.Atoi(numStr)
-	if err != nil || num < 0 || num >= 100 {
-		fm.logger.Printf("File append failed: invalid file number %s", numStr)
-		return fmt.Errorf("invalid file number")
+	if err != nil || num < 0 || num >= fm.fileCount {
+		return fmt.Errorf("%w: file number out of range", ErrInvalidFilename)
+	}
+	return nil
+}
+
+// fileLock returns the mutex guarding fullPath, creating it if needed
+func (fm *FileManager) fileLock(fullPath string) *sync.Mutex {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if _, exists := fm.fileLocks[fullPath]; !exists {
+		fm.fileLocks[fullPath] = &sync.Mutex{}
+	}
+	return fm.fileLocks[fullPath]
+}
+
+// AppendToFile appends content to a file
+func (fm *FileManager) AppendToFile(filename string, content []byte) error {
+	return fm.AppendToFileAs(-1, filename, content)
+}
+
+// AppendToFileAs appends content to a file on behalf of clientID, recording
+// it as the file's last writer. Each append lands contiguously, whatever its
+// size: appends to one file are serialized, never interleave, and a failed
+// append leaves no partial content.
+func (fm *FileManager) AppendToFileAs(clientID int32, filename string, content []byte) error {
+	_, _, err := fm.appendAt(clientID, filename, content, -1)
+	return err
+}
+
+// AppendAtAs appends content on behalf of clientID only if the file is
+// currently expectedSize bytes long, checked under the same per-file lock as
+// the write. It returns the offset the append was written at and the file's
+// size afterwards; on ErrOffsetMismatch both are the unchanged actual size.
+func (fm *FileManager) AppendAtAs(clientID int32, filename string, content []byte, expectedSize int64) (offset, size int64, err error) {
+	return fm.appendAt(clientID, filename, content, expectedSize)
+}
+
+// appendAt does the work of AppendToFileAs and AppendAtAs; a negative
+// expectedSize appends whatever the file's size
+func (fm *FileManager) appendAt(clientID int32, filename string, content []byte, expectedSize int64) (offset, size int64, err error) {
+	fm.logger.Printf("Attempting to append to %s", filename)
+
+	// Validate filename (must be "file_0" to "file_<fileCount-1>")
+	if err := fm.validateFilename(filename); err != nil {
+		fm.logger.Printf("File append failed: %v: %s", err, filename)
+		return 0, 0, err
 	}
 
-	// Prepend "data/" to the filename
-	fullPath := filepath.Join("data", filename)
+	// Resolve the filename inside the data directory
+	fullPath := filepath.Join(fm.dataDir, filename)
 
 	// Ensure the data directory exists
-	if err := os.MkdirAll("data", 0755); err != nil {
+	if err := fm.fs.MkdirAll(fm.dataDir, 0755); err != nil {
 		fm.logger.Printf("File append failed: couldn't create data directory: %v", err)
-		return err
+		return 0, 0, err
 	}
 
-	// Get or create a mutex for this file
+	// Lock this specific file for writing
+	fileMutex := fm.fileLock(fullPath)
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+
+	f, err := fm.appendHandle(fullPath)
+	for attempt := 1; err != nil && fm.retryable(err, attempt); attempt++ {
+		f, err = fm.appendHandle(fullPath)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		// The data directory went away between creating it above and opening
+		// the file; put it back and try once more
+		fm.logger.Printf("Warning: data directory %s disappeared; recreating it", fm.dataDir)
+		if err = fm.fs.MkdirAll(fm.dataDir, 0755); err == nil {
+			f, err = fm.appendHandle(fullPath)
+		}
+	}
+	if err != nil {
+		fm.logger.Printf("File append failed: couldn't open file: %v", err)
+		return 0, 0, err
+	}
+
+	// A cached handle outlives its file if the file, or the whole data
+	// directory, is deleted underneath it. Appends would then vanish into the
+	// unlinked file, so the file is recreated and reopened instead.
+	if _, err := fm.fs.Stat(fullPath); errors.Is(err, os.ErrNotExist) {
+		fm.logger.Printf("Warning: %s was removed while open; recreating it", fullPath)
+		fm.mu.Lock()
+		f.Close()
+		fm.forgetHandle(fullPath)
+		fm.mu.Unlock()
+		if err := fm.fs.MkdirAll(fm.dataDir, 0755); err != nil {
+			fm.logger.Printf("File append failed: couldn't recreate data directory: %v", err)
+			return 0, 0, err
+		}
+		if f, err = fm.appendHandle(fullPath); err != nil {
+			fm.logger.Printf("File append failed: couldn't reopen file: %v", err)
+			return 0, 0, err
+		}
+	}
+
+	// O_APPEND alone only makes small writes atomic, and not on every
+	// filesystem, so appends of any size are serialized by the per-file lock
+	// held above. If a write fails part way, the file is cut back to where the
+	// append started so no torn record is left behind.
+	info, err := f.Stat()
+	if err != nil {
+		fm.logger.Printf("File append failed: couldn't stat file: %v", err)
+		return 0, 0, err
+	}
+	if expectedSize >= 0 && info.Size() != expectedSize {
+		fm.logger.Printf("File append refused: %s is %d bytes, expected %d", filename, info.Size(), expectedSize)
+		return info.Size(), info.Size(), ErrOffsetMismatch
+	}
+	if fm.stamp {
+		content = stamped(time.Now(), clientID, content)
+	}
+	if fm.maxSize > 0 && info.Size()+int64(len(content)) > fm.maxSize {
+		fm.logger.Printf("File append refused: %s is %d bytes, %d more would exceed the %d byte limit", filename, info.Size(), len(content), fm.maxSize)
+		return info.Size(), info.Size(), ErrFileTooLarge
+	}
+	// Only a write that wrote nothing is retried, so the file never has to be
+	// cut back between attempts
+	n, err := f.Write(content)
+	for attempt := 1; err != nil && n == 0 && fm.retryable(err, attempt); attempt++ {
+		n, err = f.Write(content)
+	}
+	if err != nil {
+		fm.logger.Printf("File append failed: couldn't write to file: %v", err)
+		if terr := f.Truncate(info.Size()); terr != nil {
+			fm.logger.Printf("File append warning: couldn't roll back partial write: %v", terr)
+		}
+		return info.Size(), info.Size(), err
+	}
+
+	// Ensure data is written to disk if enabled. An append that can't be made
+	// durable is rolled back and failed rather than acknowledged.
+	if fm.syncEnabled {
+		if err := fm.syncFile(f); err != nil {
+			fm.logger.Printf("File append failed: couldn't sync file: %v", err)
+			if terr := f.Truncate(info.Size()); terr != nil {
+				fm.logger.Printf("File append warning: couldn't roll back unsynced write: %v", terr)
+			}
+			return info.Size(), info.Size(), err
+		}
+	}
+
+	// Record the writer while still holding the per-file lock so the
+	// last-writer entry always matches the last append on disk
 	fm.mu.Lock()
-	if _, exists := fm.fileLocks[fullPath]; !exists {
-		fm.fileLocks[fullPath] = &sync.Mutex{}
+	fm.lastWriters[filename] = writerInfo{clientID: clientID, at: time.Now()}
+	fm.mu.Unlock()
+
+	fm.logger.Printf("Successfully appended %d bytes to %s", len(content), fullPath)
+	return info.Size(), info.Size() + int64(len(content)), nil
+}
+
+// stamped returns content prefixed as WithAppendStamps describes
+func stamped(at time.Time, clientID int32, content []byte) []byte {
+	prefix := fmt.Sprintf("%s client=%d ", at.UTC().Format(StampLayout), clientID)
+	return append([]byte(prefix), content...)
+}
+
+// appendHandle returns the cached append handle for fullPath, opening and
+// caching one if there isn't one. Every handle is opened with O_APPEND so
+// writes land at the current end of file even if something else has grown it.
+// Must be called with fullPath's file lock held.
+func (fm *FileManager) appendHandle(fullPath string) (File, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if f, exists := fm.openFiles[fullPath]; exists {
+		fm.openOrder.MoveToFront(fm.openElems[fullPath])
+		return f, nil
+	}
+	if _, err := fm.fs.Stat(fullPath); os.IsNotExist(err) {
+		fm.logger.Printf("Creating new file: %s", fullPath)
+	}
+	f, err := fm.fs.OpenFile(fullPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fm.openFiles[fullPath] = f
+	fm.openElems[fullPath] = fm.openOrder.PushFront(fullPath)
+	fm.evictHandles()
+	return f, nil
+}
+
+// retryable reports whether an open or write that failed with err should be
+// tried again as the given attempt, and if so waits before it's made. Only
+// transient errors are retried, at most fm.retries times.
+func (fm *FileManager) retryable(err error, attempt int) bool {
+	if !IsTransient(err) || attempt > fm.retries {
+		return false
+	}
+	wait := fm.backoff << (attempt - 1)
+	fm.logger.Printf("Warning: transient file error, retrying in %v: %v", wait, err)
+	time.Sleep(wait)
+	return true
+}
+
+// Append is one entry of a batched append
+type Append struct {
+	Filename string
+	Content  []byte
+}
+
+// AppendBatchAs appends each entry in order on behalf of clientID. Every
+// filename is validated before anything is written, so a bad name fails the
+// whole batch untouched. If an append fails, the entries before it stay
+// written and its index is returned with the error.
+func (fm *FileManager) AppendBatchAs(clientID int32, entries []Append) (int, error) {
+	for i, e := range entries {
+		if err := fm.validateFilename(e.Filename); err != nil {
+			fm.logger.Printf("Batch append failed: %v: %s", err, e.Filename)
+			return i, err
+		}
+	}
+	for i, e := range entries {
+		if err := fm.AppendToFileAs(clientID, e.Filename, e.Content); err != nil {
+			return i, err
+		}
+	}
+	return -1, nil
+}
+
+// WriteFileAs replaces the contents of a file on behalf of clientID, recording
+// it as the file's last writer. The new content is written to a temporary file
+// and renamed into place, so readers see either the old or the new content.
+func (fm *FileManager) WriteFileAs(clientID int32, filename string, content []byte) error {
+	fm.logger.Printf("Attempting to write %s", filename)
+
+	if err := fm.validateFilename(filename); err != nil {
+		fm.logger.Printf("File write failed: %v: %s", err, filename)
+		return err
+	}
+	if fm.maxSize > 0 && int64(len(content)) > fm.maxSize {
+		fm.logger.Printf("File write refused: %d bytes would exceed the %d byte limit", len(content), fm.maxSize)
+		return ErrFileTooLarge
+	}
+	if err := fm.fs.MkdirAll(fm.dataDir, 0755); err != nil {
+		fm.logger.Printf("File write failed: couldn't create data directory: %v", err)
+		return err
+	}
+
+	if err := fm.replaceFile(filename, content, func() {
+		fm.lastWriters[filename] = writerInfo{clientID: clientID, at: time.Now()}
+	}); err != nil {
+		fm.logger.Printf("File write failed: %v", err)
+		return err
+	}
+
+	fm.logger.Printf("Successfully wrote %d bytes to %s", len(content), filename)
+	return nil
+}
+
+// TruncateFileAs empties a file in place on behalf of clientID, recording it
+// as the file's last writer. Unlike WriteFileAs the file keeps its identity,
+// and any cached append handle stays valid: it appends at the end of file, so
+// the next append starts at offset 0.
+func (fm *FileManager) TruncateFileAs(clientID int32, filename string) error {
+	fm.logger.Printf("Attempting to truncate %s", filename)
+
+	if err := fm.validateFilename(filename); err != nil {
+		fm.logger.Printf("File truncate failed: %v: %s", err, filename)
+		return err
+	}
+	if err := fm.fs.MkdirAll(fm.dataDir, 0755); err != nil {
+		fm.logger.Printf("File truncate failed: couldn't create data directory: %v", err)
+		return err
+	}
+
+	fullPath := filepath.Join(fm.dataDir, filename)
+	fileMutex := fm.fileLock(fullPath)
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+
+	f, err := fm.fs.OpenFile(fullPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		fm.logger.Printf("File truncate failed: %v", err)
+		return err
+	}
+	if fm.syncEnabled {
+		if err := fm.syncFile(f); err != nil {
+			f.Close()
+			fm.logger.Printf("File truncate failed: couldn't sync file: %v", err)
+			return err
+		}
 	}
-	fileMutex := fm.fileLocks[fullPath]
+	if err := f.Close(); err != nil {
+		fm.logger.Printf("File truncate failed: %v", err)
+		return err
+	}
+
+	fm.mu.Lock()
+	fm.lastWriters[filename] = writerInfo{clientID: clientID, at: time.Now()}
 	fm.mu.Unlock()
 
-	// Lock this specific file for writing
+	fm.logger.Printf("Successfully truncated %s", fullPath)
+	return nil
+}
+
+// ReadFile returns the full contents of a file
+func (fm *FileManager) ReadFile(filename string) ([]byte, error) {
+	if err := fm.validateFilename(filename); err != nil {
+		fm.logger.Printf("File read failed: %v: %s", err, filename)
+		return nil, err
+	}
+
+	fullPath := filepath.Join(fm.dataDir, filename)
+
+	// Hold the per-file lock so we never observe a partially written append
+	fileMutex := fm.fileLock(fullPath)
 	fileMutex.Lock()
 	defer fileMutex.Unlock()
 
-	// Get or open the file
-	var f *os.File
+	content, err := fm.readFile(fullPath)
+	if err != nil {
+		fm.logger.Printf("File read failed: %v", err)
+		return nil, err
+	}
+	return content, nil
+}
+
+// Stat returns the size and last writer of a file without reading its content
+func (fm *FileManager) Stat(filename string) (Stats, error) {
+	return fm.stat(filename, false)
+}
+
+// Scan is Stat plus the line count and checksum, which take a full read of the file
+func (fm *FileManager) Scan(filename string) (Stats, error) {
+	return fm.stat(filename, true)
+}
+
+// stat does the work of Stat and Scan. The file is locked throughout so the
+// size, line count and checksum all describe the same content.
+func (fm *FileManager) stat(filename string, scan bool) (Stats, error) {
+	if err := fm.validateFilename(filename); err != nil {
+		return Stats{}, err
+	}
+
+	fullPath := filepath.Join(fm.dataDir, filename)
+
+	fileMutex := fm.fileLock(fullPath)
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+
+	info, err := fm.fs.Stat(fullPath)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{Size: info.Size(), ModTime: info.ModTime(), LastWriter: -1}
 	fm.mu.Lock()
-	f, exists := fm.openFiles[fullPath]
-	if !exists {
-		// Create the file if it doesn't exist
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-			fm.logger.Printf("Creating new file: %s", fullPath)
-			f, err = os.Create(fullPath)
+	if w, ok := fm.lastWriters[filename]; ok {
+		stats.LastWriter = w.clientID
+		stats.LastWrite = w.at
+	}
+	fm.mu.Unlock()
+
+	if scan {
+		if stats.Lines, stats.SHA256, err = fm.scanFile(fullPath); err != nil {
+			return Stats{}, err
+		}
+	}
+	return stats, nil
+}
+
+// scanFile counts the lines in a file and checksums it in one pass
+func (fm *FileManager) scanFile(path string) (int64, []byte, error) {
+	f, err := fm.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	buf := make([]byte, 32*1024)
+	var lines int64
+	var last byte = '\n'
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			h.Write(buf[:n])
+			lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	return lines, h.Sum(nil), nil
+}
+
+// Filenames returns the names of the managed files that exist: those of
+// file_0 to file_<n-1> in file number order, or with a validator set, the
+// files in the data directory it accepts in name order
+func (fm *FileManager) Filenames() ([]string, error) {
+	candidates, err := fm.candidates()
+	if err != nil {
+		return nil, err
+	}
+	names := candidates[:0]
+	for _, filename := range candidates {
+		if _, err := fm.fs.Stat(filepath.Join(fm.dataDir, filename)); err == nil {
+			names = append(names, filename)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// candidates returns every name a managed file may have, in a fixed order:
+// file_0 to file_<n-1>, or with a validator set, the accepted files in the
+// data directory sorted by name
+func (fm *FileManager) candidates() ([]string, error) {
+	if fm.validator == nil {
+		names := make([]string, fm.fileCount)
+		for i := range names {
+			names[i] = fmt.Sprintf("file_%d", i)
+		}
+		return names, nil
+	}
+
+	entries, err := fm.fs.ReadDir(fm.dataDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && fm.validateFilename(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ForEachFile calls fn with the name and content of every managed file that
+// exists, in the order of Filenames. With consistent set, all files are read while
+// holding every per-file lock, so the result is a single point in time;
+// otherwise each file is read under its own lock and appends may land between
+// files. fn is never called with file locks held.
+func (fm *FileManager) ForEachFile(consistent bool, fn func(filename string, content []byte) error) error {
+	candidates, err := fm.candidates()
+	if err != nil {
+		return err
+	}
+	if !consistent {
+		for _, filename := range candidates {
+			content, err := fm.ReadFile(filename)
+			if os.IsNotExist(err) {
+				continue
+			}
 			if err != nil {
-				fm.mu.Unlock()
-				fm.logger.Printf("File append failed: couldn't create file: %v", err)
 				return err
 			}
-		} else {
-			// Open existing file
-			f, err = os.OpenFile(fullPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err := fn(filename, content); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Take every file lock in a fixed order so concurrent snapshots can't deadlock
+	names := make([]string, 0, len(candidates))
+	contents := make([][]byte, 0, len(candidates))
+	err = func() error {
+		for _, filename := range candidates {
+			fileMutex := fm.fileLock(filepath.Join(fm.dataDir, filename))
+			fileMutex.Lock()
+			defer fileMutex.Unlock()
+		}
+		for _, filename := range candidates {
+			content, err := fm.readFile(filepath.Join(fm.dataDir, filename))
+			if os.IsNotExist(err) {
+				continue
+			}
 			if err != nil {
-				fm.mu.Unlock()
-				fm.logger.Printf("File append failed: couldn't open file: %v", err)
 				return err
 			}
+			names = append(names, filename)
+			contents = append(contents, content)
 		}
-		fm.openFiles[fullPath] = f
+		return nil
+	}()
+	if err != nil {
+		fm.logger.Printf("Snapshot failed: %v", err)
+		return err
 	}
-	fm.mu.Unlock()
 
-	// Append content to the file
-	_, err = f.Write(content)
+	for i, filename := range names {
+		if err := fn(filename, contents[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreFiles replaces the given files with the given contents. Every name is
+// validated, and unless force is set every target must be missing or empty,
+// before anything is written, so a rejected restore leaves the data untouched.
+// Each file is replaced atomically under its per-file lock.
+func (fm *FileManager) RestoreFiles(files map[string][]byte, force bool) error {
+	names := make([]string, 0, len(files))
+	for filename := range files {
+		if err := fm.validateFilename(filename); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		if !force {
+			info, err := fm.fs.Stat(filepath.Join(fm.dataDir, filename))
+			if err == nil && info.Size() > 0 {
+				return fmt.Errorf("%s: %w", filename, ErrWouldOverwrite)
+			}
+		}
+		names = append(names, filename)
+	}
+
+	if err := fm.fs.MkdirAll(fm.dataDir, 0755); err != nil {
+		return err
+	}
+	for _, filename := range names {
+		if err := fm.replaceFile(filename, files[filename], nil); err != nil {
+			fm.logger.Printf("Restore of %s failed: %v", filename, err)
+			return err
+		}
+	}
+	fm.logger.Printf("Restored %d files", len(names))
+	return nil
+}
+
+// replaceFile atomically swaps in new content for filename. recordWriter, if
+// set, is called with fm.mu held once the new content is in place, so the
+// last-writer entry can be updated before anyone else touches the file.
+func (fm *FileManager) replaceFile(filename string, content []byte, recordWriter func()) error {
+	fullPath := filepath.Join(fm.dataDir, filename)
+
+	fileMutex := fm.fileLock(fullPath)
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+
+	tmp, err := fm.fs.CreateTemp(fm.dataDir, filename+".restore*")
 	if err != nil {
-		fm.logger.Printf("File append failed: couldn't write to file: %v", err)
 		return err
 	}
+	defer fm.fs.Remove(tmp.Name()) // No-op once the rename succeeds
 
-	// Ensure data is written to disk if enabled
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
 	if fm.syncEnabled {
-		if err := f.Sync(); err != nil {
-			fm.logger.Printf("File append warning: couldn't sync file: %v", err)
+		if err := fm.syncFile(tmp); err != nil {
+			tmp.Close()
+			return err
 		}
 	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := fm.fs.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := fm.fs.Rename(tmp.Name(), fullPath); err != nil {
+		return err
+	}
 
-	fm.logger.Printf("Successfully appended %d bytes to %s", len(content), fullPath)
+	// Any cached append handle still points at the replaced file
+	fm.mu.Lock()
+	if f, ok := fm.openFiles[fullPath]; ok {
+		f.Close()
+		fm.forgetHandle(fullPath)
+	}
+	delete(fm.lastWriters, filename)
+	if recordWriter != nil {
+		recordWriter()
+	}
+	fm.mu.Unlock()
 	return nil
 }
 
-// CreateFiles ensures the 100 files exist
-func (fm *FileManager) CreateFiles() {
-	// Create data directory if it doesn't exist
-	if err := os.MkdirAll("data", 0755); err != nil {
-		fm.logger.Fatalf("Failed to create data directory: %v", err)
+// CreateFiles ensures all managed files exist, creating the data directory if
+// needed. With a validator set there is no fixed set of files, so only the
+// directory is created.
+func (fm *FileManager) CreateFiles() error {
+	if err := fm.fs.MkdirAll(fm.dataDir, 0755); err != nil {
+		return fmt.Errorf("create data directory: %w", err)
+	}
+	if fm.validator != nil {
+		return nil
 	}
 
-	for i := 0; i < 100; i++ {
-		filename := fmt.Sprintf("data/file_%d", i)
+	for i := 0; i < fm.fileCount; i++ {
+		filename := filepath.Join(fm.dataDir, fmt.Sprintf("file_%d", i))
 		// Create file only if it doesn't exist
-		if _, err := os.Stat(filename); os.IsNotExist(err) {
-			f, err := os.Create(filename)
+		if _, err := fm.fs.Stat(filename); os.IsNotExist(err) {
+			f, err := fm.fs.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 			if err != nil {
-				fm.logger.Fatalf("Failed to create file %s: %v", filename, err)
+				return fmt.Errorf("create file: %w", err)
+			}
+			if len(fm.seed) > 0 {
+				if _, err := f.Write(fm.seed); err != nil {
+					// Remove the partly seeded file, or the next run would keep it
+					f.Close()
+					fm.fs.Remove(filename)
+					return fmt.Errorf("seed file: %w", err)
+				}
 			}
 			f.Close()
 			fm.logger.Printf("Created file: %s", filename)
@@ -133,19 +925,89 @@ func (fm *FileManager) CreateFiles() {
 	}
 
 	fm.logger.Printf("All files created successfully")
+	return nil
 }
 
-// Cleanup closes any open files
+// evictHandles closes least recently used append handles until no more than
+// maxOpen are open. A handle whose file is mid-append is skipped rather than
+// waited for, since the caller may itself hold a file lock; the count can then
+// briefly exceed the cap until a later open evicts it. Must be called with fm.mu held.
+func (fm *FileManager) evictHandles() {
+	if fm.maxOpen <= 0 {
+		return
+	}
+	for el := fm.openOrder.Back(); el != nil && len(fm.openFiles) > fm.maxOpen; {
+		fullPath := el.Value.(string)
+		el = el.Prev()
+
+		// Every handle was opened under its file's lock, so the lock exists
+		fileMutex := fm.fileLocks[fullPath]
+		if !fileMutex.TryLock() {
+			continue
+		}
+		if err := fm.openFiles[fullPath].Close(); err != nil {
+			fm.logger.Printf("Error closing evicted file %s: %v", fullPath, err)
+		}
+		fm.forgetHandle(fullPath)
+		fileMutex.Unlock()
+	}
+}
+
+// Flush syncs every open append handle and closes those whose file isn't
+// mid-append, releasing the memory they hold. Closed files are reopened on
+// their next append.
+func (fm *FileManager) Flush() {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	for fullPath, f := range fm.openFiles {
+		fileMutex := fm.fileLocks[fullPath]
+		if !fileMutex.TryLock() {
+			continue
+		}
+		if err := f.Sync(); err != nil {
+			fm.logger.Printf("Error flushing file %s: %v", fullPath, err)
+		}
+		if err := f.Close(); err != nil {
+			fm.logger.Printf("Error closing file %s: %v", fullPath, err)
+		}
+		fm.forgetHandle(fullPath)
+		fileMutex.Unlock()
+	}
+}
+
+// forgetHandle drops fullPath's append handle from the cache without closing
+// it. Must be called with fm.mu held.
+func (fm *FileManager) forgetHandle(fullPath string) {
+	if el, ok := fm.openElems[fullPath]; ok {
+		fm.openOrder.Remove(el)
+		delete(fm.openElems, fullPath)
+	}
+	delete(fm.openFiles, fullPath)
+}
+
+// OpenFileCount returns the number of append handles currently held open
+func (fm *FileManager) OpenFileCount() int {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return len(fm.openFiles)
+}
+
+// Cleanup flushes and closes any open files
 func (fm *FileManager) Cleanup() {
 	// Close all open file handles
 	fm.mu.Lock()
 	defer fm.mu.Unlock()
 
 	for name, file := range fm.openFiles {
+		// Appends may not have been synced as they were written
+		if err := file.Sync(); err != nil {
+			fm.logger.Printf("Error flushing file %s: %v", name, err)
+		}
 		if err := file.Close(); err != nil {
 			fm.logger.Printf("Error closing file %s: %v", name, err)
 		}
-		delete(fm.openFiles, name)
+		fm.forgetHandle(name)
 	}
 
 	fm.logger.Println("File manager cleanup complete")
@@ -2,14 +2,19 @@ package file_manager
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -129,6 +134,176 @@ func TestBasicFileOperations(t *testing.T) {
 	}
 }
 
+func TestReadFile(t *testing.T) {
+	_, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	fm := NewFileManager(false)
+	defer fm.Cleanup()
+
+	// Append known content, then read it back
+	want := []byte("line one\nline two\n")
+	if err := fm.AppendToFile("file_5", want[:9]); err != nil {
+		t.Fatalf("AppendToFile failed: %v", err)
+	}
+	if err := fm.AppendToFile("file_5", want[9:]); err != nil {
+		t.Fatalf("AppendToFile failed: %v", err)
+	}
+
+	got, err := fm.ReadFile("file_5")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ReadFile content mismatch. Got %q, want %q", got, want)
+	}
+
+	// Reads are validated the same way as appends
+	if _, err := fm.ReadFile("file_100"); err == nil {
+		t.Error("ReadFile should fail with an invalid filename")
+	}
+}
+
+func TestMaxFileSize(t *testing.T) {
+	const limit = 100
+	fm := NewFileManager(false, WithDataDir(t.TempDir()), WithMaxFileSize(limit))
+	defer fm.Cleanup()
+
+	// Many appenders race for the last bytes; the size check and the write
+	// happen under one lock, so the file lands exactly on the limit
+	var wg sync.WaitGroup
+	var accepted, refused atomic.Int32
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch err := fm.AppendToFile("file_0", []byte("0123456789")); {
+			case err == nil:
+				accepted.Add(1)
+			case errors.Is(err, ErrFileTooLarge):
+				refused.Add(1)
+			default:
+				t.Errorf("Unexpected append error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted.Load() != limit/10 || refused.Load() != 30-limit/10 {
+		t.Errorf("Expected %d appends accepted and the rest refused, got %d and %d", limit/10, accepted.Load(), refused.Load())
+	}
+
+	stats, err := fm.Stat("file_0")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if stats.Size != limit {
+		t.Errorf("Expected file_0 to be %d bytes, got %d", limit, stats.Size)
+	}
+	if err := fm.AppendToFile("file_0", []byte("x")); !errors.Is(err, ErrFileTooLarge) || IsTransient(err) {
+		t.Errorf("Expected a permanent ErrFileTooLarge for one more byte, got %v", err)
+	}
+}
+
+func TestSyncedAppends(t *testing.T) {
+	fm := NewFileManager(true, WithDataDir(t.TempDir()))
+	defer fm.Cleanup()
+	var syncs int
+	var failSync error
+	fm.syncFile = func(f File) error {
+		syncs++
+		if failSync != nil {
+			return failSync
+		}
+		return f.Sync()
+	}
+
+	// Every acknowledged append has been synced
+	for i := 0; i < 3; i++ {
+		if err := fm.AppendToFile("file_0", []byte("record\n")); err != nil {
+			t.Fatalf("AppendToFile failed: %v", err)
+		}
+		if syncs != i+1 {
+			t.Fatalf("Expected %d syncs after %d appends, got %d", i+1, i+1, syncs)
+		}
+	}
+
+	// An append that can't be synced isn't acknowledged, and is taken back out
+	failSync = syscall.EIO
+	if err := fm.AppendToFile("file_0", []byte("lost\n")); !errors.Is(err, syscall.EIO) {
+		t.Errorf("Expected the sync failure to fail the append, got %v", err)
+	}
+	got, err := fm.ReadFile("file_0")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if want := strings.Repeat("record\n", 3); string(got) != want {
+		t.Errorf("Expected the unsynced append to be rolled back, got %q", got)
+	}
+
+	// Without sync enabled nothing is synced per write
+	unsynced := NewFileManager(false, WithDataDir(t.TempDir()))
+	defer unsynced.Cleanup()
+	unsynced.syncFile = func(File) error {
+		t.Error("Sync called with sync disabled")
+		return nil
+	}
+	if err := unsynced.AppendToFile("file_0", []byte("record\n")); err != nil {
+		t.Errorf("AppendToFile failed: %v", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	_, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	fm := NewFileManager(false)
+	defer fm.Cleanup()
+
+	// A trailing partial line counts as a line
+	content := []byte("one\ntwo\nthree")
+	if err := fm.AppendToFile("file_5", content); err != nil {
+		t.Fatalf("AppendToFile failed: %v", err)
+	}
+
+	stats, err := fm.Scan("file_5")
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if stats.Size != int64(len(content)) {
+		t.Errorf("Expected size %d, got %d", len(content), stats.Size)
+	}
+	if stats.Lines != 3 {
+		t.Errorf("Expected 3 lines, got %d", stats.Lines)
+	}
+	if sum := sha256.Sum256(content); !bytes.Equal(stats.SHA256, sum[:]) {
+		t.Errorf("Checksum mismatch: got %x, want %x", stats.SHA256, sum)
+	}
+	if stats.ModTime.IsZero() {
+		t.Error("Expected a modification time")
+	}
+
+	// Stat skips the read
+	stats, err = fm.Stat("file_5")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if stats.Lines != 0 || stats.SHA256 != nil {
+		t.Errorf("Stat shouldn't scan the content, got %d lines and checksum %x", stats.Lines, stats.SHA256)
+	}
+
+	// An empty file has no lines
+	if err := fm.AppendToFile("file_6", nil); err != nil {
+		t.Fatalf("AppendToFile failed: %v", err)
+	}
+	stats, err = fm.Scan("file_6")
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if stats.Lines != 0 {
+		t.Errorf("Expected 0 lines in an empty file, got %d", stats.Lines)
+	}
+}
+
 func TestFilenameValidation(t *testing.T) {
 	_, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -167,6 +342,222 @@ func TestFilenameValidation(t *testing.T) {
 	}
 }
 
+func TestFileCountValidation(t *testing.T) {
+	dir := t.TempDir()
+	fm := NewFileManager(false, WithDataDir(dir), WithFileCount(10))
+	defer fm.Cleanup()
+
+	if err := fm.AppendToFile("file_9", []byte("ok")); err != nil {
+		t.Errorf("AppendToFile failed with valid filename file_9: %v", err)
+	}
+	if err := fm.AppendToFile("file_10", []byte("no")); err == nil {
+		t.Error("AppendToFile should fail with file_10 when only 10 files are managed")
+	}
+}
+
+func TestFilenameValidator(t *testing.T) {
+	dir := t.TempDir()
+	validate, err := MatchFilenames(`log_[a-z]+`)
+	if err != nil {
+		t.Fatalf("MatchFilenames failed: %v", err)
+	}
+	fm := NewFileManager(false, WithDataDir(dir), WithFilenameValidator(validate))
+	defer fm.Cleanup()
+
+	for _, filename := range []string{"log_app", "log_db"} {
+		if err := fm.AppendToFile(filename, []byte("entry\n")); err != nil {
+			t.Errorf("AppendToFile failed with valid filename %s: %v", filename, err)
+		}
+	}
+
+	// The default names are gone, and traversal is refused whatever the pattern says
+	outside := filepath.Join(filepath.Dir(dir), "log_escaped")
+	for _, filename := range []string{
+		"file_0", "log_1", "log_app.bak", "xlog_app", "",
+		"..", "../log_escaped", "log_a/../../log_escaped", "log_a\\..\\log_b", outside,
+	} {
+		err := fm.AppendToFile(filename, []byte("no"))
+		if !errors.Is(err, ErrInvalidFilename) {
+			t.Errorf("AppendToFile(%q) = %v, want ErrInvalidFilename", filename, err)
+		}
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("A file was written outside the data directory: %v", err)
+	}
+
+	// Even a validator that accepts everything can't be used to escape
+	fm = NewFileManager(false, WithDataDir(dir), WithFilenameValidator(func(string) error { return nil }))
+	defer fm.Cleanup()
+	for _, filename := range []string{"..", "../log_escaped", "sub/log_a", "."} {
+		if err := fm.AppendToFile(filename, []byte("no")); !errors.Is(err, ErrInvalidFilename) {
+			t.Errorf("AppendToFile(%q) with a permissive validator = %v, want ErrInvalidFilename", filename, err)
+		}
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	parent := t.TempDir()
+	dataDir := filepath.Join(parent, "data")
+	fm := NewFileManager(false, WithDataDir(dataDir), WithFilenameValidator(func(string) error { return nil }))
+	defer fm.Cleanup()
+
+	for _, filename := range []string{"file_0", "log_app", "a.b"} {
+		if err := ValidatePath(dataDir, filename); err != nil {
+			t.Errorf("ValidatePath(%q) = %v, want nil", filename, err)
+		}
+	}
+	for _, filename := range []string{
+		"", ".", "..", "./file_0", "../file_0", "file_0/../../etc/passwd", "file_0/..",
+		"sub/file_0", "..\\file_0", "/etc/passwd", filepath.Join(parent, "file_0"), "file_0\x00",
+	} {
+		if err := ValidatePath(dataDir, filename); !errors.Is(err, ErrInvalidFilename) {
+			t.Errorf("ValidatePath(%q) = %v, want ErrInvalidFilename", filename, err)
+		}
+		if err := fm.AppendToFile(filename, []byte("x")); !errors.Is(err, ErrInvalidFilename) {
+			t.Errorf("AppendToFile(%q) = %v, want ErrInvalidFilename", filename, err)
+		}
+	}
+
+	// Nothing was written next to the data directory
+	entries, _ := os.ReadDir(parent)
+	for _, e := range entries {
+		if e.Name() != "data" {
+			t.Errorf("Unexpected file %s created next to the data directory", e.Name())
+		}
+	}
+}
+
+func TestFilenames(t *testing.T) {
+	dir := t.TempDir()
+	validate, _ := MatchFilenames(`log_[a-z]+`)
+	fm := NewFileManager(false, WithDataDir(dir), WithFilenameValidator(validate))
+	defer fm.Cleanup()
+
+	for _, filename := range []string{"log_web", "log_app"} {
+		if err := fm.AppendToFile(filename, []byte("entry\n")); err != nil {
+			t.Fatalf("AppendToFile %s failed: %v", filename, err)
+		}
+	}
+	// Files the validator doesn't accept aren't managed
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to write notes.txt: %v", err)
+	}
+
+	names, err := fm.Filenames()
+	if err != nil {
+		t.Fatalf("Filenames failed: %v", err)
+	}
+	if want := []string{"log_app", "log_web"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Filenames = %v, want %v", names, want)
+	}
+	var visited []string
+	fm.ForEachFile(true, func(filename string, content []byte) error {
+		visited = append(visited, filename)
+		return nil
+	})
+	if !reflect.DeepEqual(visited, names) {
+		t.Errorf("ForEachFile visited %v, want %v", visited, names)
+	}
+
+	// With the default scheme, only file_N files that exist are listed, by number
+	fm = NewFileManager(false, WithDataDir(t.TempDir()), WithFileCount(20))
+	defer fm.Cleanup()
+	for _, filename := range []string{"file_10", "file_2"} {
+		fm.AppendToFile(filename, []byte("x"))
+	}
+	if names, _ := fm.Filenames(); !reflect.DeepEqual(names, []string{"file_2", "file_10"}) {
+		t.Errorf("Filenames = %v, want [file_2 file_10]", names)
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	dir := t.TempDir()
+	fm := NewFileManager(false, WithDataDir(dir), WithFileCount(10))
+	defer fm.Cleanup()
+
+	// Errors the file manager produces itself
+	invalid := fm.AppendToFile("file_10", []byte("x"))
+	if !errors.Is(invalid, ErrInvalidFilename) || IsTransient(invalid) {
+		t.Errorf("Expected an invalid filename to be a permanent ErrInvalidFilename, got %v", invalid)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "file_3"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := fm.AppendToFile("file_3", []byte("x")); err == nil || IsTransient(err) {
+		t.Errorf("Expected appending to a directory to fail permanently, got %v", err)
+	}
+
+	// Errors the OS can return, wrapped the way os reports them
+	cases := []struct {
+		errno     syscall.Errno
+		transient bool
+	}{
+		{syscall.EAGAIN, true},
+		{syscall.EINTR, true},
+		{syscall.EMFILE, true},
+		{syscall.ENFILE, true},
+		{syscall.ENOSPC, true},
+		{syscall.EACCES, false},
+		{syscall.EPERM, false},
+		{syscall.EROFS, false},
+		{syscall.EISDIR, false},
+	}
+	for _, tc := range cases {
+		err := fmt.Errorf("append: %w", &os.PathError{Op: "write", Path: "file_0", Err: tc.errno})
+		if got := IsTransient(err); got != tc.transient {
+			t.Errorf("IsTransient(%v) = %v, want %v", tc.errno, got, tc.transient)
+		}
+	}
+}
+
+func TestTransientErrorRetries(t *testing.T) {
+	mem := newMemFS()
+	fm := NewFileManager(false, WithFS(mem), WithDataDir("/data"), WithTransientRetries(3, time.Millisecond))
+	defer fm.Cleanup()
+
+	// Each open fails with the next error in line, then succeeds
+	var failures []error
+	opens := 0
+	mem.fail = func(op, name string) error {
+		if op != "open" {
+			return nil
+		}
+		opens++
+		if len(failures) > 0 {
+			err := failures[0]
+			failures = failures[1:]
+			return err
+		}
+		return nil
+	}
+
+	cases := []struct {
+		name      string
+		failures  []error
+		wantOpens int
+		wantErr   error
+	}{
+		{"transient errors are retried", []error{syscall.EMFILE, syscall.EINTR}, 3, nil},
+		{"retries run out", []error{syscall.EMFILE, syscall.EMFILE, syscall.EMFILE, syscall.EMFILE}, 4, syscall.EMFILE},
+		{"permission errors fail at once", []error{syscall.EACCES}, 1, syscall.EACCES},
+	}
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			failures, opens = tc.failures, 0
+			err := fm.AppendToFile(fmt.Sprintf("file_%d", i), []byte("x\n"))
+			if tc.wantErr == nil && err != nil {
+				t.Fatalf("Expected the append to succeed after retrying, got %v", err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Expected %v, got %v", tc.wantErr, err)
+			}
+			if opens != tc.wantOpens {
+				t.Errorf("Expected %d opens, got %d", tc.wantOpens, opens)
+			}
+		})
+	}
+}
+
 func TestConcurrentSameFileAppends(t *testing.T) {
 	_, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -214,6 +605,59 @@ func TestConcurrentSameFileAppends(t *testing.T) {
 	}
 }
 
+func TestConcurrentLargeAppendsDoNotInterleave(t *testing.T) {
+	_, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	fm := NewFileManager(false)
+	filename := "file_0"
+
+	// Records well beyond PIPE_BUF, where O_APPEND alone makes no atomicity promise
+	const recordSize = 256 * 1024
+	numGoroutines := 16
+	writesPerGoroutine := 4
+
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(id int) {
+			defer wg.Done()
+
+			// Each record is one byte repeated, so any interleaving shows up as a mixed record
+			record := bytes.Repeat([]byte{byte('A' + id)}, recordSize)
+			for j := 0; j < writesPerGoroutine; j++ {
+				if err := fm.AppendToFile(filename, record); err != nil {
+					t.Errorf("Goroutine %d failed to append: %v", id, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	content, err := os.ReadFile(filepath.Join("data", filename))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if want := numGoroutines * writesPerGoroutine * recordSize; len(content) != want {
+		t.Fatalf("Expected %d bytes, got %d", want, len(content))
+	}
+
+	counts := make(map[byte]int)
+	for off := 0; off < len(content); off += recordSize {
+		record := content[off : off+recordSize]
+		if n := bytes.Count(record, record[:1]); n != recordSize {
+			t.Fatalf("Record at offset %d is interleaved: only %d of %d bytes are %q", off, n, recordSize, record[0])
+		}
+		counts[record[0]]++
+	}
+	for i := 0; i < numGoroutines; i++ {
+		if got := counts[byte('A'+i)]; got != writesPerGoroutine {
+			t.Errorf("Expected %d records from goroutine %d, got %d", writesPerGoroutine, i, got)
+		}
+	}
+}
+
 func TestConcurrentMultiFileAppends(t *testing.T) {
 	_, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -418,7 +862,9 @@ func TestCreateFiles(t *testing.T) {
 	defer cleanup()
 
 	fm := NewFileManager(false)
-	fm.CreateFiles()
+	if err := fm.CreateFiles(); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
 
 	// Verify all 100 files were created
 	for i := 0; i < 100; i++ {
@@ -429,6 +875,100 @@ func TestCreateFiles(t *testing.T) {
 	}
 }
 
+func TestCreateFilesWithSeed(t *testing.T) {
+	dataDir := t.TempDir()
+	existing := filepath.Join(dataDir, "file_1")
+	if err := os.WriteFile(existing, []byte("already here\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	seed := []byte("header\n")
+	fm := NewFileManager(false, WithDataDir(dataDir), WithFileCount(3), WithSeed(seed))
+	defer fm.Cleanup()
+	if err := fm.CreateFiles(); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
+
+	for name, want := range map[string]string{"file_0": "header\n", "file_1": "already here\n", "file_2": "header\n"} {
+		got, err := os.ReadFile(filepath.Join(dataDir, name))
+		if err != nil || string(got) != want {
+			t.Errorf("Expected %s to hold %q, got %q (%v)", name, want, got, err)
+		}
+	}
+
+	// A second run leaves the seeded files alone too
+	if err := fm.AppendToFile("file_0", []byte("record\n")); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := fm.CreateFiles(); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dataDir, "file_0")); string(got) != "header\nrecord\n" {
+		t.Errorf("Expected file_0 to keep its appended record, got %q", got)
+	}
+}
+
+func TestCreateFilesErrors(t *testing.T) {
+	// A read-only parent stops the data directory being created. Root ignores
+	// permissions, so that case only runs as an ordinary user.
+	if runtime.GOOS != "windows" && os.Geteuid() != 0 {
+		parent := t.TempDir()
+		if err := os.Chmod(parent, 0555); err != nil {
+			t.Fatalf("Failed to make directory read-only: %v", err)
+		}
+		defer os.Chmod(parent, 0755)
+
+		fm := NewFileManager(false, WithDataDir(filepath.Join(parent, "data")))
+		if err := fm.CreateFiles(); !errors.Is(err, os.ErrPermission) {
+			t.Errorf("Expected a permission error under a read-only parent, got %v", err)
+		}
+	}
+
+	// A file where the data directory should be fails the same way for everyone
+	blocker := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	fm := NewFileManager(false, WithDataDir(blocker))
+	if err := fm.CreateFiles(); err == nil || !strings.Contains(err.Error(), "create data directory") {
+		t.Errorf("Expected an error creating the data directory, got %v", err)
+	}
+}
+
+func TestDataDirRemovedWhileRunning(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+	fm := NewFileManager(false, WithDataDir(dataDir))
+	defer fm.Cleanup()
+	if err := fm.CreateFiles(); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
+	if err := fm.AppendToFile("file_0", []byte("before\n")); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	// file_0's append handle is still open; the next append mustn't write to
+	// the deleted file
+	if err := os.RemoveAll(dataDir); err != nil {
+		t.Fatalf("Failed to remove data directory: %v", err)
+	}
+	if err := fm.AppendToFile("file_0", []byte("after\n")); err != nil {
+		t.Fatalf("Append after removing the data directory failed: %v", err)
+	}
+	if err := fm.AppendToFile("file_1", []byte("other\n")); err != nil {
+		t.Fatalf("Append to a file without an open handle failed: %v", err)
+	}
+
+	for filename, want := range map[string]string{"file_0": "after\n", "file_1": "other\n"} {
+		got, err := os.ReadFile(filepath.Join(dataDir, filename))
+		if err != nil {
+			t.Fatalf("Expected %s to be recreated: %v", filename, err)
+		}
+		if string(got) != want {
+			t.Errorf("Expected %s to hold %q, got %q", filename, want, got)
+		}
+	}
+}
+
 func TestCleanup(t *testing.T) {
 	_, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -466,6 +1006,68 @@ func TestCleanup(t *testing.T) {
 	}
 }
 
+func TestOpenFilesBounded(t *testing.T) {
+	_, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	const maxOpen = 5
+	fm := NewFileManager(false, WithMaxOpenFiles(maxOpen))
+	defer fm.Cleanup()
+
+	// Several rounds over more files than the cap, concurrently, so handles
+	// are evicted and reopened while other appends are in flight
+	const files, rounds = 20, 5
+	var wg sync.WaitGroup
+	for i := 0; i < files; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				if err := fm.AppendToFile(fmt.Sprintf("file_%d", i), []byte(fmt.Sprintf("round %d\n", r))); err != nil {
+					t.Errorf("Append to file_%d failed: %v", i, err)
+				}
+				fm.mu.Lock()
+				open := len(fm.openFiles)
+				fm.mu.Unlock()
+				// Handles of files mid-append can't be evicted, so allow one per appender
+				if open > maxOpen+files {
+					t.Errorf("%d files open, expected at most %d", open, maxOpen+files)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Sequential appends evict back down to the cap
+	for i := 0; i < files; i++ {
+		if err := fm.AppendToFile(fmt.Sprintf("file_%d", i), []byte("last\n")); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	if open := fm.OpenFileCount(); open > maxOpen {
+		t.Errorf("%d files open after sequential appends, expected at most %d", open, maxOpen)
+	}
+	if len(fm.openElems) != len(fm.openFiles) || fm.openOrder.Len() != len(fm.openFiles) {
+		t.Errorf("LRU bookkeeping out of sync: %d files, %d elements, %d in order", len(fm.openFiles), len(fm.openElems), fm.openOrder.Len())
+	}
+
+	// Evicted files were reopened and lost nothing
+	want := ""
+	for r := 0; r < rounds; r++ {
+		want += fmt.Sprintf("round %d\n", r)
+	}
+	want += "last\n"
+	for i := 0; i < files; i++ {
+		got, err := fm.ReadFile(fmt.Sprintf("file_%d", i))
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("file_%d has %q, want %q", i, got, want)
+		}
+	}
+}
+
 func TestErrorHandling(t *testing.T) {
 	_, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -473,7 +1075,8 @@ func TestErrorHandling(t *testing.T) {
 	fm := NewFileManager(false)
 
 	// Test with read-only directory
-	if runtime.GOOS != "windows" { // Skip on Windows as permissions work differently
+	// Skip on Windows as permissions work differently, and as root since root ignores them
+	if runtime.GOOS != "windows" && os.Geteuid() != 0 {
 		// Make data directory read-only
 		err := os.Chmod("data", 0555)
 		if err != nil {
@@ -482,8 +1085,8 @@ func TestErrorHandling(t *testing.T) {
 
 		// Try to write to a file
 		err = fm.AppendToFile("file_0", []byte("test"))
-		if err == nil {
-			t.Error("Expected error when writing to read-only directory")
+		if !errors.Is(err, os.ErrPermission) {
+			t.Errorf("Expected a permission error writing to read-only directory, got %v", err)
 		}
 
 		// Restore permissions
@@ -491,6 +1094,81 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+// chdirTemp switches into a fresh temporary working directory for the rest of the test
+func chdirTemp(t *testing.T) string {
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	workDir := t.TempDir()
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatalf("Failed to change working directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(origDir) })
+	return workDir
+}
+
+func TestSymlinkedDataDir(t *testing.T) {
+	target := t.TempDir()
+	linkDir := t.TempDir()
+	link := filepath.Join(linkDir, "data")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	// Configure the manager relative to the directory holding the symlink
+	origDir, _ := os.Getwd()
+	os.Chdir(linkDir)
+	fm := NewFileManager(false, WithDataDir("data"))
+	os.Chdir(origDir)
+	defer fm.Cleanup()
+
+	// Appends must still follow the symlink after the working directory changes
+	chdirTemp(t)
+	if err := fm.AppendToFile("file_1", []byte("via symlink")); err != nil {
+		t.Fatalf("AppendToFile through symlinked data dir failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(target, "file_1"))
+	if err != nil {
+		t.Fatalf("Failed to read file from symlink target: %v", err)
+	}
+	if string(content) != "via symlink" {
+		t.Errorf("File content mismatch. Got %q, want %q", content, "via symlink")
+	}
+}
+
+func TestAbsoluteDataDirFromOtherWorkingDir(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+	workDir := chdirTemp(t)
+
+	fm := NewFileManager(false, WithDataDir(dataDir))
+	defer fm.Cleanup()
+	if fm.DataDir() != dataDir {
+		t.Errorf("DataDir() = %s, want %s", fm.DataDir(), dataDir)
+	}
+
+	if err := fm.CreateFiles(); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
+	if err := fm.AppendToFile("file_2", []byte("absolute")); err != nil {
+		t.Fatalf("AppendToFile with absolute data dir failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dataDir, "file_2"))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "absolute" {
+		t.Errorf("File content mismatch. Got %q, want %q", content, "absolute")
+	}
+
+	// Nothing should leak into the working directory
+	if _, err := os.Stat(filepath.Join(workDir, "data")); !os.IsNotExist(err) {
+		t.Errorf("Working directory should not contain a data directory")
+	}
+}
+
 func BenchmarkAppendToFile(b *testing.B) {
 	tempDir, err := os.MkdirTemp("", "filemanager_bench")
 	if err != nil {
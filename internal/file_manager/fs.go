@@ -0,0 +1,72 @@
+package file_manager
+
+import (
+	"io"
+	"os"
+)
+
+// FS is the filesystem the file manager keeps its data files on. OSFS, the
+// default, is the real one; tests substitute one that fails on demand to reach
+// error paths a real disk won't produce reliably.
+type FS interface {
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	CreateTemp(dir, pattern string) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Chmod(name string, mode os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+}
+
+// File is an open file on an FS. *os.File implements it.
+type File interface {
+	io.ReadWriteCloser
+	Name() string
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+}
+
+// OSFS is the operating system's filesystem
+type OSFS struct{}
+
+func (OSFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err // Not f, which would make a non-nil File
+	}
+	return f, nil
+}
+
+func (OSFS) CreateTemp(dir, pattern string) (File, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (OSFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (OSFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (OSFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (OSFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (OSFS) Remove(name string) error                     { return os.Remove(name) }
+
+// WithFS keeps the data files on fsys instead of the operating system's filesystem
+func WithFS(fsys FS) Option {
+	return func(fm *FileManager) {
+		fm.fs = fsys
+	}
+}
+
+// readFile returns the whole content of the file at path
+func (fm *FileManager) readFile(path string) ([]byte, error) {
+	f, err := fm.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,328 @@
+package file_manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// memFS is an in-memory FS. If fail is set it is asked before every open,
+// write and sync, and a non-nil error it returns is reported instead.
+type memFS struct {
+	mu    sync.Mutex
+	files map[string]*memData
+	dirs  map[string]bool
+	temps int
+	fail  func(op, name string) error
+}
+
+// memData is the content of one file; open handles keep it after the file is
+// removed or replaced, as on a real filesystem
+type memData struct {
+	content []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string]*memData), dirs: map[string]bool{"/": true}}
+}
+
+// failing returns the injected error for op on name, if any. Must be called with m.mu held.
+func (m *memFS) failing(op, name string) error {
+	if m.fail == nil {
+		return nil
+	}
+	if err := m.fail(op, name); err != nil {
+		return &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return nil
+}
+
+func (m *memFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if err := m.failing("open", name); err != nil {
+		return nil, err
+	}
+	if m.dirs[name] {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+	}
+	data, ok := m.files[name]
+	switch {
+	case !ok && flag&os.O_CREATE == 0, !m.dirs[filepath.Dir(name)]:
+		return nil, &os.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case !ok:
+		data = &memData{mode: perm, modTime: time.Now()}
+		m.files[name] = data
+	case flag&os.O_TRUNC != 0:
+		data.content = nil
+	}
+	return &memFile{fs: m, name: name, data: data, flag: flag}, nil
+}
+
+func (m *memFS) CreateTemp(dir, pattern string) (File, error) {
+	m.mu.Lock()
+	m.temps++
+	name := filepath.Join(dir, strings.Replace(pattern, "*", fmt.Sprint(m.temps), 1))
+	m.mu.Unlock()
+	return m.OpenFile(name, os.O_RDWR|os.O_CREATE, 0600)
+}
+
+func (m *memFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if m.dirs[name] {
+		return memInfo{name: filepath.Base(name), mode: fs.ModeDir | 0755}, nil
+	}
+	if data, ok := m.files[name]; ok {
+		return data.info(name), nil
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *memFS) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if !m.dirs[name] {
+		return nil, &os.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	var entries []os.DirEntry
+	for path, data := range m.files {
+		if filepath.Dir(path) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(data.info(path)))
+		}
+	}
+	for path := range m.dirs {
+		if path != name && filepath.Dir(path) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: filepath.Base(path), mode: fs.ModeDir | 0755}))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *memFS) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for path = filepath.Clean(path); !m.dirs[path]; path = filepath.Dir(path) {
+		if _, ok := m.files[path]; ok {
+			return &os.PathError{Op: "mkdir", Path: path, Err: syscall.ENOTDIR}
+		}
+		m.dirs[path] = true
+	}
+	return nil
+}
+
+func (m *memFS) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[filepath.Clean(name)]
+	if !ok {
+		return &os.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+	data.mode = mode
+	return nil
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+	data, ok := m.files[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	delete(m.files, oldpath)
+	m.files[newpath] = data
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if _, ok := m.files[name]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (d *memData) info(path string) memInfo {
+	return memInfo{name: filepath.Base(path), size: int64(len(d.content)), mode: d.mode, modTime: d.modTime}
+}
+
+// memInfo describes a memFS file or directory
+type memInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() os.FileMode  { return i.mode }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
+
+// memFile is an open memFS file
+type memFile struct {
+	fs     *memFS
+	name   string
+	data   *memData
+	flag   int
+	offset int64
+}
+
+func (f *memFile) Name() string { return f.name }
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.offset >= int64(len(f.data.content)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data.content[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.fs.failing("write", f.name); err != nil {
+		return 0, err
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.data.content))
+	}
+	if end := f.offset + int64(len(p)); end > int64(len(f.data.content)) {
+		f.data.content = append(f.data.content, make([]byte, end-int64(len(f.data.content)))...)
+	}
+	copy(f.data.content[f.offset:], p)
+	f.offset += int64(len(p))
+	f.data.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return f.data.info(f.name), nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	f.data.content = f.data.content[:size]
+	return nil
+}
+
+func (f *memFile) Sync() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return f.fs.failing("sync", f.name)
+}
+
+func (f *memFile) Close() error { return nil }
+
+func TestMemFS(t *testing.T) {
+	mem := newMemFS()
+	fm := NewFileManager(false, WithFS(mem), WithDataDir("/srv/dlm"), WithFileCount(3))
+	defer fm.Cleanup()
+
+	// Every operation runs against mem; nothing touches the disk
+	if err := fm.CreateFiles(); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
+	if err := fm.AppendToFile("file_0", []byte("one\n")); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := fm.AppendToFile("file_0", []byte("two\n")); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := fm.WriteFileAs(1, "file_1", []byte("replaced")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	stats, err := fm.Scan("file_0")
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if stats.Size != 8 || stats.Lines != 2 {
+		t.Errorf("Expected file_0 to be 8 bytes in 2 lines, got %+v", stats)
+	}
+	files := map[string]string{}
+	if err := fm.ForEachFile(true, func(filename string, content []byte) error {
+		files[filename] = string(content)
+		return nil
+	}); err != nil {
+		t.Fatalf("ForEachFile failed: %v", err)
+	}
+	want := map[string]string{"file_0": "one\ntwo\n", "file_1": "replaced", "file_2": ""}
+	if fmt.Sprint(files) != fmt.Sprint(want) {
+		t.Errorf("Expected files %q, got %q", want, files)
+	}
+	if _, err := os.Stat("/srv/dlm"); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written to disk, got %v", err)
+	}
+}
+
+func TestInjectedWriteError(t *testing.T) {
+	mem := newMemFS()
+	fm := NewFileManager(false, WithFS(mem), WithDataDir("/data"), WithTransientRetries(2, time.Millisecond))
+	defer fm.Cleanup()
+	if err := fm.AppendToFile("file_0", []byte("kept\n")); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		errno      syscall.Errno
+		wantWrites int
+	}{
+		{syscall.EIO, 1},    // Permanent: fails at once
+		{syscall.ENOSPC, 3}, // Transient: retried twice, then fails
+	} {
+		writes := 0
+		mem.fail = func(op, name string) error {
+			if op == "write" {
+				writes++
+				return tc.errno
+			}
+			return nil
+		}
+		err := fm.AppendToFile("file_0", []byte("lost\n"))
+		if !errors.Is(err, tc.errno) {
+			t.Errorf("Expected the append to fail with %v, got %v", tc.errno, err)
+		}
+		if IsTransient(err) != (tc.errno == syscall.ENOSPC) {
+			t.Errorf("IsTransient(%v) = %v", err, IsTransient(err))
+		}
+		if writes != tc.wantWrites {
+			t.Errorf("Expected %d writes for %v, got %d", tc.wantWrites, tc.errno, writes)
+		}
+	}
+
+	mem.fail = nil
+	content, err := fm.ReadFile("file_0")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(content) != "kept\n" {
+		t.Errorf("Expected failed appends to leave the file alone, got %q", content)
+	}
+}
@@ -0,0 +1,93 @@
+package lock_manager
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrDeadlock is returned, with WithDeadlockBreaking, by an acquire that would
+// complete a cycle of clients each waiting for a lock the next one holds
+var ErrDeadlock = errors.New("acquire would deadlock")
+
+// WithDeadlockBreaking fails an acquire that would close a deadlock with
+// ErrDeadlock instead of letting it wait, so the clients in the cycle can get
+// on once the refused one backs off. Without it deadlocks are only logged.
+func WithDeadlockBreaking() Option {
+	return func(lm *LockManager) {
+		lm.breakDeadlocks = true
+	}
+}
+
+// Deadlocks returns how many deadlocks have been detected
+func (lm *LockManager) Deadlocks() uint64 {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.deadlocks
+}
+
+// checkDeadlock looks for a cycle in the wait-for graph that clientID waiting
+// for rl in the given mode would close, logging any it finds. It reports
+// ErrDeadlock if one was found and deadlocks are being broken. Only acquires
+// that start waiting are checked, so a cycle formed when a queued client is
+// handed a lock goes unnoticed. Must be called with lm.mu held.
+func (lm *LockManager) checkDeadlock(resource string, rl *resourceLock, clientID int32, mode Mode) error {
+	for _, owner := range rl.blockers(clientID, mode) {
+		path := lm.waitPath(owner, clientID, map[int32]bool{})
+		if path == nil {
+			continue
+		}
+		lm.deadlocks++
+		cycle := append([]int32{clientID}, path...)
+		lm.logger.Printf("Warning: deadlock: client %d waiting for lock %q would close the cycle %v", clientID, resource, cycle)
+		if lm.breakDeadlocks {
+			return fmt.Errorf("%w: clients %v wait for each other", ErrDeadlock, cycle)
+		}
+		return nil
+	}
+	return nil
+}
+
+// waitPath returns the clients from client to target, following what each
+// queued client waits for, or nil if target can't be reached.
+// Must be called with lm.mu held.
+func (lm *LockManager) waitPath(client, target int32, seen map[int32]bool) []int32 {
+	if client == target {
+		return []int32{client}
+	}
+	if seen[client] {
+		return nil
+	}
+	seen[client] = true
+	for _, rl := range lm.locks {
+		for _, w := range rl.queue {
+			if w.clientID != client {
+				continue
+			}
+			for _, owner := range rl.blockers(client, w.mode) {
+				if path := lm.waitPath(owner, target, seen); path != nil {
+					return append([]int32{client}, path...)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// blockers returns the clients holding rl that clientID waiting for it in the
+// given mode has to wait for, in a stable order
+func (rl *resourceLock) blockers(clientID int32, mode Mode) []int32 {
+	var owners []int32
+	if rl.holder != -1 && rl.holder != clientID {
+		owners = append(owners, rl.holder)
+	}
+	if mode == Exclusive {
+		for reader := range rl.readers {
+			if reader != clientID {
+				owners = append(owners, reader)
+			}
+		}
+	}
+	slices.Sort(owners)
+	return owners
+}
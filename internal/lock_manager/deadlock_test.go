@@ -0,0 +1,104 @@
+package lock_manager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// crossLocks has client 1 hold a and client 2 hold b, then leaves client 1
+// waiting for b. It returns the channel client 1's acquire reports on.
+func crossLocks(t *testing.T, lm *LockManager) <-chan error {
+	ctx := context.Background()
+	if err := lm.AcquireResource("a", 1, ctx); err != nil {
+		t.Fatalf("Acquire a failed: %v", err)
+	}
+	if err := lm.AcquireResource("b", 2, ctx); err != nil {
+		t.Fatalf("Acquire b failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- lm.AcquireResource("b", 1, ctx) }()
+	deadline := time.Now().Add(time.Second)
+	for lm.Status("b").Waiters == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for client 1 to queue for b")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return done
+}
+
+func TestDeadlockBreaking(t *testing.T) {
+	lm := NewLockManager(nil, WithDeadlockBreaking())
+	done := crossLocks(t, lm)
+
+	// Client 2 going for a would close the cycle, so it is refused at once
+	err := lm.AcquireResource("a", 2, context.Background())
+	if !errors.Is(err, ErrDeadlock) {
+		t.Fatalf("Expected ErrDeadlock, got %v", err)
+	}
+	if n := lm.Deadlocks(); n != 1 {
+		t.Errorf("Expected 1 deadlock detected, got %d", n)
+	}
+	if lm.Status("a").Waiters != 0 {
+		t.Error("The refused acquire shouldn't be left queued")
+	}
+
+	// Once client 2 backs off, client 1 gets on
+	lm.ReleaseResource("b", 2)
+	if err := <-done; err != nil {
+		t.Errorf("Client 1's acquire of b failed: %v", err)
+	}
+}
+
+func TestDeadlockLogged(t *testing.T) {
+	var buf bytes.Buffer
+	lm := NewLockManager(log.New(&buf, "", 0))
+	done := crossLocks(t, lm)
+
+	// Without breaking, the acquire waits and the deadlock is only reported
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := lm.AcquireResource("a", 2, ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the deadlocked acquire to time out, got %v", err)
+	}
+	if n := lm.Deadlocks(); n != 1 {
+		t.Errorf("Expected 1 deadlock detected, got %d", n)
+	}
+	if !strings.Contains(buf.String(), "Warning: deadlock: client 2 waiting for lock \"a\" would close the cycle [2 1 2]") {
+		t.Errorf("Expected a deadlock warning, got:\n%s", buf.String())
+	}
+
+	lm.ReleaseResource("b", 2)
+	if err := <-done; err != nil {
+		t.Errorf("Client 1's acquire of b failed: %v", err)
+	}
+}
+
+func TestNoDeadlockWithoutCycle(t *testing.T) {
+	lm := NewLockManager(nil, WithDeadlockBreaking())
+	ctx := context.Background()
+
+	// Clients queued one behind another aren't a cycle
+	lm.AcquireResource("a", 1, ctx)
+	done := make(chan error, 2)
+	for _, id := range []int32{2, 3} {
+		go func(id int32) { done <- lm.AcquireResource("a", id, ctx) }(id)
+	}
+	for lm.Status("a").Waiters < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 2; i++ {
+		lm.ReleaseResource("a", lm.ResourceHolder("a"))
+		if err := <-done; err != nil {
+			t.Errorf("Queued acquire failed: %v", err)
+		}
+	}
+	if n := lm.Deadlocks(); n != 0 {
+		t.Errorf("Expected no deadlocks, got %d", n)
+	}
+}
@@ -0,0 +1,49 @@
+package lock_manager
+
+import "time"
+
+// EventKind says how a lock changed hands
+type EventKind int
+
+const (
+	Acquired EventKind = iota // A client was granted the lock
+	Released                  // A client gave the lock up, or was made to
+	Expired                   // A client's lease ran out and the lock was taken back
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Acquired:
+		return "acquired"
+	case Released:
+		return "released"
+	case Expired:
+		return "expired"
+	}
+	return "unknown"
+}
+
+// Event is one change of ownership of a lock
+type Event struct {
+	Kind     EventKind
+	Resource string
+	ClientID int32
+	Mode     Mode
+	Time     time.Time
+}
+
+// WithEventHandler calls fn for every lock granted, released or expired. fn is
+// called with the lock manager's mutex held, so events arrive in the order they
+// happened; it must return quickly and mustn't call back into the lock manager.
+func WithEventHandler(fn func(Event)) Option {
+	return func(lm *LockManager) {
+		lm.onEvent = fn
+	}
+}
+
+// emit reports an ownership change to the event handler, if any. Must be called with lm.mu held.
+func (lm *LockManager) emit(kind EventKind, resource string, clientID int32, mode Mode) {
+	if lm.onEvent != nil {
+		lm.onEvent(Event{Kind: kind, Resource: resource, ClientID: clientID, Mode: mode, Time: time.Now()})
+	}
+}
@@ -0,0 +1,44 @@
+package lock_manager
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventHandler(t *testing.T) {
+	type event struct {
+		kind     EventKind
+		resource string
+		clientID int32
+		mode     Mode
+	}
+	var got []event
+	clock := &fakeClock{wall: time.Unix(1000, 0)}
+	lm := NewLockManager(nil, WithLease(time.Second), WithClock(clock), WithEventHandler(func(ev Event) {
+		got = append(got, event{ev.Kind, ev.Resource, ev.ClientID, ev.Mode})
+	}))
+	defer lm.Close()
+	ctx := context.Background()
+
+	lm.Acquire(1)
+	lm.AcquireShared("a", 2, ctx)
+	lm.TransferResource(GlobalResource, 1, 3)
+	lm.Release(3)
+	lm.Release(3) // Not held any more, so nothing to report
+	clock.Advance(2 * time.Second)
+	lm.ExpireLeases()
+
+	want := []event{
+		{Acquired, GlobalResource, 1, Exclusive},
+		{Acquired, "a", 2, Shared},
+		{Released, GlobalResource, 1, Exclusive},
+		{Acquired, GlobalResource, 3, Exclusive},
+		{Released, GlobalResource, 3, Exclusive},
+		{Expired, "a", 2, Shared},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Events:\n got %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package lock_manager
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultHoldSamples is how many recent exclusive holds the hold-time percentiles are taken over
+const DefaultHoldSamples = 1024
+
+// HoldStats summarises how long clients held locks exclusively before giving
+// them up, whether by release, transfer, eviction or lease expiry
+type HoldStats struct {
+	Count uint64        // Exclusive holds that have ended
+	Total time.Duration // Sum of every hold's duration
+	Mean  time.Duration
+
+	// Percentiles over the most recent DefaultHoldSamples holds
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// holdTimes accumulates exclusive hold durations; the lock manager guards it with lm.mu
+type holdTimes struct {
+	count   uint64
+	total   time.Duration
+	samples []time.Duration // Ring of the latest holds
+	next    int             // Where the next sample goes once samples is full
+}
+
+// add records one hold
+func (h *holdTimes) add(d time.Duration) {
+	h.count++
+	h.total += d
+	if len(h.samples) < DefaultHoldSamples {
+		h.samples = append(h.samples, d)
+		return
+	}
+	h.samples[h.next] = d
+	h.next = (h.next + 1) % DefaultHoldSamples
+}
+
+// endHold records how long the exclusive holder of rl has had it, now that it
+// is giving it up. Must be called with lm.mu held, before rl.holder changes.
+func (lm *LockManager) endHold(rl *resourceLock) {
+	lm.holdTimes.add(lm.clock.Monotonic() - rl.heldSince)
+}
+
+// HoldStats returns how long locks have been held exclusively so far
+func (lm *LockManager) HoldStats() HoldStats {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	h := &lm.holdTimes
+	stats := HoldStats{Count: h.count, Total: h.total}
+	if h.count == 0 {
+		return stats
+	}
+	stats.Mean = h.total / time.Duration(h.count)
+
+	sorted := slices.Clone(h.samples)
+	slices.Sort(sorted)
+	percentile := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+	stats.P50, stats.P95, stats.P99 = percentile(50), percentile(95), percentile(99)
+	return stats
+}
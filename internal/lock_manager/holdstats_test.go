@@ -0,0 +1,58 @@
+package lock_manager
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHoldStats(t *testing.T) {
+	clock := &fakeClock{}
+	lm := NewLockManager(nil, WithClock(clock))
+	defer lm.Close()
+
+	if stats := lm.HoldStats(); stats != (HoldStats{}) {
+		t.Errorf("Expected no holds recorded yet, got %+v", stats)
+	}
+
+	// Hold the global lock for 1s..100s, in that order
+	for i := 1; i <= 100; i++ {
+		lm.Acquire(1)
+		clock.Advance(time.Duration(i) * time.Second)
+		lm.Release(1)
+	}
+	// Shared holds and holds still in progress aren't counted
+	lm.AcquireShared("a", 2, context.Background())
+	clock.Advance(time.Hour)
+	lm.ReleaseShared("a", 2)
+	lm.Acquire(3)
+	clock.Advance(time.Hour)
+
+	stats := lm.HoldStats()
+	if stats.Count != 100 {
+		t.Errorf("Expected 100 holds, got %d", stats.Count)
+	}
+	if stats.Total != 5050*time.Second {
+		t.Errorf("Expected holds totalling 5050s, got %v", stats.Total)
+	}
+	for _, c := range []struct {
+		name     string
+		got      time.Duration
+		min, max time.Duration
+	}{
+		{"mean", stats.Mean, 50 * time.Second, 51 * time.Second},
+		{"p50", stats.P50, 49 * time.Second, 51 * time.Second},
+		{"p95", stats.P95, 94 * time.Second, 96 * time.Second},
+		{"p99", stats.P99, 98 * time.Second, 100 * time.Second},
+	} {
+		if c.got < c.min || c.got > c.max {
+			t.Errorf("Expected %s hold time in [%v, %v], got %v", c.name, c.min, c.max, c.got)
+		}
+	}
+
+	// A transfer ends the old holder's hold
+	lm.TransferResource(GlobalResource, 3, 4)
+	if stats := lm.HoldStats(); stats.Count != 101 || stats.Total != 5050*time.Second+time.Hour {
+		t.Errorf("Expected the transfer to record a 1h hold, got %+v", stats)
+	}
+}
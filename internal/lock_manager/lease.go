@@ -0,0 +1,96 @@
+package lock_manager
+
+import (
+	"slices"
+	"time"
+)
+
+// WithResourceLease gives holders of the named lock d without a heartbeat
+// before it is taken back, instead of the lease set by WithLease, so locks
+// guarding slow work can be held longer than the rest. A heartbeat extends
+// every lock a client holds, each by its own lease. d must be positive.
+func WithResourceLease(resource string, d time.Duration) Option {
+	return func(lm *LockManager) {
+		if d <= 0 {
+			return
+		}
+		if lm.resourceLeases == nil {
+			lm.resourceLeases = make(map[string]time.Duration)
+		}
+		lm.resourceLeases[resource] = d
+	}
+}
+
+// shortestLease returns the shortest lease in force, 0 if leases are off
+func (lm *LockManager) shortestLease() time.Duration {
+	shortest := lm.lease
+	for _, d := range lm.resourceLeases {
+		if shortest == 0 || d < shortest {
+			shortest = d
+		}
+	}
+	return shortest
+}
+
+// leaseDeadline returns the clock reading at which clientID's hold on the
+// named lock expires, and false if it doesn't. A lock with its own lease runs
+// from the client's last sign of life; otherwise, as for holders restored
+// without having checked in since, the client's deadline applies.
+// Must be called with lm.mu held.
+func (lm *LockManager) leaseDeadline(resource string, clientID int32) (time.Duration, bool) {
+	if lease, ok := lm.resourceLeases[resource]; ok {
+		if seen, ok := lm.seen[clientID]; ok {
+			return seen + lease, true
+		}
+	}
+	deadline, ok := lm.deadlines[clientID]
+	return deadline, ok
+}
+
+// forgetSilent stops tracking the last sign of life of clients silent for
+// longer than every lock's own lease, which can hold none of those locks any
+// more. Must be called with lm.mu held.
+func (lm *LockManager) forgetSilent(now time.Duration) {
+	var longest time.Duration
+	for _, d := range lm.resourceLeases {
+		longest = max(longest, d)
+	}
+	for clientID, seen := range lm.seen {
+		if now > seen+longest {
+			delete(lm.seen, clientID)
+		}
+	}
+}
+
+// expireHolds releases every hold on a lock whose lease ran out before now,
+// adding the clients that lost one to expired. Must be called with lm.mu held.
+func (lm *LockManager) expireHolds(now time.Duration, expired []int32) []int32 {
+	lapsed := func(resource string, clientID int32) bool {
+		deadline, ok := lm.leaseDeadline(resource, clientID)
+		if !ok || now <= deadline {
+			return false
+		}
+		if !slices.Contains(expired, clientID) {
+			expired = append(expired, clientID)
+		}
+		return true
+	}
+
+	for resource, rl := range lm.locks {
+		if holder := rl.holder; holder != -1 && lapsed(resource, holder) {
+			lm.logger.Printf("Lease expired: releasing lock %q held by client %d", resource, holder)
+			lm.releaseAs(Expired, resource, holder, Exclusive)
+		}
+		var readers []int32
+		for reader := range rl.readers {
+			readers = append(readers, reader)
+		}
+		for _, reader := range readers {
+			if lapsed(resource, reader) {
+				lm.logger.Printf("Lease expired: releasing shared lock %q held by client %d", resource, reader)
+				lm.releaseAs(Expired, resource, reader, Shared)
+			}
+		}
+	}
+	return expired
+}
@@ -2,168 +2,1069 @@ package lock_manager
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 )
 
+// GlobalResource is the lock used by clients that don't name a resource
+const GlobalResource = "global"
+
+// ErrShuttingDown is returned by acquires turned away because the lock manager is draining
+var ErrShuttingDown = errors.New("lock manager is shutting down")
+
+// ErrAlreadyHeld is returned when a client acquires a lock it already holds.
+// Locks aren't reentrant: waiting would mean waiting on itself forever.
+var ErrAlreadyHeld = errors.New("lock already held by this client")
+
+// ErrTooManyWaiters is returned by acquires turned away because the number of
+// blocked acquires is at the limit set with WithMaxWaiters
+var ErrTooManyWaiters = errors.New("too many clients waiting for locks")
+
+// ErrEvicted is returned by acquires that were waiting when their client was evicted
+var ErrEvicted = errors.New("client evicted")
+
+// ErrLockBusy is returned by TryAcquireMode when the lock is held in a
+// conflicting mode or other clients are queued for it
+var ErrLockBusy = errors.New("lock busy")
+
+// ErrNotHolder is returned by HandOff when the lock isn't held exclusively by
+// the expected client
+var ErrNotHolder = errors.New("lock not held by the expected client")
+
+// Mode selects between exclusive (write) and shared (read) ownership of a lock
+type Mode int
+
+const (
+	// Exclusive gives a single client sole ownership of the lock
+	Exclusive Mode = iota
+	// Shared lets any number of clients hold the lock together, excluding writers
+	Shared
+)
+
+func (m Mode) String() string {
+	if m == Shared {
+		return "shared"
+	}
+	return "exclusive"
+}
+
+// waiter is a client queued for a lock. The lock is handed over by closing ready.
+// Concurrent acquires by the same client in the same mode share one waiter.
+type waiter struct {
+	clientID int32
+	mode     Mode
+	priority int32         // Higher goes first; equal priorities are served in arrival order
+	since    time.Duration // Clock reading when the waiter joined the queue, for aging
+	ready    chan struct{}
+	evicted  chan struct{} // Closed by Evict to turn the waiting callers away
+	callers  int           // Acquire calls waiting on this entry that haven't returned yet
+	accepted bool          // Some caller returned with the lock after it was handed over
+}
+
+// resourceLock is the state of a single named lock
+type resourceLock struct {
+	holder     int32              // ID of the client holding the lock exclusively, -1 if none
+	readers    map[int32]struct{} // Clients holding the lock in shared mode
+	queue      []*waiter          // Waiting clients by effective priority, then arrival
+	lastHolder int32              // Client that most recently released the lock exclusively, -1 if none
+	token      uint64             // Fencing token issued to the current exclusive holder
+	heldSince  time.Duration      // Clock reading when the current exclusive holder was granted the lock
+}
+
+// Clock supplies the time source for lease bookkeeping; tests substitute a fake
+// to control lease expiry
+type Clock interface {
+	// Monotonic returns the time elapsed since some fixed point. It must never
+	// go backward or jump when the wall clock is stepped (by an NTP correction,
+	// say), so leases last as long as they should whatever the wall clock does.
+	Monotonic() time.Duration
+}
+
+// realClock reads the system's monotonic clock
+type realClock struct {
+	start time.Time
+}
+
+// Monotonic uses the monotonic reading Go keeps alongside the wall time
+func (c realClock) Monotonic() time.Duration { return time.Since(c.start) }
+
 // LockManager handles all lock-related operations
 type LockManager struct {
-	mu         sync.Mutex // Protects shared state
-	cond       *sync.Cond // Condition variable for lock waiting
-	lockHolder int32      // ID of the client holding the lock, -1 if free
-	logger     *log.Logger
-	queue      []int32 // FIFO queue for fairness
+	mu           sync.Mutex               // Protects shared state
+	locks        map[string]*resourceLock // Named locks; entries are dropped once free and unwaited
+	logger       *log.Logger
+	antiAffinity bool          // Prefer handing a released lock to someone other than its last holder
+	maxWaiters   int           // Most acquires that may be blocked at once across all locks; 0 means no limit
+	aging        time.Duration // Waiting this long raises a waiter's effective priority by one; 0 disables aging
+	waiting      int           // Acquires currently blocked waiting for a lock
+
+	breakDeadlocks bool   // Refuse acquires that would deadlock rather than only logging them
+	deadlocks      uint64 // Deadlocks detected so far
+
+	clock      Clock
+	lease      time.Duration            // How long a holder may go without a heartbeat; 0 disables expiry
+	deadlines  map[int32]time.Duration  // Clock reading at which each tracked client's locks expire unless it checks in
+	lockOrders map[int32]map[string]int // Position of each lock in the order a client declared
+	stop       chan struct{}            // Closed by Close to stop the lease sweeper and persist loop
+	sweeping   bool                     // Whether the lease sweeper has been started
+	stopOnce   sync.Once
+	draining   chan struct{} // Closed by Drain to turn away waiting and new acquires
+	drainOnce  sync.Once
+
+	seen           map[int32]time.Duration  // Clock reading of each client's last sign of life, kept for resourceLeases
+	resourceLeases map[string]time.Duration // Locks whose holders get their own lease instead of lease
+
+	lastToken    uint64        // Most recent fencing token issued; tokens only ever increase
+	restored     *Snapshot     // State to start from, set by WithRestoredState
+	restoreLease time.Duration // Deadline given to holders loaded from restored state
+
+	version          uint64 // Bumped on every ownership change
+	persister        Persister
+	persistThreshold time.Duration
+	persistPolicy    PersistPolicy
+	persistKick      chan struct{} // Signals the persist loop that the state changed
+	persistDone      chan struct{} // Closed when the persist loop has exited
+	persistMu        sync.Mutex    // Protects persisted and persistedCh
+	persisted        uint64        // Latest version the persister has stored
+	persistedCh      chan struct{} // Closed and replaced whenever persisted advances
+
+	onEvent   func(Event) // Set by WithEventHandler
+	holdTimes holdTimes   // How long exclusive holders kept their locks
+
+	// yield, if set, is called at points where other goroutines could
+	// interleave, letting tests force a particular order. Always nil outside tests.
+	yield func(point string, clientID int32)
+}
+
+// Option configures optional LockManager settings
+type Option func(*LockManager)
+
+// WithAntiAffinity makes a released lock go to the first waiter that isn't the
+// client who just released it, if there is one, so a client that still has an
+// acquire queued from before it was handed the lock can't take it straight back
+func WithAntiAffinity() Option {
+	return func(lm *LockManager) {
+		lm.antiAffinity = true
+	}
+}
+
+// WithMaxWaiters caps how many acquires may be blocked at once across all
+// locks. Once the limit is reached, acquires that would have to wait fail
+// straight away with ErrTooManyWaiters; acquires of a free lock still succeed.
+func WithMaxWaiters(n int) Option {
+	return func(lm *LockManager) {
+		lm.maxWaiters = n
+	}
+}
+
+// DefaultPriorityAging is how long a waiter waits for each step its effective
+// priority rises, unless set with WithPriorityAging
+const DefaultPriorityAging = time.Second
+
+// WithPriorityAging raises a waiting acquire's effective priority by one for
+// every d it has waited, so a steady stream of higher-priority acquires can't
+// starve lower ones forever. 0 disables aging, making priorities strict.
+func WithPriorityAging(d time.Duration) Option {
+	return func(lm *LockManager) {
+		lm.aging = d
+	}
+}
+
+// WithLease releases a client's locks if it goes longer than d without
+// acquiring or sending a heartbeat, so a crashed holder can't wedge the system.
+// A background sweeper checks for expired holders until Close is called.
+func WithLease(d time.Duration) Option {
+	return func(lm *LockManager) {
+		lm.lease = d
+	}
+}
+
+// WithClock replaces the system clock used for lease bookkeeping
+func WithClock(c Clock) Option {
+	return func(lm *LockManager) {
+		lm.clock = c
+	}
 }
 
 // NewLockManager initializes a new lock manager
-func NewLockManager(logger *log.Logger) *LockManager {
+func NewLockManager(logger *log.Logger, opts ...Option) *LockManager {
 	if logger == nil {
 		logger = log.New(os.Stdout, "[LockManager] ", log.LstdFlags)
 	}
 
 	lm := &LockManager{
-		lockHolder: -1, // No client holds the lock initially
+		locks:      make(map[string]*resourceLock),
 		logger:     logger,
-		queue:      make([]int32, 0),
+		clock:      realClock{start: time.Now()},
+		deadlines:  make(map[int32]time.Duration),
+		seen:       make(map[int32]time.Duration),
+		lockOrders: make(map[int32]map[string]int),
+		stop:       make(chan struct{}),
+		draining:   make(chan struct{}),
+		aging:      DefaultPriorityAging,
+
+		restoreLease:     DefaultRestoreLease,
+		persistThreshold: DefaultPersistThreshold,
+		persistKick:      make(chan struct{}, 1),
+		persistDone:      make(chan struct{}),
+		persistedCh:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(lm)
+	}
+
+	if lm.restored != nil {
+		lm.restore(*lm.restored)
+	}
+
+	// Restored holders get a deadline even when leases are off, so sweep for them too
+	if period := lm.shortestLease(); period > 0 {
+		lm.sweeping = true
+		go lm.sweepLeases(period)
+	} else if len(lm.deadlines) > 0 {
+		lm.sweeping = true
+		go lm.sweepLeases(lm.restoreLease)
+	}
+	if lm.persister != nil {
+		go lm.persistLoop()
 	}
-	lm.cond = sync.NewCond(&lm.mu)
 	return lm
 }
 
-// Acquire attempts to acquire the lock for the given client
-func (lm *LockManager) Acquire(clientID int32) bool {
-	lm.mu.Lock()
-	defer lm.mu.Unlock()
+// Drain fails every queued acquire, and any acquire made afterwards, with
+// ErrShuttingDown. Locks already held are unaffected and can still be released.
+func (lm *LockManager) Drain() {
+	lm.drainOnce.Do(func() {
+		lm.logger.Printf("Draining: turning away waiting and new acquires")
+		close(lm.draining)
+	})
+}
 
-	lm.logger.Printf("Client %d attempting to acquire lock", clientID)
+// Close stops background work such as the lease sweeper, flushing any
+// unpersisted lock state first. It also drains the lock manager.
+func (lm *LockManager) Close() {
+	lm.Drain()
+	lm.stopOnce.Do(func() { close(lm.stop) })
+	if lm.persister != nil {
+		<-lm.persistDone
+	}
+}
 
-	// Add client to queue for fairness
-	lm.queue = append(lm.queue, clientID)
+// lockFor returns the state for resource, creating it if needed. Must be called with lm.mu held.
+func (lm *LockManager) lockFor(resource string) *resourceLock {
+	rl, exists := lm.locks[resource]
+	if !exists {
+		rl = &resourceLock{holder: -1, readers: make(map[int32]struct{}), lastHolder: -1}
+		lm.locks[resource] = rl
+	}
+	return rl
+}
 
-	// Wait until the lock is free AND this client is at the front of the queue
-	for lm.lockHolder != -1 || (len(lm.queue) > 0 && lm.queue[0] != clientID) {
-		lm.logger.Printf("Client %d waiting for lock (currently held by %d)", clientID, lm.lockHolder)
-		lm.cond.Wait() // Unlocks mu, waits, then relocks mu when woken
+// holderOf returns the exclusive holder of resource without creating state. Must be called with lm.mu held.
+func (lm *LockManager) holderOf(resource string) int32 {
+	if rl, exists := lm.locks[resource]; exists {
+		return rl.holder
 	}
+	return -1
+}
 
-	// Remove client from queue
-	lm.queue = lm.queue[1:]
+// prune drops the state for resource if it is free and nobody waits. Must be called with lm.mu held.
+func (lm *LockManager) prune(resource string) {
+	if rl, exists := lm.locks[resource]; exists && rl.holder == -1 && len(rl.readers) == 0 && len(rl.queue) == 0 {
+		delete(lm.locks, resource)
+	}
+}
 
-	// Assign the lock to this client
-	lm.lockHolder = clientID
-	lm.logger.Printf("Lock acquired by client %d", clientID)
+// canGrant reports whether a request in the given mode is compatible with the current owners
+func (rl *resourceLock) canGrant(mode Mode) bool {
+	if mode == Shared {
+		return rl.holder == -1
+	}
+	return rl.holder == -1 && len(rl.readers) == 0
+}
 
-	return true
+// grant records clientID as an owner of the lock in the given mode
+func (rl *resourceLock) grant(clientID int32, mode Mode) {
+	if mode == Shared {
+		rl.readers[clientID] = struct{}{}
+	} else {
+		rl.holder = clientID
+	}
 }
 
-// AcquireWithTimeout attempts to acquire the lock with a timeout
+// grantTo makes clientID an owner of rl and starts its lease. Must be called with lm.mu held.
+func (lm *LockManager) grantTo(resource string, rl *resourceLock, clientID int32, mode Mode) {
+	rl.grant(clientID, mode)
+	if mode == Exclusive {
+		lm.lastToken++
+		rl.token = lm.lastToken
+		rl.heldSince = lm.clock.Monotonic()
+	}
+	lm.touch(clientID)
+	lm.changed()
+	lm.emit(Acquired, resource, clientID, mode)
+}
+
+// Acquire attempts to acquire the global lock for the given client, waiting as long as needed
+func (lm *LockManager) Acquire(clientID int32) bool {
+	return lm.AcquireWithTimeout(clientID, context.Background())
+}
+
+// AcquireWithTimeout attempts to acquire the global lock, giving up when ctx is done
 func (lm *LockManager) AcquireWithTimeout(clientID int32, ctx context.Context) bool {
+	return lm.AcquireResource(GlobalResource, clientID, ctx) == nil
+}
+
+// AcquireResource acquires the named lock exclusively for the given client,
+// returning ctx.Err() if ctx is done first
+func (lm *LockManager) AcquireResource(resource string, clientID int32, ctx context.Context) error {
+	return lm.acquire(resource, clientID, Exclusive, ctx)
+}
+
+// AcquireShared acquires the named lock in shared mode for the given client,
+// returning ctx.Err() if ctx is done first
+func (lm *LockManager) AcquireShared(resource string, clientID int32, ctx context.Context) error {
+	return lm.acquire(resource, clientID, Shared, ctx)
+}
+
+// AcquirePriority acquires the named lock in the given mode, queueing ahead of
+// waiters with a lower effective priority if it has to wait. AcquireResource and
+// AcquireShared use priority 0.
+func (lm *LockManager) AcquirePriority(resource string, clientID int32, mode Mode, priority int32, ctx context.Context) error {
+	if err := lm.wait(resource, clientID, mode, priority, ctx); err != nil {
+		return err
+	}
+	return lm.confirmGrant(resource, clientID, mode)
+}
+
+// acquire waits for the named lock in the given mode, then applies the
+// slow-persist policy to the grant
+func (lm *LockManager) acquire(resource string, clientID int32, mode Mode, ctx context.Context) error {
+	if err := lm.wait(resource, clientID, mode, 0, ctx); err != nil {
+		return err
+	}
+	return lm.confirmGrant(resource, clientID, mode)
+}
+
+// wait blocks until the named lock is granted in the given mode. Waiters are granted
+// the lock by effective priority, and in the order they arrived among equals; a new
+// reader never overtakes a queued writer of the same priority, so a steady stream of
+// readers can't starve writers.
+func (lm *LockManager) wait(resource string, clientID int32, mode Mode, priority int32, ctx context.Context) error {
 	lm.mu.Lock()
 
-	lm.logger.Printf("Client %d attempting to acquire lock with timeout", clientID)
+	lm.logger.Printf("Client %d attempting to acquire %s lock %q with timeout", clientID, mode, resource)
+	select {
+	case <-lm.draining:
+		lm.mu.Unlock()
+		lm.logger.Printf("Client %d turned away from lock %q: shutting down", clientID, resource)
+		return ErrShuttingDown
+	default:
+	}
+	if lm.holds(resource, clientID) {
+		lm.mu.Unlock()
+		lm.logger.Printf("Client %d already holds lock %q, refusing to wait on itself", clientID, resource)
+		return ErrAlreadyHeld
+	}
+	if err := lm.checkOrder(resource, clientID); err != nil {
+		lm.mu.Unlock()
+		return err
+	}
+	rl := lm.lockFor(resource)
 
-	// Add client to queue for fairness
-	lm.queue = append(lm.queue, clientID)
+	// Take the lock right away if it is compatible and nobody is ahead of us
+	if len(rl.queue) == 0 && rl.canGrant(mode) {
+		lm.grantTo(resource, rl, clientID, mode)
+		lm.logger.Printf("Lock %q acquired by client %d (%s)", resource, clientID, mode)
+		lm.mu.Unlock()
+		return nil
+	}
 
-	for lm.lockHolder != -1 || (len(lm.queue) > 0 && lm.queue[0] != clientID) {
-		// Set up a channel to signal when cond.Wait() returns
-		waitCh := make(chan struct{})
-		go func() {
-			lm.mu.Lock()
-			lm.cond.Wait() // Unlocks mu, waits, then relocks mu when woken
+	if lm.maxWaiters > 0 && lm.waiting >= lm.maxWaiters {
+		lm.mu.Unlock()
+		lm.logger.Printf("Client %d turned away from lock %q: %d acquires already waiting", clientID, resource, lm.maxWaiters)
+		return ErrTooManyWaiters
+	}
+	if rl.queued(clientID, mode) == nil {
+		if err := lm.checkDeadlock(resource, rl, clientID, mode); err != nil {
+			lm.prune(resource)
 			lm.mu.Unlock()
-			close(waitCh)
-		}()
+			return err
+		}
+	}
+	lm.waiting++
 
-		// Temporarily unlock while waiting
+	// If this client is already queued in the same mode, share that entry so a
+	// client firing off duplicate acquires takes one place in line, not many
+	w := rl.queued(clientID, mode)
+	if w != nil {
+		w.callers++
+		lm.logger.Printf("Client %d already waiting for %s lock %q, coalescing %d acquires", clientID, mode, resource, w.callers)
+	} else {
+		// Otherwise join the back of the queue and wait to be handed the lock
+		w = &waiter{
+			clientID: clientID,
+			mode:     mode,
+			priority: priority,
+			since:    lm.clock.Monotonic(),
+			ready:    make(chan struct{}),
+			evicted:  make(chan struct{}),
+			callers:  1,
+		}
+		rl.queue = append(rl.queue, w)
+		lm.logger.Printf("Client %d waiting for %s lock %q (currently held by %d, %d readers, position %d)",
+			clientID, mode, resource, rl.holder, len(rl.readers), len(rl.queue))
+	}
+	lm.mu.Unlock()
+	lm.pause("acquire.queued", clientID)
+
+	select {
+	case <-w.ready:
+		lm.pause("acquire.woken", clientID)
+		lm.mu.Lock()
+		w.callers--
+		w.accepted = true
+		lm.waiting--
 		lm.mu.Unlock()
+		lm.logger.Printf("Lock %q acquired by client %d (%s)", resource, clientID, mode)
+		return nil
+	case <-ctx.Done():
+		return lm.abandonWait(resource, w, ctx.Err(), "timed out")
+	case <-lm.draining:
+		return lm.abandonWait(resource, w, ErrShuttingDown, "shutting down")
+	case <-w.evicted:
+		return lm.abandonWait(resource, w, ErrEvicted, "evicted")
+	}
+}
 
-		// Wait for either the condition to be signaled or timeout
-		select {
-		case <-waitCh:
-			// Reacquire the lock and continue
-			lm.mu.Lock()
-		case <-ctx.Done():
-			// Timeout occurred, reacquire lock to clean up
-			lm.mu.Lock()
-
-			// Remove client from queue
-			for i, id := range lm.queue {
-				if id == clientID {
-					lm.queue = append(lm.queue[:i], lm.queue[i+1:]...)
-					break
-				}
-			}
+// abandonWait withdraws one caller waiting on w and returns err. The last
+// caller to leave takes w out of the queue for the named lock, or, if the lock
+// was handed to w in the meantime and nobody took it, passes it on.
+func (lm *LockManager) abandonWait(resource string, w *waiter, err error, reason string) error {
+	lm.pause("acquire.abandon", w.clientID)
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
 
-			lm.logger.Printf("Client %d timed out waiting for lock", clientID)
-			lm.mu.Unlock()
-			return false
+	w.callers--
+	lm.waiting--
+	select {
+	case <-w.ready:
+		// The lock may have been handed to us just as we gave up; pass it on
+		// unless a coalesced caller is returning with it
+		if w.callers == 0 && !w.accepted {
+			lm.logger.Printf("Client %d stopped waiting (%s) just as it was granted lock %q, passing it on", w.clientID, reason, resource)
+			lm.releaseLocked(resource, w.clientID, w.mode)
 		}
+		return err
+	default:
 	}
 
-	// Remove client from queue
-	lm.queue = lm.queue[1:]
+	if w.callers > 0 {
+		lm.logger.Printf("Client %d dropped one of %d coalesced acquires of lock %q: %s", w.clientID, w.callers+1, resource, reason)
+		return err
+	}
+	lm.removeWaiter(resource, w)
+	lm.logger.Printf("Client %d stopped waiting for lock %q: %s", w.clientID, resource, reason)
+	return err
+}
 
-	// Assign the lock to this client
-	lm.lockHolder = clientID
-	lm.logger.Printf("Lock acquired by client %d", clientID)
+// pause runs the test scheduling hook, if any, at the named point. It must be
+// called without lm.mu held so the hook can call back into the lock manager.
+func (lm *LockManager) pause(point string, clientID int32) {
+	if lm.yield != nil {
+		lm.yield(point, clientID)
+	}
+}
 
-	lm.mu.Unlock()
-	return true
+// dispatch hands the named lock to as many waiters at the head of its queue as
+// are compatible: one writer, or a run of consecutive readers.
+// Must be called with lm.mu held.
+func (lm *LockManager) dispatch(resource string) {
+	rl := lm.lockFor(resource)
+	lm.order(rl)
+	if lm.antiAffinity {
+		rl.skipLastHolder()
+	}
+	for len(rl.queue) > 0 && rl.canGrant(rl.queue[0].mode) {
+		next := rl.queue[0]
+		rl.queue = rl.queue[1:]
+		lm.grantTo(resource, rl, next.clientID, next.mode)
+		close(next.ready)
+	}
+	lm.prune(resource)
 }
 
-// Release attempts to release the lock for the given client
+// order sorts rl's queue by effective priority, highest first, keeping arrival
+// order among equals. Must be called with lm.mu held.
+func (lm *LockManager) order(rl *resourceLock) {
+	if len(rl.queue) < 2 {
+		return
+	}
+	now := lm.clock.Monotonic()
+	sort.SliceStable(rl.queue, func(i, j int) bool {
+		return lm.effectivePriority(rl.queue[i], now) > lm.effectivePriority(rl.queue[j], now)
+	})
+}
+
+// effectivePriority is w's priority raised by one for every aging period it has waited
+func (lm *LockManager) effectivePriority(w *waiter, now time.Duration) int64 {
+	p := int64(w.priority)
+	if lm.aging > 0 {
+		p += int64((now - w.since) / lm.aging)
+	}
+	return p
+}
+
+// skipLastHolder moves the first waiter that isn't the last holder to the head
+// of the queue when the last holder is next in line
+func (rl *resourceLock) skipLastHolder() {
+	if len(rl.queue) < 2 || rl.queue[0].clientID != rl.lastHolder {
+		return
+	}
+	for i, w := range rl.queue {
+		if w.clientID != rl.lastHolder {
+			copy(rl.queue[1:i+1], rl.queue[:i])
+			rl.queue[0] = w
+			return
+		}
+	}
+}
+
+// releaseLocked drops clientID's ownership of the named lock in the given mode
+// and wakes whoever is next. Must be called with lm.mu held.
+func (lm *LockManager) releaseLocked(resource string, clientID int32, mode Mode) {
+	lm.releaseAs(Released, resource, clientID, mode)
+}
+
+// releaseAs is releaseLocked, reporting the release to the event handler as kind
+func (lm *LockManager) releaseAs(kind EventKind, resource string, clientID int32, mode Mode) {
+	rl := lm.lockFor(resource)
+	if _, reading := rl.readers[clientID]; mode == Shared && reading {
+		delete(rl.readers, clientID)
+		lm.emit(kind, resource, clientID, mode)
+	} else if mode == Exclusive && rl.holder == clientID {
+		lm.endHold(rl)
+		rl.holder = -1
+		rl.lastHolder = clientID
+		lm.emit(kind, resource, clientID, mode)
+	}
+	lm.changed()
+	lm.dispatch(resource)
+}
+
+// queued returns the entry clientID is waiting on in the given mode, or nil
+func (rl *resourceLock) queued(clientID int32, mode Mode) *waiter {
+	for _, w := range rl.queue {
+		if w.clientID == clientID && w.mode == mode {
+			return w
+		}
+	}
+	return nil
+}
+
+// removeWaiter drops w from the queue of the named lock. Must be called with lm.mu held.
+func (lm *LockManager) removeWaiter(resource string, w *waiter) {
+	rl := lm.lockFor(resource)
+	for i, queued := range rl.queue {
+		if queued == w {
+			rl.queue = append(rl.queue[:i], rl.queue[i+1:]...)
+			break
+		}
+	}
+	// A departing writer may have been holding back readers behind it
+	lm.dispatch(resource)
+}
+
+// TryAcquire acquires the global lock only if it is immediately available
+func (lm *LockManager) TryAcquire(clientID int32) bool {
+	return lm.TryAcquireResource(GlobalResource, clientID)
+}
+
+// TryAcquireResource acquires the named lock exclusively for the given client only
+// if it is immediately available, never waiting. Returns false if the lock is held or clients are queued.
+func (lm *LockManager) TryAcquireResource(resource string, clientID int32) bool {
+	return lm.TryAcquireMode(resource, clientID, Exclusive) == nil
+}
+
+// TryAcquireShared acquires the named lock in shared mode only if it is immediately available
+func (lm *LockManager) TryAcquireShared(resource string, clientID int32) bool {
+	return lm.TryAcquireMode(resource, clientID, Shared) == nil
+}
+
+// TryAcquireMode acquires the named lock in the given mode only if it is
+// immediately available, returning ErrLockBusy if it isn't. Like a waiting
+// acquire, the grant is then subject to the slow-persist policy, and fails with
+// ErrServerBusy if PersistReject gives it back.
+func (lm *LockManager) TryAcquireMode(resource string, clientID int32, mode Mode) error {
+	if err := lm.tryAcquire(resource, clientID, mode); err != nil {
+		return err
+	}
+	return lm.confirmGrant(resource, clientID, mode)
+}
+
+func (lm *LockManager) tryAcquire(resource string, clientID int32, mode Mode) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	if lm.holds(resource, clientID) {
+		return ErrAlreadyHeld
+	}
+	if err := lm.checkOrder(resource, clientID); err != nil {
+		return err
+	}
+	rl := lm.lockFor(resource)
+	if len(rl.queue) > 0 || !rl.canGrant(mode) {
+		lm.logger.Printf("Client %d try-acquire of %s lock %q failed: lock busy (held by %d, %d readers)",
+			clientID, mode, resource, rl.holder, len(rl.readers))
+		lm.prune(resource)
+		return ErrLockBusy
+	}
+
+	lm.grantTo(resource, rl, clientID, mode)
+	lm.logger.Printf("Lock %q acquired by client %d (%s, try-acquire)", resource, clientID, mode)
+	return nil
+}
+
+// CompareAndAcquire atomically hands the global lock to newHolder if expectedHolder holds it
+func (lm *LockManager) CompareAndAcquire(expectedHolder, newHolder int32) bool {
+	return lm.CompareAndAcquireResource(GlobalResource, expectedHolder, newHolder)
+}
+
+// CompareAndAcquireResource atomically hands the named lock to newHolder if
+// expectedHolder currently holds it exclusively. An expectedHolder of -1 takes a
+// free lock, but only when no clients are queued so waiters are not jumped.
+func (lm *LockManager) CompareAndAcquireResource(resource string, expectedHolder, newHolder int32) bool {
+	return lm.HandOff(resource, expectedHolder, newHolder) == nil
+}
+
+// TransferResource hands the named lock straight from its exclusive holder to
+// another client, with a new fencing token, without letting queued clients in
+// between. Returns false if from doesn't hold the lock exclusively.
+func (lm *LockManager) TransferResource(resource string, from, to int32) bool {
+	if from == -1 {
+		return false
+	}
+	return lm.HandOff(resource, from, to) == nil
+}
+
+// HandOff is CompareAndAcquireResource returning why it failed: ErrNotHolder
+// if expectedHolder doesn't hold the lock, or ErrServerBusy if the slow-persist
+// policy undid the handoff, giving the lock back to expectedHolder under a new
+// fencing token, or freeing it if expectedHolder was -1
+func (lm *LockManager) HandOff(resource string, expectedHolder, newHolder int32) error {
+	if err := lm.handOff(resource, expectedHolder, newHolder); err != nil {
+		return err
+	}
+	return lm.confirmHandOff(resource, expectedHolder, newHolder)
+}
+
+func (lm *LockManager) handOff(resource string, expectedHolder, newHolder int32) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	if err := lm.checkOrder(resource, newHolder); err != nil {
+		return err
+	}
+	rl := lm.lockFor(resource)
+	free := len(rl.readers) == 0 && len(rl.queue) == 0
+	if rl.holder != expectedHolder || (expectedHolder == -1 && !free) {
+		lm.logger.Printf("Compare-and-acquire of %q failed: expected holder %d, current holder %d",
+			resource, expectedHolder, rl.holder)
+		lm.prune(resource)
+		return ErrNotHolder
+	}
+
+	lm.swapHolder(resource, rl, expectedHolder, newHolder)
+	lm.logger.Printf("Lock %q handed from client %d to client %d", resource, expectedHolder, newHolder)
+	return nil
+}
+
+// swapHolder hands rl from its exclusive holder from, or from nobody if from
+// is -1, to to. Must be called with lm.mu held.
+func (lm *LockManager) swapHolder(resource string, rl *resourceLock, from, to int32) {
+	if from != -1 {
+		lm.endHold(rl)
+		lm.emit(Released, resource, from, Exclusive)
+	}
+	lm.grantTo(resource, rl, to, Exclusive)
+}
+
+// Release attempts to release the global lock for the given client
 func (lm *LockManager) Release(clientID int32) bool {
+	return lm.ReleaseResource(GlobalResource, clientID)
+}
+
+// ReleaseResource attempts to release the client's exclusive hold on the named lock
+func (lm *LockManager) ReleaseResource(resource string, clientID int32) bool {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
 
-	lm.logger.Printf("Client %d attempting to release lock", clientID)
+	lm.logger.Printf("Client %d attempting to release lock %q", clientID, resource)
 
 	// Check if this client holds the lock
-	if lm.lockHolder == clientID {
-		lm.lockHolder = -1 // Free the lock
-		lm.logger.Printf("Lock released by client %d", clientID)
-		lm.cond.Broadcast() // Wake all waiting clients
+	if holder := lm.holderOf(resource); holder == clientID {
+		lm.logger.Printf("Lock %q released by client %d", resource, clientID)
+		lm.releaseLocked(resource, clientID, Exclusive) // Hand the lock to the next waiter in line
 		return true
 	}
 
 	// Client doesn't hold the lock
-	lm.logger.Printf("Lock release failed: client %d doesn't hold the lock (current holder: %d)",
-		clientID, lm.lockHolder)
+	lm.logger.Printf("Lock release failed: client %d doesn't hold lock %q (current holder: %d)",
+		clientID, resource, lm.holderOf(resource))
 	return false
 }
 
-// HasLock checks if the given client holds the lock
+// ReleaseShared attempts to release the client's shared hold on the named lock
+func (lm *LockManager) ReleaseShared(resource string, clientID int32) bool {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	if !lm.isReader(resource, clientID) {
+		lm.logger.Printf("Shared release failed: client %d doesn't hold a shared lock on %q", clientID, resource)
+		return false
+	}
+
+	lm.logger.Printf("Shared lock %q released by client %d", resource, clientID)
+	lm.releaseLocked(resource, clientID, Shared)
+	return true
+}
+
+// holds reports whether clientID holds the named lock in either mode. Must be called with lm.mu held.
+func (lm *LockManager) holds(resource string, clientID int32) bool {
+	if holder := lm.holderOf(resource); holder != -1 && holder == clientID {
+		return true
+	}
+	return lm.isReader(resource, clientID)
+}
+
+// isReader reports whether clientID holds the named lock in shared mode. Must be called with lm.mu held.
+func (lm *LockManager) isReader(resource string, clientID int32) bool {
+	rl, exists := lm.locks[resource]
+	if !exists {
+		return false
+	}
+	_, reading := rl.readers[clientID]
+	return reading
+}
+
+// HasLock checks if the given client holds the global lock
 func (lm *LockManager) HasLock(clientID int32) bool {
+	return lm.HasResourceLock(GlobalResource, clientID)
+}
+
+// HasResourceLock checks if the given client holds the named lock exclusively
+func (lm *LockManager) HasResourceLock(resource string, clientID int32) bool {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.holderOf(resource) == clientID
+}
+
+// HasSharedLock checks if the given client holds the named lock in shared mode
+func (lm *LockManager) HasSharedLock(resource string, clientID int32) bool {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
-	return lm.lockHolder == clientID
+	return lm.isReader(resource, clientID)
 }
 
-// ReleaseLockIfHeld releases the lock if the given client holds it
+// ReleaseLockIfHeld releases every lock the given client holds, in either mode
 func (lm *LockManager) ReleaseLockIfHeld(clientID int32) {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
+	lm.releaseAll(clientID, "closing")
+}
+
+// ReleaseAll releases every lock the given client holds, in either mode, and
+// hands each to its next waiter. It returns how many locks were released.
+func (lm *LockManager) ReleaseAll(clientID int32) int {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.releaseAll(clientID, "releasing all its locks")
+}
 
-	// If this client holds the lock, release it
-	if lm.lockHolder == clientID {
-		lm.lockHolder = -1
-		lm.logger.Printf("Lock released due to client %d closing", clientID)
-		lm.cond.Broadcast()
+// releaseAll releases clientID's locks, logging why. Must be called with lm.mu held.
+func (lm *LockManager) releaseAll(clientID int32, reason string) int {
+	released := 0
+	for resource, rl := range lm.locks {
+		if rl.holder == clientID {
+			lm.logger.Printf("Lock %q released due to client %d %s", resource, clientID, reason)
+			lm.releaseLocked(resource, clientID, Exclusive)
+			released++
+		} else if _, reading := rl.readers[clientID]; reading {
+			lm.logger.Printf("Shared lock %q released due to client %d %s", resource, clientID, reason)
+			lm.releaseLocked(resource, clientID, Shared)
+			released++
+		}
 	}
+	return released
 }
 
-// IsLocked returns true if the lock is currently held
-func (lm *LockManager) IsLocked() bool {
+// Evict releases every lock clientID holds and fails its waiting acquires with
+// ErrEvicted, so the clients queued behind it move up
+func (lm *LockManager) Evict(clientID int32) {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
-	return lm.lockHolder != -1
+
+	for resource, rl := range lm.locks {
+		var evicted []*waiter
+		for _, w := range rl.queue {
+			if w.clientID == clientID {
+				evicted = append(evicted, w)
+			}
+		}
+		for _, w := range evicted {
+			lm.removeWaiter(resource, w)
+			close(w.evicted)
+			lm.logger.Printf("Client %d evicted from the queue for %s lock %q", clientID, w.mode, resource)
+		}
+
+		if rl.holder == clientID {
+			lm.logger.Printf("Lock %q released due to client %d being evicted", resource, clientID)
+			lm.releaseLocked(resource, clientID, Exclusive)
+		} else if _, reading := rl.readers[clientID]; reading {
+			lm.logger.Printf("Shared lock %q released due to client %d being evicted", resource, clientID)
+			lm.releaseLocked(resource, clientID, Shared)
+		}
+	}
+}
+
+// ForceRelease takes the named lock from its exclusive holder whoever that is,
+// for breaking a lock left behind by a crashed client, and hands it to the next
+// waiter. The fencing token moves on even if nobody is waiting, so writes
+// still carrying the old holder's token are refused. It returns the client
+// that held the lock, or false if it was free.
+func (lm *LockManager) ForceRelease(resource string) (int32, bool) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	holder := lm.holderOf(resource)
+	if holder == -1 {
+		return -1, false
+	}
+	lm.lastToken++
+	lm.logger.Printf("Lock %q forcibly taken from client %d", resource, holder)
+	lm.releaseLocked(resource, holder, Exclusive)
+	return holder, true
 }
 
-// CurrentHolder returns the ID of the client holding the lock, or -1 if free
+// Heartbeat records that clientID is still alive, extending the lease on every lock it holds
+func (lm *LockManager) Heartbeat(clientID int32) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.touch(clientID)
+}
+
+// touch pushes back clientID's lease deadline after a sign of life. Must be called with lm.mu held.
+func (lm *LockManager) touch(clientID int32) {
+	if len(lm.resourceLeases) > 0 {
+		lm.seen[clientID] = lm.clock.Monotonic()
+	}
+	if lm.lease > 0 {
+		lm.deadlines[clientID] = lm.clock.Monotonic() + lm.lease
+	} else {
+		// Without leases only restored holders have deadlines; checking in proves they're alive
+		delete(lm.deadlines, clientID)
+	}
+}
+
+// ForgetClient drops liveness tracking and the declared lock order for a client that has closed
+func (lm *LockManager) ForgetClient(clientID int32) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	delete(lm.deadlines, clientID)
+	delete(lm.seen, clientID)
+	delete(lm.lockOrders, clientID)
+}
+
+// KnowsClient reports whether clientID holds or waits for any lock, or has a
+// lease or lock order on record
+func (lm *LockManager) KnowsClient(clientID int32) bool {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	if _, ok := lm.deadlines[clientID]; ok {
+		return true
+	}
+	if _, ok := lm.lockOrders[clientID]; ok {
+		return true
+	}
+	for _, rl := range lm.locks {
+		if _, reading := rl.readers[clientID]; rl.holder == clientID || reading {
+			return true
+		}
+		for _, w := range rl.queue {
+			if w.clientID == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sweepLeases periodically releases locks held by clients whose lease ran out
+func (lm *LockManager) sweepLeases(period time.Duration) {
+	interval := period / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			lm.ExpireLeases()
+		case <-lm.stop:
+			return
+		}
+	}
+}
+
+// ExpireLeases releases every lock whose holder hasn't been heard from within
+// that lock's lease and returns the IDs of the clients that were expired:
+// those that lost a lock, and those whose own lease ran out
+func (lm *LockManager) ExpireLeases() []int32 {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	now := lm.clock.Monotonic()
+	expired := lm.expireHolds(now, nil)
+	lm.forgetSilent(now)
+	for clientID, deadline := range lm.deadlines {
+		if now <= deadline {
+			continue
+		}
+		delete(lm.deadlines, clientID)
+		if !slices.Contains(expired, clientID) {
+			expired = append(expired, clientID)
+		}
+	}
+	return expired
+}
+
+// FencingToken returns the token issued when clientID took the named lock
+// exclusively. Tokens grow with every exclusive grant, so storage can reject
+// writes carrying an older token than one it has already seen.
+func (lm *LockManager) FencingToken(resource string, clientID int32) (uint64, bool) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	rl, exists := lm.locks[resource]
+	if !exists || rl.holder != clientID {
+		return 0, false
+	}
+	return rl.token, true
+}
+
+// Status is a point-in-time view of one lock
+type Status struct {
+	Holder         int32         // Exclusive holder, -1 if none
+	Readers        int           // Number of shared holders
+	Waiters        int           // Number of queued acquirers
+	LeaseRemaining time.Duration // Time left on the holder's lease; 0 if leases are off or there's no holder
+}
+
+// Status reports the holder, readers and queue length of the named lock in one consistent snapshot
+func (lm *LockManager) Status(resource string) Status {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	st := Status{Holder: -1}
+	rl, exists := lm.locks[resource]
+	if !exists {
+		return st
+	}
+	st.Holder = rl.holder
+	st.Readers = len(rl.readers)
+	st.Waiters = len(rl.queue)
+
+	if rl.holder != -1 {
+		if deadline, ok := lm.leaseDeadline(resource, rl.holder); ok {
+			if remaining := deadline - lm.clock.Monotonic(); remaining > 0 {
+				st.LeaseRemaining = remaining
+			}
+		}
+	}
+	return st
+}
+
+// LockInfo is a point-in-time view of one lock, by name
+type LockInfo struct {
+	Resource string
+	Holder   int32  // Exclusive holder, -1 if none
+	Token    uint64 // Fencing token of the exclusive holder, 0 if none
+	Readers  int    // Number of shared holders
+	Waiters  int    // Number of queued acquirers
+}
+
+// AllLocks reports every lock that is held or waited for, sorted by name, in
+// one consistent snapshot
+func (lm *LockManager) AllLocks() []LockInfo {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	locks := make([]LockInfo, 0, len(lm.locks))
+	for resource, rl := range lm.locks {
+		info := LockInfo{Resource: resource, Holder: rl.holder, Readers: len(rl.readers), Waiters: len(rl.queue)}
+		if rl.holder != -1 {
+			info.Token = rl.token
+		}
+		locks = append(locks, info)
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Resource < locks[j].Resource })
+	return locks
+}
+
+// QueuePosition reports how many waiters are ahead of clientID in the named
+// lock's queue, and false if the client isn't waiting for that lock
+func (lm *LockManager) QueuePosition(resource string, clientID int32) (int, bool) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	rl, exists := lm.locks[resource]
+	if !exists {
+		return 0, false
+	}
+	lm.order(rl)
+	for i, w := range rl.queue {
+		if w.clientID == clientID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// QueuedByMode counts the clients queued across all locks, by the mode they're waiting for
+func (lm *LockManager) QueuedByMode() map[Mode]int {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	counts := map[Mode]int{Exclusive: 0, Shared: 0}
+	for _, rl := range lm.locks {
+		for _, w := range rl.queue {
+			counts[w.mode]++
+		}
+	}
+	return counts
+}
+
+// IsLocked returns true if the global lock is currently held
+func (lm *LockManager) IsLocked() bool {
+	return lm.CurrentHolder() != -1
+}
+
+// CurrentHolder returns the ID of the client holding the global lock, or -1 if free
 func (lm *LockManager) CurrentHolder() int32 {
+	return lm.ResourceHolder(GlobalResource)
+}
+
+// ResourceHolder returns the ID of the client holding the named lock exclusively, or -1 if none
+func (lm *LockManager) ResourceHolder(resource string) int32 {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
-	return lm.lockHolder
+	return lm.holderOf(resource)
 }
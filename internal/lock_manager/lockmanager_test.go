@@ -2,6 +2,7 @@ package lock_manager
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
@@ -176,6 +177,62 @@ func TestReleaseLockIfHeld(t *testing.T) {
 	}
 }
 
+func TestTryAcquire(t *testing.T) {
+	lm := NewLockManager(nil)
+
+	// Lock is free, so try-acquire succeeds immediately
+	if !lm.TryAcquire(1) {
+		t.Fatal("TryAcquire should succeed on a free lock")
+	}
+
+	// Lock is held, so try-acquire must report busy without blocking
+	if lm.TryAcquire(2) {
+		t.Error("TryAcquire should fail while client 1 holds the lock")
+	}
+	if !lm.HasLock(1) {
+		t.Error("Client 1 should still hold the lock")
+	}
+
+	// Once released, the next try-acquire succeeds
+	lm.Release(1)
+	if !lm.TryAcquire(2) {
+		t.Error("TryAcquire should succeed after client 1 released the lock")
+	}
+
+	// Clean up
+	lm.Release(2)
+}
+
+func TestCompareAndAcquire(t *testing.T) {
+	lm := NewLockManager(nil)
+	lm.Acquire(1)
+
+	// Handoff from the known predecessor succeeds
+	if !lm.CompareAndAcquire(1, 2) {
+		t.Fatal("CompareAndAcquire(1, 2) should succeed while client 1 holds the lock")
+	}
+	if lm.CurrentHolder() != 2 {
+		t.Errorf("Current holder should be 2, got %d", lm.CurrentHolder())
+	}
+
+	// Handoff with a stale expected holder fails and leaves the lock untouched
+	if lm.CompareAndAcquire(1, 3) {
+		t.Error("CompareAndAcquire(1, 3) should fail while client 2 holds the lock")
+	}
+	if lm.CurrentHolder() != 2 {
+		t.Errorf("Current holder should still be 2, got %d", lm.CurrentHolder())
+	}
+
+	// Taking a free lock works with an expected holder of -1
+	lm.Release(2)
+	if !lm.CompareAndAcquire(-1, 3) {
+		t.Error("CompareAndAcquire(-1, 3) should succeed on a free lock")
+	}
+
+	// Clean up
+	lm.Release(3)
+}
+
 func TestLockContention(t *testing.T) {
 	lm := NewLockManager(nil)
 
@@ -316,6 +373,724 @@ func TestFIFOFairness(t *testing.T) {
 	lm.Release(3)
 }
 
+// waitForQueueLen blocks until n clients are queued for the global lock
+func waitForQueueLen(t *testing.T, lm *LockManager, n int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		lm.mu.Lock()
+		queued := len(lm.lockFor(GlobalResource).queue)
+		lm.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d queued clients", n)
+}
+
+func TestFIFOOrderManyWaiters(t *testing.T) {
+	lm := NewLockManager(nil)
+	const numWaiters = 10
+
+	// Client 0 holds the lock while the others queue up
+	lm.Acquire(0)
+
+	order := make(chan int32, numWaiters)
+	for i := 1; i <= numWaiters; i++ {
+		id := int32(i)
+		go func() {
+			lm.Acquire(id)
+			order <- id
+			lm.Release(id)
+		}()
+		// Wait for each client to be queued so arrival order is known
+		waitForQueueLen(t, lm, i)
+	}
+
+	lm.Release(0)
+
+	for want := int32(1); want <= numWaiters; want++ {
+		select {
+		case got := <-order:
+			if got != want {
+				t.Errorf("Acquisition %d went to client %d, want client %d", want, got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for client %d to acquire the lock", want)
+		}
+	}
+}
+
+func TestReleaseWakesOneWaiter(t *testing.T) {
+	lm := NewLockManager(nil)
+	const numWaiters = 50
+
+	// Client 0 holds the lock while the others queue up. Nobody who gets the
+	// lock releases it, so every acquisition needs a release of its own.
+	lm.Acquire(0)
+	cancels := make(map[int32]context.CancelFunc)
+	acquired := make(chan int32, numWaiters)
+	for i := 1; i <= numWaiters; i++ {
+		id := int32(i)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels[id] = cancel
+		go func() {
+			if lm.AcquireWithTimeout(id, ctx) {
+				acquired <- id
+			}
+		}()
+		waitForQueueLen(t, lm, i)
+	}
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	expectOnly := func(want int32, queued int) {
+		t.Helper()
+		select {
+		case got := <-acquired:
+			if got != want {
+				t.Errorf("Lock went to client %d, want client %d", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for client %d to acquire the lock", want)
+		}
+		// Give anyone else wrongly woken time to show up
+		select {
+		case got := <-acquired:
+			t.Errorf("Client %d also acquired the lock after a single release", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+		waitForQueueLen(t, lm, queued)
+	}
+
+	lm.Release(0)
+	expectOnly(1, numWaiters-1)
+
+	// A waiter that gave up is skipped and the one behind it woken instead
+	cancels[2]()
+	waitForQueueLen(t, lm, numWaiters-2)
+	lm.Release(1)
+	expectOnly(3, numWaiters-3)
+}
+
+func TestPriorityOrder(t *testing.T) {
+	// Client 1 queues first with priority 0 and waits 25s before the rest arrive
+	waiters := []struct {
+		clientID int32
+		priority int32
+	}{{1, 0}, {2, 1}, {3, 5}, {4, 1}, {5, 3}}
+
+	tests := []struct {
+		name  string
+		aging time.Duration
+		want  []int32
+	}{
+		// Strict priorities, ties in arrival order
+		{"no aging", 0, []int32{3, 5, 2, 4, 1}},
+		// Two aging periods lift client 1 to 2, past the later priority 1 waiters
+		{"aging", 10 * time.Second, []int32{3, 5, 1, 2, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{}
+			lm := NewLockManager(nil, WithClock(clock), WithPriorityAging(tt.aging))
+			defer lm.Close()
+			lm.Acquire(0)
+
+			order := make(chan int32, len(waiters))
+			for i, w := range waiters {
+				go func() {
+					if err := lm.AcquirePriority(GlobalResource, w.clientID, Exclusive, w.priority, context.Background()); err != nil {
+						t.Errorf("Client %d acquire failed: %v", w.clientID, err)
+						return
+					}
+					order <- w.clientID
+					lm.Release(w.clientID)
+				}()
+				waitForQueueLen(t, lm, i+1)
+				if i == 0 {
+					clock.Advance(25 * time.Second)
+				}
+			}
+
+			if pos, _ := lm.QueuePosition(GlobalResource, tt.want[0]); pos != 0 {
+				t.Errorf("Client %d should be first in the queue, is at %d", tt.want[0], pos)
+			}
+			lm.Release(0)
+			for _, want := range tt.want {
+				select {
+				case got := <-order:
+					if got != want {
+						t.Errorf("Lock went to client %d, want client %d", got, want)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("Timed out waiting for client %d to acquire the lock", want)
+				}
+			}
+		})
+	}
+}
+
+func TestTimedOutWaiterLeavesQueue(t *testing.T) {
+	lm := NewLockManager(nil)
+	lm.Acquire(1)
+
+	// Client 2 gives up while client 3 keeps waiting behind it
+	ctx, cancel := context.WithCancel(context.Background())
+	done2 := make(chan bool)
+	go func() { done2 <- lm.AcquireWithTimeout(2, ctx) }()
+	waitForQueueLen(t, lm, 1)
+
+	done3 := make(chan bool)
+	go func() { done3 <- lm.Acquire(3) }()
+	waitForQueueLen(t, lm, 2)
+
+	cancel()
+	if <-done2 {
+		t.Fatal("Cancelled client 2 should not acquire the lock")
+	}
+	waitForQueueLen(t, lm, 1)
+
+	// The lock skips the cancelled waiter and goes to client 3
+	lm.Release(1)
+	select {
+	case <-done3:
+	case <-time.After(time.Second):
+		t.Fatal("Client 3 didn't acquire the lock after client 1 released it")
+	}
+	if lm.CurrentHolder() != 3 {
+		t.Errorf("Current holder should be 3, got %d", lm.CurrentHolder())
+	}
+}
+
+func TestDuplicateAcquiresShareOneQueueSlot(t *testing.T) {
+	lm := NewLockManager(nil)
+	lm.Acquire(1)
+
+	// Client 2 fires off several acquires at once
+	const duplicates = 5
+	done := make(chan bool, duplicates)
+	for i := 0; i < duplicates; i++ {
+		go func() { done <- lm.Acquire(2) }()
+	}
+
+	// They all join the same entry instead of taking a place each
+	deadline := time.Now().Add(time.Second)
+	for {
+		lm.mu.Lock()
+		callers := 0
+		if rl := lm.locks[GlobalResource]; len(rl.queue) > 0 {
+			callers = rl.queue[0].callers
+		}
+		lm.mu.Unlock()
+		if callers == duplicates {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d coalesced callers, got %d", duplicates, callers)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if waiters := lm.Status(GlobalResource).Waiters; waiters != 1 {
+		t.Errorf("Expected duplicate acquires to use 1 queue slot, got %d", waiters)
+	}
+
+	// One release satisfies every caller together
+	lm.Release(1)
+	for i := 0; i < duplicates; i++ {
+		select {
+		case ok := <-done:
+			if !ok {
+				t.Errorf("Coalesced acquire %d failed", i)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Only %d of %d coalesced acquires returned", i, duplicates)
+		}
+	}
+	if lm.CurrentHolder() != 2 {
+		t.Errorf("Current holder should be 2, got %d", lm.CurrentHolder())
+	}
+}
+
+func TestCoalescedAcquireTimeoutKeepsSlot(t *testing.T) {
+	lm := NewLockManager(nil)
+	lm.Acquire(1)
+
+	// One of client 2's duplicate acquires gives up; the other keeps its place
+	ctx, cancel := context.WithCancel(context.Background())
+	impatient := make(chan bool)
+	go func() { impatient <- lm.AcquireWithTimeout(2, ctx) }()
+	waitForQueueLen(t, lm, 1)
+	patient := make(chan bool)
+	go func() { patient <- lm.Acquire(2) }()
+	go func() { lm.Acquire(3) }()
+	waitForQueueLen(t, lm, 2)
+
+	cancel()
+	if <-impatient {
+		t.Fatal("Cancelled acquire should not get the lock")
+	}
+	if waiters := lm.Status(GlobalResource).Waiters; waiters != 2 {
+		t.Errorf("Expected client 2 to keep its slot ahead of client 3, got %d waiters", waiters)
+	}
+
+	lm.Release(1)
+	select {
+	case ok := <-patient:
+		if !ok || lm.CurrentHolder() != 2 {
+			t.Errorf("Expected the remaining acquire to get the lock, holder is %d", lm.CurrentHolder())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Remaining coalesced acquire never got the lock")
+	}
+}
+
+func TestLockHandedToAbandoningWaiterIsPassedOn(t *testing.T) {
+	lm := NewLockManager(nil)
+	lm.Acquire(1)
+	firstToken, _ := lm.FencingToken(GlobalResource, 1)
+
+	// Force the release to land after client 2 gives up but before it has
+	// left the queue, so the lock is handed to a waiter that is already gone.
+	// This used to depend on the timing of the timeout and the release.
+	lm.yield = func(point string, clientID int32) {
+		if point == "acquire.abandon" && clientID == 2 {
+			lm.Release(1)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gaveUp := make(chan bool)
+	go func() { gaveUp <- lm.AcquireWithTimeout(2, ctx) }()
+	waitForQueueLen(t, lm, 1)
+	third := make(chan bool)
+	go func() { third <- lm.Acquire(3) }()
+	waitForQueueLen(t, lm, 2)
+
+	cancel()
+	if <-gaveUp {
+		t.Fatal("Cancelled acquire should report failure")
+	}
+	select {
+	case <-third:
+	case <-time.After(time.Second):
+		t.Fatalf("Lock stuck with the departed waiter, holder is %d", lm.CurrentHolder())
+	}
+	if lm.CurrentHolder() != 3 {
+		t.Errorf("Expected the lock to pass to client 3, holder is %d", lm.CurrentHolder())
+	}
+	if _, ok := lm.FencingToken(GlobalResource, 2); ok {
+		t.Error("Client 2 gave up and should hold no valid token")
+	}
+	if token, _ := lm.FencingToken(GlobalResource, 3); token <= firstToken+1 {
+		t.Errorf("Client 3's token %d should be past the one briefly issued to client 2 (first was %d)", token, firstToken)
+	}
+}
+
+func TestWokenWaiterOwnsLockBeforeReturning(t *testing.T) {
+	lm := NewLockManager(nil)
+	lm.Acquire(1)
+
+	// Pause client 2 between being handed the lock and returning with it, and
+	// check the old holder can no longer act on it in that window
+	checked := make(chan struct{})
+	lm.yield = func(point string, clientID int32) {
+		if point != "acquire.woken" || clientID != 2 {
+			return
+		}
+		defer close(checked)
+		if lm.Release(1) {
+			t.Error("Old holder released a lock it had already handed over")
+		}
+		if lm.CompareAndAcquire(1, 3) {
+			t.Error("Old holder's compare-and-acquire succeeded after the handoff")
+		}
+		if _, ok := lm.FencingToken(GlobalResource, 2); !ok {
+			t.Error("New holder's token should be valid as soon as it is handed the lock")
+		}
+	}
+
+	done := make(chan bool)
+	go func() { done <- lm.Acquire(2) }()
+	waitForQueueLen(t, lm, 1)
+	lm.Release(1)
+	<-checked
+	if !<-done || lm.CurrentHolder() != 2 {
+		t.Errorf("Client 2 should return holding the lock, holder is %d", lm.CurrentHolder())
+	}
+}
+
+func TestPerResourceLocks(t *testing.T) {
+	lm := NewLockManager(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Two clients hold locks on unrelated resources at the same time
+	if err := lm.AcquireResource("file_3", 1, ctx); err != nil {
+		t.Fatalf("Client 1 failed to acquire file_3: %v", err)
+	}
+	if err := lm.AcquireResource("file_47", 2, ctx); err != nil {
+		t.Fatalf("Client 2 failed to acquire file_47 while file_3 is held: %v", err)
+	}
+	if !lm.HasResourceLock("file_3", 1) || !lm.HasResourceLock("file_47", 2) {
+		t.Error("Both clients should hold their resource locks")
+	}
+
+	// Per-resource locks don't affect the global lock
+	if lm.IsLocked() {
+		t.Error("Global lock should be free")
+	}
+
+	// A second client on the same resource still has to wait
+	if lm.TryAcquireResource("file_3", 2) {
+		t.Error("Client 2 should not get file_3 while client 1 holds it")
+	}
+
+	// Releasing one resource leaves the other held, and frees its state
+	lm.ReleaseResource("file_3", 1)
+	if lm.ResourceHolder("file_3") != -1 {
+		t.Error("file_3 should be free after release")
+	}
+	if lm.ResourceHolder("file_47") != 2 {
+		t.Error("file_47 should still be held by client 2")
+	}
+	lm.ReleaseResource("file_47", 2)
+
+	lm.mu.Lock()
+	remaining := len(lm.locks)
+	lm.mu.Unlock()
+	if remaining != 0 {
+		t.Errorf("Expected no lock state after all releases, found %d entries", remaining)
+	}
+}
+
+func TestSharedReadersConcurrent(t *testing.T) {
+	lm := NewLockManager(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Several readers hold the lock at once
+	for id := int32(1); id <= 3; id++ {
+		if err := lm.AcquireShared("file_0", id, ctx); err != nil {
+			t.Fatalf("Reader %d failed to acquire shared lock: %v", id, err)
+		}
+	}
+	for id := int32(1); id <= 3; id++ {
+		if !lm.HasSharedLock("file_0", id) {
+			t.Errorf("Reader %d should hold the shared lock", id)
+		}
+	}
+
+	// A shared hold is not an exclusive one
+	if lm.HasResourceLock("file_0", 1) {
+		t.Error("Reader should not be reported as the exclusive holder")
+	}
+	if lm.ReleaseResource("file_0", 1) {
+		t.Error("Exclusive release should fail for a shared holder")
+	}
+
+	for id := int32(1); id <= 3; id++ {
+		if !lm.ReleaseShared("file_0", id) {
+			t.Errorf("Reader %d failed to release shared lock", id)
+		}
+	}
+}
+
+func TestWriterExclusivity(t *testing.T) {
+	lm := NewLockManager(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// A writer excludes readers...
+	lm.AcquireResource("file_0", 1, ctx)
+	if lm.TryAcquireShared("file_0", 2) {
+		t.Error("Reader should not get the lock while a writer holds it")
+	}
+	lm.ReleaseResource("file_0", 1)
+
+	// ...and readers exclude writers until they all drain
+	lm.AcquireShared("file_0", 2, ctx)
+	lm.AcquireShared("file_0", 3, ctx)
+
+	writerDone := make(chan error, 1)
+	go func() { writerDone <- lm.AcquireResource("file_0", 4, ctx) }()
+
+	lm.ReleaseShared("file_0", 2)
+	select {
+	case <-writerDone:
+		t.Fatal("Writer acquired the lock while a reader still held it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lm.ReleaseShared("file_0", 3)
+	select {
+	case err := <-writerDone:
+		if err != nil {
+			t.Fatalf("Writer failed to acquire after readers drained: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Writer didn't acquire the lock after all readers released")
+	}
+	lm.ReleaseResource("file_0", 4)
+}
+
+func TestWriterNotStarvedByReaders(t *testing.T) {
+	lm := NewLockManager(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// A reader holds the lock when a writer arrives
+	lm.AcquireShared("file_0", 1, ctx)
+	writerDone := make(chan struct{})
+	go func() {
+		lm.AcquireResource("file_0", 100, ctx)
+		close(writerDone)
+	}()
+
+	// Wait until the writer is queued
+	deadline := time.Now().Add(time.Second)
+	for {
+		lm.mu.Lock()
+		queued := len(lm.lockFor("file_0").queue)
+		lm.mu.Unlock()
+		if queued == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Writer never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// New readers keep arriving; none of them may overtake the queued writer
+	for id := int32(2); id <= 5; id++ {
+		if lm.TryAcquireShared("file_0", id) {
+			t.Errorf("Reader %d overtook the queued writer", id)
+		}
+	}
+	readersDone := make(chan struct{})
+	go func() {
+		for id := int32(2); id <= 5; id++ {
+			lm.AcquireShared("file_0", id, ctx)
+		}
+		close(readersDone)
+	}()
+
+	// Once the original reader leaves, the writer goes first
+	lm.ReleaseShared("file_0", 1)
+	select {
+	case <-writerDone:
+	case <-time.After(time.Second):
+		t.Fatal("Writer was starved by the stream of readers")
+	}
+	select {
+	case <-readersDone:
+		t.Fatal("Readers acquired the lock while the writer held it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The queued readers are admitted once the writer releases
+	lm.ReleaseResource("file_0", 100)
+	select {
+	case <-readersDone:
+	case <-time.After(time.Second):
+		t.Fatal("Readers didn't acquire the lock after the writer released")
+	}
+}
+
+func TestReacquireByHolderIsRejected(t *testing.T) {
+	lm := NewLockManager(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := lm.AcquireResource("a", 1, ctx); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	// Waiting would mean waiting on itself forever, so it fails straight away
+	if err := lm.AcquireResource("a", 1, ctx); !errors.Is(err, ErrAlreadyHeld) {
+		t.Errorf("Expected ErrAlreadyHeld re-acquiring an exclusive lock, got %v", err)
+	}
+	if err := lm.AcquireShared("a", 1, ctx); !errors.Is(err, ErrAlreadyHeld) {
+		t.Errorf("Expected ErrAlreadyHeld taking a shared hold on a lock held exclusively, got %v", err)
+	}
+	if lm.TryAcquireResource("a", 1) {
+		t.Error("Try-acquire of a lock already held should fail")
+	}
+
+	// Shared holders can't stack holds or upgrade either
+	if err := lm.AcquireShared("b", 1, ctx); err != nil {
+		t.Fatalf("Shared acquire failed: %v", err)
+	}
+	if err := lm.AcquireResource("b", 1, ctx); !errors.Is(err, ErrAlreadyHeld) {
+		t.Errorf("Expected ErrAlreadyHeld upgrading a shared hold, got %v", err)
+	}
+	if err := lm.AcquireShared("b", 1, ctx); !errors.Is(err, ErrAlreadyHeld) {
+		t.Errorf("Expected ErrAlreadyHeld for a second shared hold, got %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("Re-acquires should fail fast, not wait")
+	}
+
+	// Nothing was queued, so other clients are unaffected
+	if waiters := lm.Status("a").Waiters; waiters != 0 {
+		t.Errorf("Expected no waiters, got %d", waiters)
+	}
+}
+
+func TestEvict(t *testing.T) {
+	lm := NewLockManager(nil)
+	ctx := context.Background()
+
+	// Client 1 holds a shared lock and is queued for the global lock, ahead of client 3
+	lm.AcquireShared("a", 1, ctx)
+	lm.Acquire(2)
+	evicted := make(chan error, 1)
+	go func() { evicted <- lm.AcquireResource(GlobalResource, 1, ctx) }()
+	waitForQueueLen(t, lm, 1)
+	granted := make(chan error, 1)
+	go func() { granted <- lm.AcquireResource(GlobalResource, 3, ctx) }()
+	waitForQueueLen(t, lm, 2)
+
+	lm.Evict(1)
+	if err := <-evicted; !errors.Is(err, ErrEvicted) {
+		t.Errorf("Expected ErrEvicted for the waiting acquire, got %v", err)
+	}
+	if lm.HasSharedLock("a", 1) {
+		t.Error("Evicted client should have lost its shared lock")
+	}
+	waitForQueueLen(t, lm, 1)
+
+	// The client behind it is next in line
+	lm.Release(2)
+	if err := <-granted; err != nil || lm.CurrentHolder() != 3 {
+		t.Errorf("Expected client 3 to get the lock after client 2, got holder %d (%v)", lm.CurrentHolder(), err)
+	}
+}
+
+func TestForceRelease(t *testing.T) {
+	lm := NewLockManager(nil)
+	if _, ok := lm.ForceRelease(GlobalResource); ok {
+		t.Error("Force releasing a free lock should report nothing to release")
+	}
+
+	lm.Acquire(1)
+	oldToken, _ := lm.FencingToken(GlobalResource, 1)
+	done := make(chan bool)
+	go func() { done <- lm.Acquire(2) }()
+	waitForQueueLen(t, lm, 1)
+
+	// The stuck holder loses the lock and the waiter gets it under a newer token
+	if holder, ok := lm.ForceRelease(GlobalResource); !ok || holder != 1 {
+		t.Fatalf("ForceRelease = %d, %v, want 1, true", holder, ok)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Waiter didn't get the lock after it was force released")
+	}
+	if lm.HasLock(1) || !lm.HasLock(2) {
+		t.Errorf("Expected client 2 to hold the lock instead of 1, holder is %d", lm.CurrentHolder())
+	}
+	if newToken, _ := lm.FencingToken(GlobalResource, 2); newToken <= oldToken+1 {
+		t.Errorf("Expected the token to skip past %d on a forced release, got %d", oldToken+1, newToken)
+	}
+
+	// A release by the old holder no longer does anything
+	if lm.Release(1) {
+		t.Error("Old holder released a lock it lost")
+	}
+}
+
+func TestMaxWaitersCountsBlockedAcquires(t *testing.T) {
+	lm := NewLockManager(nil, WithMaxWaiters(2))
+	lm.Acquire(1)
+
+	// Two coalesced acquires by the same client fill the limit between them
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- lm.AcquireResource(GlobalResource, 2, ctx) }()
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		lm.mu.Lock()
+		waiting := lm.waiting
+		lm.mu.Unlock()
+		if waiting == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for 2 blocked acquires, have %d", waiting)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := lm.AcquireResource(GlobalResource, 3, context.Background()); !errors.Is(err, ErrTooManyWaiters) {
+		t.Errorf("Expected ErrTooManyWaiters, got %v", err)
+	}
+
+	// Timed-out acquires give their slots back
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := lm.AcquireResource(GlobalResource, 3, ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the acquire to queue and time out, got %v", err)
+	}
+}
+
+func TestAntiAffinity(t *testing.T) {
+	lm := NewLockManager(nil, WithAntiAffinity())
+	lm.Acquire(3)
+
+	// Client 1 queues ahead of client 2, then is handed the lock directly, so
+	// it still has an acquire waiting when it releases
+	granted := make(chan int32, 2)
+	go func() {
+		lm.Acquire(1)
+		granted <- 1
+	}()
+	waitForQueueLen(t, lm, 1)
+	go func() {
+		lm.Acquire(2)
+		granted <- 2
+	}()
+	waitForQueueLen(t, lm, 2)
+	if !lm.TransferResource(GlobalResource, 3, 1) {
+		t.Fatal("Transfer to client 1 failed")
+	}
+
+	// On release the lock skips the just-released holder in favor of client 2
+	lm.Release(1)
+	select {
+	case id := <-granted:
+		if id != 2 {
+			t.Fatalf("Lock went to client %d, want client 2", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("No waiter acquired the lock")
+	}
+
+	// Client 1 still gets its turn afterwards
+	lm.Release(2)
+	select {
+	case id := <-granted:
+		if id != 1 {
+			t.Fatalf("Lock went to client %d, want client 1", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Client 1 never acquired the lock")
+	}
+	lm.Release(1)
+}
+
 func TestStressTest(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping stress test in short mode")
@@ -422,3 +1197,157 @@ func BenchmarkConcurrentLockOperations(b *testing.B) {
 
 	wg.Wait()
 }
+
+// fakeClock is a Clock that only moves when advanced. Like a real system clock
+// it keeps wall time too, which can be stepped without time passing.
+type fakeClock struct {
+	mu      sync.Mutex
+	wall    time.Time
+	elapsed time.Duration
+}
+
+func (c *fakeClock) Monotonic() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.elapsed
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.wall
+}
+
+// Advance lets d pass
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wall = c.wall.Add(d)
+	c.elapsed += d
+}
+
+// StepWall moves the wall clock by d without any time passing, as an NTP correction does
+func (c *fakeClock) StepWall(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wall = c.wall.Add(d)
+}
+
+func TestLeaseExpiryIgnoresWallClockSteps(t *testing.T) {
+	clock := &fakeClock{wall: time.Unix(1000, 0)}
+	lm := NewLockManager(nil, WithLease(time.Second), WithClock(clock))
+	defer lm.Close()
+
+	lm.Acquire(1)
+
+	// Stepping the wall clock back an hour doesn't stretch the lease
+	clock.StepWall(-time.Hour)
+	clock.Advance(1500 * time.Millisecond)
+	if expired := lm.ExpireLeases(); len(expired) != 1 || expired[0] != 1 {
+		t.Fatalf("Expected client 1 to expire on schedule, got %v", expired)
+	}
+
+	// Stepping it forward an hour doesn't cut a lease short
+	lm.Acquire(2)
+	clock.StepWall(time.Hour)
+	clock.Advance(500 * time.Millisecond)
+	if expired := lm.ExpireLeases(); len(expired) != 0 {
+		t.Fatalf("Expected no expiry half way through the lease, got %v", expired)
+	}
+	if remaining := lm.Status(GlobalResource).LeaseRemaining; remaining != 500*time.Millisecond {
+		t.Errorf("Expected 500ms of lease left, got %v", remaining)
+	}
+}
+
+func TestResourceLease(t *testing.T) {
+	clock := &fakeClock{wall: time.Unix(1000, 0)}
+	lm := NewLockManager(nil, WithLease(time.Second), WithResourceLease("file_99", 3*time.Second), WithClock(clock))
+	defer lm.Close()
+
+	ctx := context.Background()
+	for _, resource := range []string{"file_1", "file_99"} {
+		if err := lm.AcquireResource(resource, 1, ctx); err != nil {
+			t.Fatalf("AcquireResource(%s) failed: %v", resource, err)
+		}
+	}
+
+	// file_1 goes on the default lease, file_99 keeps its longer one
+	clock.Advance(1500 * time.Millisecond)
+	if expired := lm.ExpireLeases(); len(expired) != 1 || expired[0] != 1 {
+		t.Fatalf("Expected client 1 to expire, got %v", expired)
+	}
+	if holder := lm.Status("file_1").Holder; holder != -1 {
+		t.Errorf("Expected file_1 to be released, held by %d", holder)
+	}
+	st := lm.Status("file_99")
+	if st.Holder != 1 || st.LeaseRemaining != 1500*time.Millisecond {
+		t.Errorf("Expected client 1 to hold file_99 with 1.5s left, got %+v", st)
+	}
+
+	// A heartbeat restarts file_99's own lease
+	lm.Heartbeat(1)
+	clock.Advance(2500 * time.Millisecond)
+	lm.ExpireLeases()
+	if holder := lm.Status("file_99").Holder; holder != 1 {
+		t.Fatalf("Expected client 1 to keep file_99 after a heartbeat, held by %d", holder)
+	}
+	clock.Advance(time.Second)
+	if expired := lm.ExpireLeases(); len(expired) != 1 || expired[0] != 1 {
+		t.Fatalf("Expected client 1 to expire from file_99, got %v", expired)
+	}
+	if holder := lm.Status("file_99").Holder; holder != -1 {
+		t.Errorf("Expected file_99 to be released, held by %d", holder)
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	clock := &fakeClock{wall: time.Unix(1000, 0)}
+	lm := NewLockManager(nil, WithLease(time.Second), WithClock(clock))
+	defer lm.Close()
+
+	lm.Acquire(1)
+	if err := lm.AcquireShared("file_1", 1, context.Background()); err != nil {
+		t.Fatalf("AcquireShared failed: %v", err)
+	}
+
+	// Heartbeats keep the lease alive past the lease period
+	clock.Advance(800 * time.Millisecond)
+	lm.Heartbeat(1)
+	clock.Advance(800 * time.Millisecond)
+	if expired := lm.ExpireLeases(); len(expired) != 0 {
+		t.Fatalf("Expected no expiry while heartbeating, got %v", expired)
+	}
+	if !lm.HasLock(1) {
+		t.Fatal("Client 1 should still hold the lock")
+	}
+
+	// Client 2 queues behind the silent holder
+	done := make(chan bool)
+	go func() { done <- lm.AcquireWithTimeout(2, context.Background()) }()
+	waitForQueueLen(t, lm, 1)
+
+	clock.Advance(1500 * time.Millisecond)
+	if expired := lm.ExpireLeases(); len(expired) != 1 || expired[0] != 1 {
+		t.Fatalf("Expected client 1 to expire, got %v", expired)
+	}
+
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("Client 2 failed to acquire")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Client 2 wasn't granted the lock after client 1 expired")
+	}
+	if lm.HasSharedLock("file_1", 1) {
+		t.Error("Client 1's shared lock should be released too")
+	}
+	if lm.CurrentHolder() != 2 {
+		t.Errorf("Expected client 2 to hold the lock, got %d", lm.CurrentHolder())
+	}
+
+	// The new holder's lease starts at its grant, not when it queued
+	if expired := lm.ExpireLeases(); len(expired) != 0 {
+		t.Errorf("Client 2 expired immediately after being granted: %v", expired)
+	}
+}
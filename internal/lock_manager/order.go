@@ -0,0 +1,66 @@
+package lock_manager
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLockOrder is returned by acquires that break the order the client declared
+// with SetLockOrder
+var ErrLockOrder = errors.New("acquisition violates declared lock order")
+
+// SetLockOrder declares the order in which clientID promises to take the named
+// locks. From then on, acquiring one of them while holding a lock that comes
+// later in the order, or the same position, fails with ErrLockOrder instead of
+// risking a deadlock. Locks not in the order are unconstrained. An empty order
+// removes the declaration.
+func (lm *LockManager) SetLockOrder(clientID int32, order []string) error {
+	ranks := make(map[string]int, len(order))
+	for i, resource := range order {
+		if _, dup := ranks[resource]; dup {
+			return fmt.Errorf("lock %q appears twice in lock order", resource)
+		}
+		ranks[resource] = i
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	if len(ranks) == 0 {
+		delete(lm.lockOrders, clientID)
+		return nil
+	}
+	lm.lockOrders[clientID] = ranks
+	lm.logger.Printf("Client %d declared lock order %q", clientID, order)
+	return nil
+}
+
+// CheckLockOrder reports ErrLockOrder if clientID acquiring resource now would
+// break its declared lock order
+func (lm *LockManager) CheckLockOrder(resource string, clientID int32) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.checkOrder(resource, clientID)
+}
+
+// checkOrder does the work of CheckLockOrder. Must be called with lm.mu held.
+func (lm *LockManager) checkOrder(resource string, clientID int32) error {
+	ranks, ok := lm.lockOrders[clientID]
+	if !ok {
+		return nil
+	}
+	rank, ok := ranks[resource]
+	if !ok {
+		return nil
+	}
+	for held, rl := range lm.locks {
+		heldRank, ranked := ranks[held]
+		if !ranked || heldRank < rank {
+			continue
+		}
+		if _, reading := rl.readers[clientID]; rl.holder == clientID || reading {
+			lm.logger.Printf("Client %d can't take lock %q while holding %q: violates its lock order", clientID, resource, held)
+			return fmt.Errorf("%w: %q must be taken before %q", ErrLockOrder, resource, held)
+		}
+	}
+	return nil
+}
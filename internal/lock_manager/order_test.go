@@ -0,0 +1,71 @@
+package lock_manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLockOrderRejectsOutOfOrderAcquire(t *testing.T) {
+	lm := NewLockManager(nil)
+	ctx := context.Background()
+	if err := lm.SetLockOrder(1, []string{"a", "b", "c"}); err != nil {
+		t.Fatalf("SetLockOrder failed: %v", err)
+	}
+
+	// Holding b, going back for a is refused straight away
+	if err := lm.AcquireResource("b", 1, ctx); err != nil {
+		t.Fatalf("Acquire b failed: %v", err)
+	}
+	if err := lm.AcquireResource("a", 1, ctx); !errors.Is(err, ErrLockOrder) {
+		t.Errorf("Expected ErrLockOrder acquiring a while holding b, got %v", err)
+	}
+	if lm.TryAcquireResource("a", 1) {
+		t.Error("Try-acquire of a while holding b should be refused")
+	}
+	if lm.CompareAndAcquireResource("a", -1, 1) {
+		t.Error("Compare-and-acquire of a while holding b should be refused")
+	}
+	if lm.ResourceHolder("a") != -1 {
+		t.Errorf("Refused acquires should leave a free, held by %d", lm.ResourceHolder("a"))
+	}
+
+	// Shared holds count too
+	lm.ReleaseResource("b", 1)
+	if err := lm.AcquireShared("c", 1, ctx); err != nil {
+		t.Fatalf("Shared acquire of c failed: %v", err)
+	}
+	if err := lm.AcquireResource("b", 1, ctx); !errors.Is(err, ErrLockOrder) {
+		t.Errorf("Expected ErrLockOrder acquiring b while reading c, got %v", err)
+	}
+}
+
+func TestLockOrderAllowsInOrderAcquires(t *testing.T) {
+	lm := NewLockManager(nil)
+	ctx := context.Background()
+	lm.SetLockOrder(1, []string{"a", "b", "c"})
+
+	for _, resource := range []string{"a", "c", "unordered"} {
+		if err := lm.AcquireResource(resource, 1, ctx); err != nil {
+			t.Fatalf("In-order acquire of %s failed: %v", resource, err)
+		}
+	}
+	// Locks outside the order are unconstrained, and other clients aren't affected
+	if !lm.TryAcquireResource("b", 2) {
+		t.Error("Client 2 declared no order and should get b")
+	}
+
+	// Clearing the order lifts the check
+	lm.SetLockOrder(1, nil)
+	lm.ReleaseResource("b", 2)
+	if !lm.TryAcquireResource("b", 1) {
+		t.Error("Acquire should be allowed once the order is cleared")
+	}
+}
+
+func TestLockOrderRejectsDuplicates(t *testing.T) {
+	lm := NewLockManager(nil)
+	if err := lm.SetLockOrder(1, []string{"a", "b", "a"}); err == nil {
+		t.Error("Expected an error for a lock listed twice")
+	}
+}
@@ -0,0 +1,362 @@
+package lock_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultPersistThreshold is how long an acquire waits for its grant to be persisted
+// before the slow-persist policy kicks in
+const DefaultPersistThreshold = 100 * time.Millisecond
+
+// DefaultRestoreLease is how long a holder loaded from saved state keeps its
+// locks without checking in, so a holder that died with the old server can't block forever
+const DefaultRestoreLease = 5 * time.Second
+
+// ErrServerBusy is returned by acquires rejected because persistence is too slow
+var ErrServerBusy = errors.New("lock state persistence is too slow")
+
+// Snapshot is the lock ownership state handed to a Persister
+type Snapshot struct {
+	Version   uint64             `json:"version"`    // Increases with every ownership change
+	Holders   map[string]int32   `json:"holders"`    // Exclusive holder of each held lock
+	Readers   map[string][]int32 `json:"readers"`    // Shared holders of each lock read-locked
+	Tokens    map[string]uint64  `json:"tokens"`     // Fencing token of each exclusive holder
+	LastToken uint64             `json:"last_token"` // Most recent fencing token issued
+}
+
+// Persister stores lock ownership so it can be recovered later. Save is
+// always called from a single goroutine with increasing versions.
+type Persister interface {
+	Save(snap Snapshot) error
+}
+
+// PersistPolicy decides what an acquire does when persisting its grant takes
+// longer than the threshold
+type PersistPolicy int
+
+const (
+	// PersistAsync grants the lock anyway and lets the write finish in the
+	// background: lower latency, but a crash may lose the grant
+	PersistAsync PersistPolicy = iota
+	// PersistReject gives the lock back and fails the acquire with ErrServerBusy:
+	// a granted lock is always durable, but acquires fail while the disk is slow
+	PersistReject
+)
+
+// WithPersister saves lock ownership to p after every change
+func WithPersister(p Persister) Option {
+	return func(lm *LockManager) {
+		lm.persister = p
+	}
+}
+
+// WithPersistPolicy sets how long an acquire waits for its grant to be persisted
+// and what happens when that wait runs out
+func WithPersistPolicy(threshold time.Duration, policy PersistPolicy) Option {
+	return func(lm *LockManager) {
+		lm.persistThreshold = threshold
+		lm.persistPolicy = policy
+	}
+}
+
+// WithRestoredState starts the lock manager with the ownership recorded in snap,
+// typically loaded from a Persister's storage after a restart. Restored holders
+// keep their locks only until the restore lease runs out unless they check in.
+func WithRestoredState(snap Snapshot) Option {
+	return func(lm *LockManager) {
+		lm.restored = &snap
+	}
+}
+
+// WithRestoreLease sets how long restored holders have to check in before
+// losing their locks (DefaultRestoreLease by default)
+func WithRestoreLease(d time.Duration) Option {
+	return func(lm *LockManager) {
+		lm.restoreLease = d
+	}
+}
+
+// restore loads snap into an empty lock manager. Called before the lock manager is shared.
+func (lm *LockManager) restore(snap Snapshot) {
+	lease := lm.load(snap)
+	lm.persisted = snap.Version
+	lm.logger.Printf("Restored %d held and %d read-locked resources (version %d), holders have %v to check in",
+		len(snap.Holders), len(snap.Readers), snap.Version, lease)
+}
+
+// Adopt takes over the ownership recorded in snap while running, as a backup
+// does when it is promoted to replace a failed primary. The lock manager must
+// not have granted any locks itself. As with WithRestoredState, adopted
+// holders keep their locks only until the restore lease runs out unless they
+// check in.
+func (lm *LockManager) Adopt(snap Snapshot) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	version, lastToken := lm.version, lm.lastToken
+	lease := lm.load(snap)
+	// Neither may go backward, or saves would be skipped and tokens reissued
+	lm.version = max(lm.version, version)
+	lm.lastToken = max(lm.lastToken, lastToken)
+	lm.changed()
+	if lm.lease == 0 && len(lm.deadlines) > 0 && !lm.sweeping {
+		lm.sweeping = true
+		go lm.sweepLeases(lm.restoreLease)
+	}
+	lm.logger.Printf("Adopted %d held and %d read-locked resources (version %d), holders have %v to check in",
+		len(snap.Holders), len(snap.Readers), snap.Version, lease)
+}
+
+// load records the ownership in snap, giving every holder the restore lease to
+// check in, and returns that lease. Must be called with lm.mu held or before
+// the lock manager is shared.
+func (lm *LockManager) load(snap Snapshot) time.Duration {
+	lease := lm.restoreLease
+	if lm.lease > 0 && lm.lease < lease {
+		lease = lm.lease
+	}
+	deadline := lm.clock.Monotonic() + lease
+
+	for resource, holder := range snap.Holders {
+		rl := lm.lockFor(resource)
+		rl.holder = holder
+		rl.token = snap.Tokens[resource]
+		rl.heldSince = lm.clock.Monotonic() // Time held before the restart isn't known
+		lm.deadlines[holder] = deadline
+	}
+	for resource, readers := range snap.Readers {
+		rl := lm.lockFor(resource)
+		for _, reader := range readers {
+			rl.readers[reader] = struct{}{}
+			lm.deadlines[reader] = deadline
+		}
+	}
+
+	lm.lastToken = snap.LastToken
+	lm.version = snap.Version
+	return lease
+}
+
+// FilePersister saves lock state as JSON in a single file, replacing it
+// atomically on every save
+type FilePersister struct {
+	path string
+}
+
+// NewFilePersister returns a persister writing to path
+func NewFilePersister(path string) *FilePersister {
+	return &FilePersister{path: path}
+}
+
+// Save writes snap to a temporary file and renames it over the state file, so
+// a crash mid-write leaves the previous state intact
+func (p *FilePersister) Save(snap Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode lock state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("create lock state file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename succeeds
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write lock state: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync lock state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close lock state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return fmt.Errorf("replace lock state file: %w", err)
+	}
+	return nil
+}
+
+// Load reads the saved state. It returns false, with no error, if nothing has been saved yet.
+func (p *FilePersister) Load() (Snapshot, bool, error) {
+	data, err := os.ReadFile(p.path)
+	if os.IsNotExist(err) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, fmt.Errorf("read lock state: %w", err)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, false, fmt.Errorf("decode lock state %s: %w", p.path, err)
+	}
+	return snap, true, nil
+}
+
+// changed notes an ownership change and wakes the persist loop. Must be called with lm.mu held.
+func (lm *LockManager) changed() {
+	lm.version++
+	if lm.persister == nil {
+		return
+	}
+	select {
+	case lm.persistKick <- struct{}{}:
+	default: // A write is already pending and will pick up this change
+	}
+}
+
+// Snapshot returns the current ownership state, as it would be handed to a Persister
+func (lm *LockManager) Snapshot() Snapshot {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.snapshotLocked()
+}
+
+// snapshotLocked copies the current ownership state. Must be called with lm.mu held.
+func (lm *LockManager) snapshotLocked() Snapshot {
+	snap := Snapshot{
+		Version:   lm.version,
+		Holders:   make(map[string]int32),
+		Readers:   make(map[string][]int32),
+		Tokens:    make(map[string]uint64),
+		LastToken: lm.lastToken,
+	}
+	for resource, rl := range lm.locks {
+		if rl.holder != -1 {
+			snap.Holders[resource] = rl.holder
+			snap.Tokens[resource] = rl.token
+		}
+		for reader := range rl.readers {
+			snap.Readers[resource] = append(snap.Readers[resource], reader)
+		}
+	}
+	return snap
+}
+
+// persistLoop writes the latest state whenever it changes, coalescing changes
+// that arrive while a write is in progress. It flushes once more on Close.
+func (lm *LockManager) persistLoop() {
+	defer close(lm.persistDone)
+	for {
+		select {
+		case <-lm.persistKick:
+			lm.save()
+		case <-lm.stop:
+			lm.save()
+			return
+		}
+	}
+}
+
+// save writes the current state if it is newer than what was last persisted
+func (lm *LockManager) save() {
+	lm.mu.Lock()
+	snap := lm.snapshotLocked()
+	lm.mu.Unlock()
+
+	lm.persistMu.Lock()
+	current := snap.Version <= lm.persisted
+	lm.persistMu.Unlock()
+	if current {
+		return
+	}
+
+	if err := lm.persister.Save(snap); err != nil {
+		lm.logger.Printf("Failed to persist lock state (version %d): %v", snap.Version, err)
+		return
+	}
+
+	lm.persistMu.Lock()
+	lm.persisted = snap.Version
+	close(lm.persistedCh)
+	lm.persistedCh = make(chan struct{})
+	lm.persistMu.Unlock()
+}
+
+// awaitPersisted waits up to the persist threshold for version to reach the
+// persister, reporting whether it did
+func (lm *LockManager) awaitPersisted(version uint64) bool {
+	timer := time.NewTimer(lm.persistThreshold)
+	defer timer.Stop()
+
+	for {
+		lm.persistMu.Lock()
+		done := lm.persisted >= version
+		next := lm.persistedCh
+		lm.persistMu.Unlock()
+		if done {
+			return true
+		}
+
+		select {
+		case <-next:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// confirmGrant applies the slow-persist policy to a lock just granted to clientID
+func (lm *LockManager) confirmGrant(resource string, clientID int32, mode Mode) error {
+	if lm.grantPersisted(resource, clientID) {
+		return nil
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.logger.Printf("Persisting grant of %q to client %d is slow, rejecting the acquire", resource, clientID)
+	if (mode == Exclusive && lm.holderOf(resource) == clientID) || (mode == Shared && lm.isReader(resource, clientID)) {
+		lm.releaseLocked(resource, clientID, mode)
+	}
+	return ErrServerBusy
+}
+
+// confirmHandOff applies the slow-persist policy to a lock just handed from
+// from to to, handing it back if the policy rejects the handoff
+func (lm *LockManager) confirmHandOff(resource string, from, to int32) error {
+	if lm.grantPersisted(resource, to) {
+		return nil
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.logger.Printf("Persisting handoff of %q to client %d is slow, giving it back to %d", resource, to, from)
+	if rl, exists := lm.locks[resource]; exists && rl.holder == to {
+		if from == -1 {
+			lm.releaseLocked(resource, to, Exclusive)
+		} else {
+			lm.swapHolder(resource, rl, to, from)
+		}
+	}
+	return ErrServerBusy
+}
+
+// grantPersisted waits up to the persist threshold for a grant to clientID to
+// be persisted, and reports whether the grant stands: it was persisted, there
+// is no persister, or the policy lets it finish in the background
+func (lm *LockManager) grantPersisted(resource string, clientID int32) bool {
+	if lm.persister == nil {
+		return true
+	}
+
+	lm.mu.Lock()
+	version := lm.version
+	lm.mu.Unlock()
+
+	if lm.awaitPersisted(version) {
+		return true
+	}
+	if lm.persistPolicy == PersistAsync {
+		lm.logger.Printf("Persisting grant of %q to client %d is slow, continuing in the background", resource, clientID)
+		return true
+	}
+	return false
+}
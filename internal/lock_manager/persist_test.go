@@ -0,0 +1,116 @@
+package lock_manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowPersister records every snapshot after sleeping for delay
+type slowPersister struct {
+	delay time.Duration
+
+	mu    sync.Mutex
+	saved []Snapshot
+}
+
+func (p *slowPersister) Save(snap Snapshot) error {
+	time.Sleep(p.delay)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.saved = append(p.saved, snap)
+	return nil
+}
+
+func (p *slowPersister) last() (Snapshot, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.saved) == 0 {
+		return Snapshot{}, false
+	}
+	return p.saved[len(p.saved)-1], true
+}
+
+func TestPersistRejectCoversEveryGrant(t *testing.T) {
+	p := &slowPersister{delay: 200 * time.Millisecond}
+	restored := Snapshot{Holders: map[string]int32{"file_1": 1}, Tokens: map[string]uint64{"file_1": 7}, LastToken: 7}
+	lm := NewLockManager(nil, WithPersister(p), WithPersistPolicy(10*time.Millisecond, PersistReject), WithRestoredState(restored))
+	defer lm.Close()
+
+	if err := lm.TryAcquireMode("file_2", 2, Exclusive); !errors.Is(err, ErrServerBusy) {
+		t.Errorf("Expected ErrServerBusy from a slow try-acquire, got %v", err)
+	}
+	if holder := lm.ResourceHolder("file_2"); holder != -1 {
+		t.Errorf("A rejected try-acquire must give the lock back, held by %d", holder)
+	}
+
+	// A rejected transfer leaves the lock with its holder, under a new token
+	if err := lm.HandOff("file_1", 1, 3); !errors.Is(err, ErrServerBusy) {
+		t.Errorf("Expected ErrServerBusy from a slow transfer, got %v", err)
+	}
+	if holder := lm.ResourceHolder("file_1"); holder != 1 {
+		t.Errorf("Expected client 1 to get file_1 back, held by %d", holder)
+	}
+	if token, _ := lm.FencingToken("file_1", 1); token <= 7 {
+		t.Errorf("Expected a new fencing token after the handback, got %d", token)
+	}
+}
+
+func TestPersistFastEnough(t *testing.T) {
+	p := &slowPersister{}
+	lm := NewLockManager(nil, WithPersister(p), WithPersistPolicy(time.Second, PersistReject))
+	defer lm.Close()
+
+	if err := lm.AcquireResource("file_1", 1, context.Background()); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// The acquire only returns once its grant is durable
+	snap, ok := p.last()
+	if !ok || snap.Holders["file_1"] != 1 {
+		t.Fatalf("Expected the grant to be persisted before acquire returned, got %+v", snap)
+	}
+}
+
+func TestPersistAsyncPolicy(t *testing.T) {
+	p := &slowPersister{delay: 200 * time.Millisecond}
+	lm := NewLockManager(nil, WithPersister(p), WithPersistPolicy(10*time.Millisecond, PersistAsync))
+
+	start := time.Now()
+	if err := lm.AcquireResource("file_1", 1, context.Background()); err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= p.delay {
+		t.Errorf("Async policy waited %v for the slow write", elapsed)
+	}
+	if !lm.HasResourceLock("file_1", 1) {
+		t.Fatal("Client 1 should hold the lock")
+	}
+
+	// Close flushes, so the grant reaches the persister eventually
+	lm.Close()
+	snap, ok := p.last()
+	if !ok || snap.Holders["file_1"] != 1 {
+		t.Errorf("Expected the grant to be persisted in the background, got %+v", snap)
+	}
+}
+
+func TestPersistRejectPolicy(t *testing.T) {
+	p := &slowPersister{delay: 200 * time.Millisecond}
+	lm := NewLockManager(nil, WithPersister(p), WithPersistPolicy(10*time.Millisecond, PersistReject))
+	defer lm.Close()
+
+	start := time.Now()
+	err := lm.AcquireResource("file_1", 1, context.Background())
+	if !errors.Is(err, ErrServerBusy) {
+		t.Fatalf("Expected ErrServerBusy, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= p.delay {
+		t.Errorf("Reject policy waited %v for the slow write", elapsed)
+	}
+	if lm.HasResourceLock("file_1", 1) {
+		t.Error("A rejected acquire must give the lock back")
+	}
+}
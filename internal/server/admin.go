@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// AdminTokenHeader is the gRPC metadata key admin RPCs read the shared admin token from
+const AdminTokenHeader = "x-admin-token"
+
+// WithAdminToken enables admin RPCs for callers presenting token. Without it
+// every admin RPC is refused.
+func WithAdminToken(token string) Option {
+	return func(c *config) {
+		c.adminToken = token
+	}
+}
+
+// isAdmin reports whether the caller sent the configured admin token
+func (s *LockServer) isAdmin(ctx context.Context) bool {
+	if s.adminToken == "" {
+		return false
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, token := range md.Get(AdminTokenHeader) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminForceRelease handles the admin RPC breaking a stuck lock: the holder
+// loses it however it got it, for when a client crashed holding a lock and no
+// lease is set to free it. The reason goes in the log.
+func (s *LockServer) AdminForceRelease(ctx context.Context, args *pb.AdminArgs) (*pb.Response, error) {
+	if !s.isAdmin(ctx) {
+		s.logger.Printf("Force release refused: missing or invalid admin token")
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+
+	resource := resourceName(args.Resource)
+	holder, ok := s.lockManager.ForceRelease(resource)
+	if !ok {
+		s.logger.Printf("Force release of lock %q refused: nobody holds it", resource)
+		return &pb.Response{Status: pb.Status_PRECONDITION_FAILED}, nil
+	}
+	s.logger.Printf("Warning: lock %q forcibly released from client %d by an admin: %s", resource, holder, args.Reason)
+	return &pb.Response{Status: pb.Status_SUCCESS}, nil
+}
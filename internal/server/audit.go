@@ -0,0 +1,185 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultAuditBuffer is how many audit events may wait for a slow sink before
+// new ones are dropped
+const DefaultAuditBuffer = 1024
+
+// auditedRPCs are the RPCs that change lock or file state and so get an audit event
+var auditedRPCs = map[string]bool{
+	"lock_acquire":             true,
+	"lock_release":             true,
+	"lock_release_all":         true,
+	"lock_try_acquire":         true,
+	"lock_compare_and_acquire": true,
+	"lock_transfer":            true,
+	"file_append":              true,
+	"file_write":               true,
+	"file_append_batch":        true,
+	"file_truncate":            true,
+	"client_close":             true,
+}
+
+// AuditEvent records one state-changing RPC
+type AuditEvent struct {
+	Time     time.Time `json:"time"`
+	RPC      string    `json:"rpc"`
+	ClientID int32     `json:"client_id"`
+	Resource string    `json:"resource,omitempty"`
+	Status   string    `json:"status"`
+}
+
+// AuditSink stores audit events. Record is called from a single goroutine, never
+// from an RPC handler, so a slow or failing sink can't hold up lock operations.
+type AuditSink interface {
+	Record(e AuditEvent) error
+}
+
+// WithAuditSink sends an AuditEvent for every state-changing RPC to sink.
+// Events are queued, up to buffer of them, and delivered in the background;
+// when the queue is full new events are dropped rather than delaying the RPC.
+// Like the per-RPC log records, events come from UnaryInterceptor.
+func WithAuditSink(sink AuditSink, buffer int) Option {
+	return func(c *config) {
+		c.auditSink = sink
+		c.auditBuffer = buffer
+	}
+}
+
+// auditor queues audit events for a sink. A nil *auditor records nothing.
+type auditor struct {
+	sink    AuditSink
+	events  chan AuditEvent
+	stop    chan struct{}
+	logger  *log.Logger
+	dropped atomic.Uint64 // Events discarded because the queue was full
+	failed  atomic.Uint64 // Events the sink returned an error for
+}
+
+// newAuditor starts delivering events to sink, or returns nil if there is none
+func newAuditor(sink AuditSink, buffer int, logger *log.Logger) *auditor {
+	if sink == nil {
+		return nil
+	}
+	if buffer < 1 {
+		buffer = 1
+	}
+	a := &auditor{
+		sink:   sink,
+		events: make(chan AuditEvent, buffer),
+		stop:   make(chan struct{}),
+		logger: logger,
+	}
+	go a.run()
+	return a
+}
+
+// record queues e without blocking, dropping it if the sink is behind
+func (a *auditor) record(e AuditEvent) {
+	if a == nil {
+		return
+	}
+	select {
+	case a.events <- e:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// run delivers queued events until close. It logs when the sink starts and
+// stops failing rather than on every event, so a full disk can't flood the log.
+func (a *auditor) run() {
+	failing := false
+	for {
+		select {
+		case e := <-a.events:
+			err := a.deliver(e)
+			if err != nil {
+				a.failed.Add(1)
+				if !failing {
+					a.logger.Printf("Audit sink failing, events are being lost: %v", err)
+				}
+			} else if failing {
+				a.logger.Printf("Audit sink recovered after %d failed events", a.failed.Load())
+			}
+			failing = err != nil
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// deliver passes e to the sink, turning a panic into an error
+func (a *auditor) deliver(e AuditEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("audit sink panicked: %v", r)
+		}
+	}()
+	return a.sink.Record(e)
+}
+
+// close stops delivery. It doesn't wait for a sink stuck in Record.
+func (a *auditor) close() {
+	if a == nil {
+		return
+	}
+	close(a.stop)
+}
+
+// AuditDropped returns how many audit events were discarded because the sink fell behind
+func (s *LockServer) AuditDropped() uint64 {
+	if s.audit == nil {
+		return 0
+	}
+	return s.audit.dropped.Load()
+}
+
+// AuditFailed returns how many audit events the sink failed to record
+func (s *LockServer) AuditFailed() uint64 {
+	if s.audit == nil {
+		return 0
+	}
+	return s.audit.failed.Load()
+}
+
+// FileAuditSink appends audit events to a file as JSON lines
+type FileAuditSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileAuditSink opens path for appending, creating it if needed
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return &FileAuditSink{f: f}, nil
+}
+
+// Record writes e as one line of JSON
+func (s *FileAuditSink) Record(e AuditEvent) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the audit log
+func (s *FileAuditSink) Close() error {
+	return s.f.Close()
+}
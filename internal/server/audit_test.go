@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+)
+
+// blockingSink never returns from Record until released
+type blockingSink struct {
+	release chan struct{}
+}
+
+func (s *blockingSink) Record(e AuditEvent) error {
+	<-s.release
+	return nil
+}
+
+// failingSink rejects every event, like an audit log on a full disk
+type failingSink struct{}
+
+func (failingSink) Record(e AuditEvent) error {
+	return errors.New("no space left on device")
+}
+
+// callThroughInterceptor runs one acquire/append/release cycle through the server's interceptor
+func callThroughInterceptor(t *testing.T, s *LockServer, clientID int32) {
+	t.Helper()
+	ctx := context.Background()
+	intercept := s.UnaryInterceptor()
+
+	lockInfo := &grpc.UnaryServerInfo{FullMethod: "/lock_service.LockService/lock_acquire"}
+	resp, err := intercept(ctx, &pb.LockArgs{ClientId: clientID}, lockInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.LockAcquire(ctx, req.(*pb.LockArgs))
+	})
+	if err != nil || resp.(*pb.Response).Status != pb.Status_SUCCESS {
+		t.Fatalf("LockAcquire failed: %v, %v", resp, err)
+	}
+
+	appendInfo := &grpc.UnaryServerInfo{FullMethod: "/lock_service.LockService/file_append"}
+	resp, err = intercept(ctx, &pb.FileArgs{ClientId: clientID, Filename: "file_0", Content: []byte("x")}, appendInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.FileAppend(ctx, req.(*pb.FileArgs))
+	})
+	if err != nil || resp.(*pb.Response).Status != pb.Status_SUCCESS {
+		t.Fatalf("FileAppend failed: %v, %v", resp, err)
+	}
+
+	releaseInfo := &grpc.UnaryServerInfo{FullMethod: "/lock_service.LockService/lock_release"}
+	resp, err = intercept(ctx, &pb.LockArgs{ClientId: clientID}, releaseInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.LockRelease(ctx, req.(*pb.LockArgs))
+	})
+	if err != nil || resp.(*pb.Response).Status != pb.Status_SUCCESS {
+		t.Fatalf("LockRelease failed: %v, %v", resp, err)
+	}
+}
+
+func TestBlockedAuditSinkDropsEvents(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	defer close(sink.release)
+	s, _ := newTestServer(t, WithAuditSink(sink, 2))
+
+	// Each cycle produces three events; the sink takes one and the queue holds
+	// two, so the rest must be dropped without holding up any of the calls
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		callThroughInterceptor(t, s, int32(i+1))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Lock operations took %v behind a blocked audit sink", elapsed)
+	}
+
+	if s.AuditDropped() == 0 {
+		t.Errorf("Expected audit events to be dropped while the sink is blocked")
+	}
+}
+
+func TestFailingAuditSinkIsCounted(t *testing.T) {
+	s, _ := newTestServer(t, WithAuditSink(failingSink{}, DefaultAuditBuffer))
+
+	callThroughInterceptor(t, s, 1)
+
+	// Delivery is asynchronous, so wait for all three events to be attempted
+	deadline := time.Now().Add(time.Second)
+	for s.AuditFailed() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := s.AuditFailed(); got != 3 {
+		t.Errorf("Expected 3 failed audit events, got %d", got)
+	}
+	if got := s.AuditDropped(); got != 0 {
+		t.Errorf("Expected no dropped audit events, got %d", got)
+	}
+}
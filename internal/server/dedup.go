@@ -0,0 +1,112 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+// DefaultDedupCapacity is how many append request IDs the server remembers
+// when none is configured
+const DefaultDedupCapacity = 10000
+
+// WithDedupCapacity sets how many recent append request IDs the server remembers
+// to recognize retries. Older IDs are forgotten first; 0 disables deduplication.
+func WithDedupCapacity(n int) Option {
+	return func(c *config) {
+		c.dedupCapacity = n
+	}
+}
+
+// dedupKey identifies one append request. IDs are scoped to the client so one
+// client can't replay or shadow another's.
+type dedupKey struct {
+	clientID  int32
+	requestID string
+}
+
+// dedupEntry is the outcome of an append request, filled in once it completes
+type dedupEntry struct {
+	key  dedupKey
+	done chan struct{} // Closed when resp is set
+	resp *pb.Response  // nil if the request failed and may be retried
+}
+
+// dedupCache is a bounded LRU of append request IDs and their results
+type dedupCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Most recently used at the front
+	entries  map[dedupKey]*list.Element
+}
+
+// newDedupCache returns a cache remembering up to capacity requests, or nil if capacity is 0
+func newDedupCache(capacity int) *dedupCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &dedupCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[dedupKey]*list.Element),
+	}
+}
+
+// begin claims key for a new request. If the request was already seen it
+// returns the original result instead, waiting for it if it is still in
+// flight; claimed is false in that case. A nil cache claims every request.
+func (d *dedupCache) begin(ctx context.Context, key dedupKey) (entry *dedupEntry, resp *pb.Response, claimed bool, err error) {
+	if d == nil || key.requestID == "" {
+		return nil, nil, true, nil
+	}
+
+	for {
+		d.mu.Lock()
+		if el, ok := d.entries[key]; ok {
+			d.order.MoveToFront(el)
+			existing := el.Value.(*dedupEntry)
+			d.mu.Unlock()
+
+			select {
+			case <-existing.done:
+			case <-ctx.Done():
+				return nil, nil, false, ctx.Err()
+			}
+			if existing.resp != nil {
+				return nil, existing.resp, false, nil
+			}
+			continue // The original failed and was dropped, so try to claim it again
+		}
+
+		entry = &dedupEntry{key: key, done: make(chan struct{})}
+		d.entries[key] = d.order.PushFront(entry)
+		for d.order.Len() > d.capacity {
+			oldest := d.order.Back()
+			d.order.Remove(oldest)
+			delete(d.entries, oldest.Value.(*dedupEntry).key)
+		}
+		d.mu.Unlock()
+		return entry, nil, true, nil
+	}
+}
+
+// finish records the result of a claimed request. Only successes are kept, so
+// a request that failed, or panicked leaving resp nil, can be retried and
+// actually performed.
+func (d *dedupCache) finish(entry *dedupEntry, resp *pb.Response) {
+	if entry == nil {
+		return
+	}
+
+	d.mu.Lock()
+	if resp != nil && resp.Status == pb.Status_SUCCESS {
+		entry.resp = resp
+	} else if el, ok := d.entries[entry.key]; ok && el.Value == entry {
+		d.order.Remove(el)
+		delete(d.entries, entry.key)
+	}
+	d.mu.Unlock()
+	close(entry.done)
+}
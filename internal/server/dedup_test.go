@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+func TestDuplicateAppendAppliedOnce(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	args := &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("once\n"), RequestId: "req-1"}
+	for i := 0; i < 2; i++ {
+		resp, err := s.FileAppend(ctx, args)
+		if err != nil || resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("Append %d failed: %v, %v", i+1, resp, err)
+		}
+		if resp.Bytes != int64(len(args.Content)) {
+			t.Errorf("Append %d reported %d bytes, want %d", i+1, resp.Bytes, len(args.Content))
+		}
+	}
+
+	// The same ID from another client is a different request
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 2})
+	other := &pb.FileArgs{ClientId: 2, Filename: "file_0", Content: []byte("twice\n"), RequestId: "req-1"}
+	if resp, _ := s.FileAppend(ctx, other); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Client 2 append failed: %v", resp.Status)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file_0: %v", err)
+	}
+	if want := "once\ntwice\n"; string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestServerGeneratedRequestID(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	// No request ID, so the server picks one and reports it
+	args := &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("once\n")}
+	resp, _ := s.FileAppend(ctx, args)
+	if resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Append failed: %v", resp.Status)
+	}
+	if resp.RequestId == "" {
+		t.Fatal("Expected a server-generated request ID")
+	}
+
+	// A retry quoting it is recognized and not written again
+	retry := &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: args.Content, RequestId: resp.RequestId}
+	again, _ := s.FileAppend(ctx, retry)
+	if again.Status != pb.Status_SUCCESS || again.RequestId != resp.RequestId {
+		t.Errorf("Retry got %v with ID %q, want SUCCESS with %q", again.Status, again.RequestId, resp.RequestId)
+	}
+
+	// Each append without an ID gets its own
+	batch, _ := s.FileAppendBatch(ctx, &pb.BatchArgs{ClientId: 1, Entries: []*pb.BatchEntry{{Filename: "file_0", Content: []byte("batch\n")}}})
+	if batch.RequestId == "" || batch.RequestId == resp.RequestId {
+		t.Errorf("Expected a fresh request ID for the batch, got %q", batch.RequestId)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file_0: %v", err)
+	}
+	if want := "once\nbatch\n"; string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestFailedAppendCanBeRetried(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+
+	// Without the lock the append is denied, and that result isn't remembered
+	args := &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("late\n"), RequestId: "req-1"}
+	if resp, _ := s.FileAppend(ctx, args); resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Fatalf("Expected PERMISSION_DENIED without the lock, got %v", resp.Status)
+	}
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	if resp, _ := s.FileAppend(ctx, args); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Retry after acquiring failed: %v", resp.Status)
+	}
+	got, _ := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if string(got) != "late\n" {
+		t.Errorf("Expected the retried append to be written, got %q", got)
+	}
+}
+
+func TestDedupCacheEvictsOldest(t *testing.T) {
+	d := newDedupCache(2)
+	ctx := context.Background()
+	ok := &pb.Response{Status: pb.Status_SUCCESS}
+
+	for _, id := range []string{"a", "b", "c"} {
+		entry, _, claimed, _ := d.begin(ctx, dedupKey{clientID: 1, requestID: id})
+		if !claimed {
+			t.Fatalf("Expected new request %q to be claimed", id)
+		}
+		d.finish(entry, ok)
+	}
+
+	// "a" was pushed out by "c", so it counts as new again; "c" is still remembered
+	if _, _, claimed, _ := d.begin(ctx, dedupKey{clientID: 1, requestID: "c"}); claimed {
+		t.Errorf("Expected recent request c to be recognized as a duplicate")
+	}
+	if _, _, claimed, _ := d.begin(ctx, dedupKey{clientID: 1, requestID: "a"}); !claimed {
+		t.Errorf("Expected evicted request a to be treated as new")
+	}
+}
+
+func TestDedupCacheDropsRequestWithoutResponse(t *testing.T) {
+	d := newDedupCache(2)
+	key := dedupKey{clientID: 1, requestID: "a"}
+
+	// A handler that panicked finishes with no response at all
+	entry, _, _, _ := d.begin(context.Background(), key)
+	d.finish(entry, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, _, claimed, err := d.begin(ctx, key); err != nil || !claimed {
+		t.Errorf("Expected the retry to be claimed as new, got claimed %v, %v", claimed, err)
+	}
+}
+
+func TestRetryDuringInFlightAppendWaitsForIt(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	args := &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("once\n"), RequestId: "req-1"}
+
+	// Send the retry while the original is paused between its checks and its
+	// write, so the retry is certain to find it still in flight
+	retried := make(chan *pb.Response, 1)
+	var once sync.Once
+	s.yield = func(point string, clientID int32) {
+		if point != "file_append.authorized" {
+			return
+		}
+		once.Do(func() {
+			go func() {
+				resp, _ := s.FileAppend(ctx, args)
+				retried <- resp
+			}()
+			select {
+			case <-retried:
+				t.Error("Retry returned before the original append finished")
+			case <-time.After(50 * time.Millisecond):
+			}
+			// Maintenance can't start in the middle of an append either
+			if s.quiesce.TryLock() {
+				s.quiesce.Unlock()
+				t.Error("Maintenance could start while an append was in flight")
+			}
+		})
+	}
+
+	if resp, _ := s.FileAppend(ctx, args); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Original append failed: %v", resp.Status)
+	}
+	if resp := <-retried; resp.GetStatus() != pb.Status_SUCCESS {
+		t.Errorf("Retry should get the original result, got %v", resp.GetStatus())
+	}
+	if got, _ := os.ReadFile(filepath.Join(dataDir, "file_0")); string(got) != "once\n" {
+		t.Errorf("Expected the append applied once, got %q", got)
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Health service names reported through grpc_health_v1. The empty name and
+// LivenessService report whether the process is up; ReadinessService and the
+// lock service's own name report whether it can serve requests.
+const (
+	LivenessService  = "liveness"
+	ReadinessService = "readiness"
+)
+
+// DefaultHealthInterval is how often readiness is re-evaluated for the gRPC health service
+const DefaultHealthInterval = 5 * time.Second
+
+// errDraining is reported by readiness checks once graceful shutdown has started
+var errDraining = errors.New("shutting down")
+
+// ReadinessCheck reports why the server can't serve requests, or nil if it can
+type ReadinessCheck func() error
+
+// healthState holds the readiness checks and the gRPC health service fed by them
+type healthState struct {
+	mu       sync.Mutex
+	checks   []namedCheck
+	server   *health.Server // nil until RegisterHealth is called
+	draining atomic.Bool    // Set when graceful shutdown starts
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// namedCheck is a registered readiness check and the name it is reported under
+type namedCheck struct {
+	name string
+	fn   ReadinessCheck
+}
+
+// AddReadinessCheck adds a condition that must hold for the server to be ready,
+// on top of the built-in data directory check
+func (s *LockServer) AddReadinessCheck(name string, check ReadinessCheck) {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	s.health.checks = append(s.health.checks, namedCheck{name: name, fn: check})
+}
+
+// Ready reports whether the server can serve requests: it mustn't be shutting
+// down, the data directory must be writable and every registered readiness
+// check must pass
+func (s *LockServer) Ready() error {
+	if s.health.draining.Load() {
+		return errDraining
+	}
+	if err := s.checkDataDirWritable(); err != nil {
+		return err
+	}
+
+	s.health.mu.Lock()
+	checks := append([]namedCheck(nil), s.health.checks...)
+	s.health.mu.Unlock()
+	for _, c := range checks {
+		if err := c.fn(); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// Ping handles the lightweight health RPC, checking that the data directory
+// accepts writes. Unlike readiness, it keeps answering during shutdown.
+func (s *LockServer) Ping(ctx context.Context, args *pb.Empty) (*pb.Pong, error) {
+	if err := s.checkDataDirWritable(); err != nil {
+		s.logger.Printf("Ping failed: %v", err)
+		return &pb.Pong{Status: pb.Status_IO_ERROR, Reason: err.Error()}, nil
+	}
+	return &pb.Pong{Status: pb.Status_SUCCESS}, nil
+}
+
+// checkDataDirWritable proves the data directory accepts writes by creating and removing a file in it
+func (s *LockServer) checkDataDirWritable() error {
+	if err := s.fileManager.CheckWritable(); err != nil {
+		return fmt.Errorf("data directory not writable: %w", err)
+	}
+	return nil
+}
+
+// RegisterHealth registers the grpc_health_v1 service on gs and keeps its
+// readiness statuses up to date every interval until the server shuts down
+func (s *LockServer) RegisterHealth(gs *grpc.Server, interval time.Duration) {
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(gs, hs)
+
+	s.health.mu.Lock()
+	s.health.server = hs
+	s.health.stop = make(chan struct{})
+	s.health.mu.Unlock()
+
+	s.UpdateHealth()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.UpdateHealth()
+			case <-s.health.stop:
+				return
+			}
+		}
+	}()
+
+	// Stop advertising readiness as soon as shutdown starts
+	s.RegisterShutdownHook("health", func(ctx context.Context) error {
+		hs.Shutdown()
+		s.stopHealth()
+		return nil
+	})
+}
+
+// UpdateHealth re-evaluates readiness and publishes it to the gRPC health service
+func (s *LockServer) UpdateHealth() {
+	s.health.mu.Lock()
+	hs := s.health.server
+	s.health.mu.Unlock()
+	if hs == nil {
+		return
+	}
+
+	ready := healthpb.HealthCheckResponse_SERVING
+	if err := s.Ready(); err != nil {
+		s.logger.Printf("Server not ready: %v", err)
+		ready = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	// Liveness only says the process is running, so it stays SERVING
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus(LivenessService, healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus(ReadinessService, ready)
+	hs.SetServingStatus("lock_service.LockService", ready)
+}
+
+// stopHealth stops the readiness updater started by RegisterHealth, if any
+func (s *LockServer) stopHealth() {
+	s.health.mu.Lock()
+	stop := s.health.stop
+	s.health.mu.Unlock()
+	if stop != nil {
+		s.health.stopOnce.Do(func() { close(stop) })
+	}
+}
+
+// HealthHandler serves HTTP probes: /livez answers 200 while the process runs,
+// /readyz answers 200 when Ready passes and 503 with the reason otherwise
+func (s *LockServer) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.Ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
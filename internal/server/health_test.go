@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestReadinessFollowsDataDir(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	gs := grpc.NewServer()
+	s.RegisterHealth(gs, time.Hour) // Updated by hand below
+
+	probe := httptest.NewServer(s.HealthHandler())
+	defer probe.Close()
+
+	check := func(want healthpb.HealthCheckResponse_ServingStatus, wantReadyz int) {
+		t.Helper()
+		for service, expected := range map[string]healthpb.HealthCheckResponse_ServingStatus{
+			LivenessService:  healthpb.HealthCheckResponse_SERVING,
+			"":               healthpb.HealthCheckResponse_SERVING,
+			ReadinessService: want,
+		} {
+			resp, err := s.health.server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Fatalf("Health check of %q failed: %v", service, err)
+			}
+			if resp.Status != expected {
+				t.Errorf("Expected %q to be %v, got %v", service, expected, resp.Status)
+			}
+		}
+
+		for path, code := range map[string]int{"/livez": http.StatusOK, "/readyz": wantReadyz} {
+			resp, err := http.Get(probe.URL + path)
+			if err != nil {
+				t.Fatalf("GET %s failed: %v", path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != code {
+				t.Errorf("Expected %s to answer %d, got %d", path, code, resp.StatusCode)
+			}
+		}
+	}
+
+	check(healthpb.HealthCheckResponse_SERVING, http.StatusOK)
+
+	// Put a regular file where the data directory should be; even root can't write into that
+	if err := os.RemoveAll(dataDir); err != nil {
+		t.Fatalf("Failed to remove data dir: %v", err)
+	}
+	if err := os.WriteFile(dataDir, nil, 0644); err != nil {
+		t.Fatalf("Failed to block data dir: %v", err)
+	}
+	s.UpdateHealth()
+	check(healthpb.HealthCheckResponse_NOT_SERVING, http.StatusServiceUnavailable)
+
+	// Readiness comes back once the directory is usable again
+	if err := os.Remove(dataDir); err != nil {
+		t.Fatalf("Failed to unblock data dir: %v", err)
+	}
+	s.UpdateHealth()
+	check(healthpb.HealthCheckResponse_SERVING, http.StatusOK)
+}
+
+func TestHealthDuringGracefulShutdown(t *testing.T) {
+	s, _ := newTestServer(t)
+	gs := grpc.NewServer()
+	pb.RegisterLockServiceServer(gs, s)
+	s.RegisterHealth(gs, time.Hour) // Updated by hand, and by GracefulStop
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go gs.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+
+	// Before shutdown: ready, and ping passes
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: ReadinessService})
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Expected SERVING before shutdown, got %v %v", resp.GetStatus(), err)
+	}
+	lockClient := pb.NewLockServiceClient(conn)
+	if pong, err := lockClient.Ping(ctx, &pb.Empty{}); err != nil || pong.Status != pb.Status_SUCCESS {
+		t.Fatalf("Ping failed: %v %v", pong.GetStatus(), err)
+	}
+
+	// An append stuck in flight holds the drain open
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	s.yield = func(point string, clientID int32) {
+		if point == "file_append.authorized" {
+			close(entered)
+			<-unblock
+		}
+	}
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	appended := make(chan error, 1)
+	go func() {
+		_, err := lockClient.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("x\n")})
+		appended <- err
+	}()
+	<-entered
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop(gs)
+		close(stopped)
+	}()
+
+	// While draining, readiness is NOT_SERVING but ping still works
+	deadline := time.Now().Add(time.Second)
+	for s.Ready() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Readiness never dropped during shutdown")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	check, err := s.health.server.Check(ctx, &healthpb.HealthCheckRequest{Service: ReadinessService})
+	if err != nil || check.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING while draining, got %v %v", check.GetStatus(), err)
+	}
+	if pong, _ := s.Ping(ctx, &pb.Empty{}); pong.Status != pb.Status_SUCCESS {
+		t.Errorf("Ping should keep passing while draining, got %v", pong.Status)
+	}
+	select {
+	case <-stopped:
+		t.Fatal("Shutdown finished with an append still in flight")
+	default:
+	}
+
+	close(unblock)
+	if err := <-appended; err != nil {
+		t.Errorf("In-flight append failed: %v", err)
+	}
+	<-stopped
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// DefaultKeepaliveTime is how long a client connection may sit idle before the
+// server pings it to check it is still there
+const DefaultKeepaliveTime = 30 * time.Second
+
+// DefaultKeepaliveTimeout is how long the server waits for a ping to be
+// answered before it closes the connection as dead
+const DefaultKeepaliveTimeout = 10 * time.Second
+
+// keepaliveMinClientPing is the most often clients may ping the server. Clients
+// pinging more often are disconnected, so a misconfigured one can't flood it.
+const keepaliveMinClientPing = 5 * time.Second
+
+// KeepaliveOptions returns gRPC server options that ping a client connection
+// after it has been idle for pingAfter and close it if the ping isn't answered
+// within timeout, so the connections of clients that vanished without closing
+// them (a crashed host, a dropped network) are cleaned up. Clients may send
+// keepalive pings of their own, even with no RPC in flight, but no more often
+// than every 5 seconds. gRPC raises a pingAfter under one second to one second.
+func KeepaliveOptions(pingAfter, timeout time.Duration) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: pingAfter, Timeout: timeout}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             keepaliveMinClientPing,
+			PermitWithoutStream: true,
+		}),
+	}
+}
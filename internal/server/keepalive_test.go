@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// blackhole forwards one connection to target until frozen, after which
+// everything in either direction is dropped, as if the client's host had died.
+// hungUp is closed once the server closes its end.
+type blackhole struct {
+	net.Listener
+	target string
+	frozen atomic.Bool
+	hungUp chan struct{}
+}
+
+func (b *blackhole) serve(t *testing.T) {
+	client, err := b.Accept()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	srv, err := net.Dial("tcp", b.target)
+	if err != nil {
+		t.Errorf("Failed to dial server: %v", err)
+		return
+	}
+	defer srv.Close()
+	go b.pipe(srv, client)
+	b.pipe(client, srv)
+	close(b.hungUp)
+}
+
+// pipe copies from src to dst until src fails, discarding once frozen
+func (b *blackhole) pipe(dst io.Writer, src io.Reader) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if err != nil {
+			return
+		}
+		if !b.frozen.Load() {
+			dst.Write(buf[:n])
+		}
+	}
+}
+
+func TestKeepaliveClosesDeadConnection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("takes over a second: gRPC won't ping idle connections more often")
+	}
+	ls, _ := newTestServer(t)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	gs := grpc.NewServer(KeepaliveOptions(time.Second, 100*time.Millisecond)...)
+	pb.RegisterLockServiceServer(gs, ls)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer proxy.Close()
+	hole := &blackhole{Listener: proxy, target: lis.Addr().String(), hungUp: make(chan struct{})}
+	go hole.serve(t)
+
+	conn, err := grpc.Dial(proxy.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	if resp, err := pb.NewLockServiceClient(conn).Ping(context.Background(), &pb.Empty{}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Ping failed: %v, %v", resp, err)
+	}
+
+	// The client goes silent; the server's ping goes unanswered and it hangs up
+	hole.frozen.Store(true)
+	select {
+	case <-hole.hungUp:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server didn't close the dead connection")
+	}
+}
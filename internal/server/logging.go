@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+// WithLogger sends the server's logs, including those of its lock and file
+// managers, through l, and uses it for the per-RPC records written by
+// UnaryInterceptor. Pair it with a slog.JSONHandler for machine-readable logs.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
+// legacyLogger adapts l to the *log.Logger the managers log through, tagging
+// every line with the component it came from
+func legacyLogger(l *slog.Logger, component string) *log.Logger {
+	return slog.NewLogLogger(l.With("component", component).Handler(), slog.LevelInfo)
+}
+
+// UnaryInterceptor returns a gRPC interceptor writing one structured record per
+// unary RPC with its name, client, resource, status, duration and, for
+// appends, the request ID, and passing
+// state-changing RPCs to the audit sink. It also refuses RPCs from quarantined
+// clients. Install it with grpc.UnaryInterceptor when creating the gRPC server.
+func (s *LockServer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		var resp interface{}
+		err := s.checkQuarantine(ctx, req)
+		if err == nil {
+			resp, err = handler(ctx, req)
+		}
+		rpc := path.Base(info.FullMethod)
+
+		attrs := []any{
+			slog.String("rpc", rpc),
+			slog.Float64("duration_ms", float64(time.Since(start))/float64(time.Millisecond)),
+		}
+		attrs = append(attrs, requestAttrs(req)...)
+		var outcome string
+		if err != nil {
+			outcome = status.Code(err).String()
+			attrs = append(attrs, slog.String("status", outcome), slog.String("error", err.Error()))
+		} else if r, ok := resp.(interface{ GetStatus() pb.Status }); ok {
+			outcome = r.GetStatus().String()
+			attrs = append(attrs, slog.String("status", outcome))
+		}
+		if r, ok := resp.(*pb.Response); ok && r.GetRequestId() != "" {
+			attrs = append(attrs, slog.String("request_id", r.RequestId))
+		}
+		s.slog.InfoContext(ctx, "rpc", attrs...)
+
+		if auditedRPCs[rpc] {
+			clientID, resource, _ := requestFields(req)
+			s.audit.record(AuditEvent{Time: start, RPC: rpc, ClientID: clientID, Resource: resource, Status: outcome})
+		}
+		return resp, err
+	}
+}
+
+// requestAttrs extracts the client and resource a request refers to, if any
+func requestAttrs(req interface{}) []any {
+	clientID, resource, ok := requestFields(req)
+	if !ok {
+		return nil
+	}
+	attrs := []any{slog.Int("client_id", int(clientID))}
+	if resource != "" {
+		attrs = append(attrs, slog.String("resource", resource))
+	}
+	return attrs
+}
+
+// requestFields returns the client and resource (empty if none) a request
+// refers to, and false for requests that don't name a client
+func requestFields(req interface{}) (clientID int32, resource string, ok bool) {
+	switch r := req.(type) {
+	case *pb.LockArgs:
+		return r.ClientId, resourceName(r.Resource), true
+	case *pb.CasArgs:
+		return r.NewHolder, resourceName(r.Resource), true
+	case *pb.TransferArgs:
+		return r.FromClientId, resourceName(r.Resource), true
+	case *pb.TokenArgs:
+		return r.ClientId, resourceName(r.Resource), true
+	case *pb.FileArgs:
+		return r.ClientId, r.Filename, true
+	case *pb.BatchArgs:
+		return r.ClientId, "", true
+	case *pb.Int:
+		return r.Rc, "", true
+	case *pb.InitArgs:
+		return r.Rc, "", true
+	}
+	return 0, "", false
+}
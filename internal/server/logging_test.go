@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+)
+
+func TestStructuredRPCLogging(t *testing.T) {
+	var buf bytes.Buffer
+	s, _ := newTestServer(t, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/lock_service.LockService/lock_acquire"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.LockAcquire(ctx, req.(*pb.LockArgs))
+	}
+	if _, err := s.UnaryInterceptor()(context.Background(), &pb.LockArgs{ClientId: 7, Resource: "file_3"}, info, handler); err != nil {
+		t.Fatalf("LockAcquire failed: %v", err)
+	}
+
+	// Every line must be JSON; find the per-RPC record among the manager logs
+	var record map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Log line is not JSON: %q: %v", line, err)
+		}
+		if entry["msg"] == "rpc" {
+			record = entry
+		}
+	}
+	if record == nil {
+		t.Fatalf("No rpc record logged, got:\n%s", buf.String())
+	}
+
+	want := map[string]any{
+		"rpc":       "lock_acquire",
+		"client_id": float64(7),
+		"resource":  "file_3",
+		"status":    "SUCCESS",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, record[key])
+		}
+	}
+	if _, ok := record["duration_ms"].(float64); !ok {
+		t.Errorf("Expected a numeric duration_ms, got %v", record["duration_ms"])
+	}
+}
+
+func TestSlowWaitWarning(t *testing.T) {
+	var buf bytes.Buffer
+	s, _ := newTestServer(t, WithSlowWaitThreshold(20*time.Millisecond),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	ctx := context.Background()
+
+	// A quick acquire isn't slow
+	if resp, err := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("LockAcquire failed: %v, %v", resp, err)
+	}
+	if n := s.SlowWaits(); n != 0 {
+		t.Fatalf("Expected no slow waits yet, got %d", n)
+	}
+
+	// Client 2 waits for client 1 well past the threshold
+	done := make(chan *pb.Response, 1)
+	go func() {
+		resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 2})
+		done <- resp
+	}()
+	time.Sleep(60 * time.Millisecond)
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+	if resp := <-done; resp.GetStatus() != pb.Status_SUCCESS {
+		t.Fatalf("Client 2's acquire failed: %v", resp)
+	}
+	if n := s.SlowWaits(); n != 1 {
+		t.Errorf("Expected 1 slow wait, got %d", n)
+	}
+	if !strings.Contains(buf.String(), "Warning: client 2 waited") {
+		t.Errorf("Expected a slow wait warning for client 2, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMemoryCheckInterval is how often memory use is sampled when a limit is set
+const DefaultMemoryCheckInterval = time.Second
+
+// MemoryReader returns the server's current memory use in bytes
+type MemoryReader func() uint64
+
+// HeapInUse reports the bytes in in-use heap spans, from runtime.MemStats
+func HeapInUse() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+// WithMemoryLimit sheds load while memory use, as reported by read (HeapInUse
+// if nil), is at or above limit bytes: new lock acquires are refused with
+// SERVER_BUSY, and idle file handles are flushed and closed when the limit is
+// first crossed. Clients already holding locks are untouched and can keep
+// appending and releasing. Memory is sampled every DefaultMemoryCheckInterval.
+func WithMemoryLimit(limit uint64, read MemoryReader) Option {
+	return func(c *config) {
+		c.memoryLimit = limit
+		c.memoryReader = read
+	}
+}
+
+// memoryGuard samples memory use and tracks whether the server is shedding
+// load. A nil *memoryGuard never sheds.
+type memoryGuard struct {
+	limit      uint64
+	read       MemoryReader
+	onPressure func() // Called each time use crosses the limit
+	logger     *log.Logger
+	shedding   atomic.Bool
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+// newMemoryGuard starts sampling memory use every interval, or returns nil if limit is 0
+func newMemoryGuard(limit uint64, read MemoryReader, interval time.Duration, onPressure func(), logger *log.Logger) *memoryGuard {
+	if limit == 0 {
+		return nil
+	}
+	if read == nil {
+		read = HeapInUse
+	}
+	g := &memoryGuard{
+		limit:      limit,
+		read:       read,
+		onPressure: onPressure,
+		logger:     logger,
+		stop:       make(chan struct{}),
+	}
+	g.check()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				g.check()
+			case <-g.stop:
+				return
+			}
+		}
+	}()
+	return g
+}
+
+// check samples memory use and starts or stops shedding load accordingly
+func (g *memoryGuard) check() {
+	used := g.read()
+	over := used >= g.limit
+	if over == g.shedding.Swap(over) {
+		return
+	}
+	if over {
+		g.logger.Printf("Memory use %d bytes reached limit %d: refusing new acquires and flushing files", used, g.limit)
+		if g.onPressure != nil {
+			g.onPressure()
+		}
+	} else {
+		g.logger.Printf("Memory use %d bytes back under limit %d: accepting acquires again", used, g.limit)
+	}
+}
+
+// overLimit reports whether new acquires should be refused
+func (g *memoryGuard) overLimit() bool {
+	return g != nil && g.shedding.Load()
+}
+
+// close stops sampling
+func (g *memoryGuard) close() {
+	if g == nil {
+		return
+	}
+	g.stopOnce.Do(func() { close(g.stop) })
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+func TestMemoryPressureShedsNewAcquires(t *testing.T) {
+	var used atomic.Uint64
+	s, _ := newTestServer(t, WithMemoryLimit(1000, func() uint64 { return used.Load() }))
+	ctx := context.Background()
+
+	// Client 1 takes a lock and opens a file before memory runs short
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_0"})
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("before\n")}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Append failed: %v", resp.Status)
+	}
+
+	used.Store(1000)
+	s.memory.check()
+
+	if n := s.fileManager.OpenFileCount(); n != 0 {
+		t.Errorf("Expected idle files to be flushed and closed, %d still open", n)
+	}
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_1"}); resp.Status != pb.Status_SERVER_BUSY {
+		t.Errorf("Expected SERVER_BUSY for a new acquire over the limit, got %v", resp.Status)
+	}
+	if resp, _ := s.LockTryAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_1"}); resp.Status != pb.Status_SERVER_BUSY {
+		t.Errorf("Expected SERVER_BUSY for a new try-acquire over the limit, got %v", resp.Status)
+	}
+
+	// The existing holder carries on untouched
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("during\n")}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Holder's append over the limit failed: %v", resp.Status)
+	}
+	if resp, _ := s.LockRelease(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_0"}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Holder's release over the limit failed: %v", resp.Status)
+	}
+
+	// Back under the limit, acquires are accepted again
+	used.Store(999)
+	s.memory.check()
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_1"}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Expected acquire to succeed under the limit, got %v", resp.Status)
+	}
+}
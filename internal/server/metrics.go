@@ -0,0 +1,210 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"Distributed-Lock-Manager/internal/lock_manager"
+	pb "Distributed-Lock-Manager/proto"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultQueueSampleInterval is how often the waiter queue is sampled when metrics are on
+const DefaultQueueSampleInterval = 10 * time.Second
+
+// serverMetrics holds the Prometheus collectors updated by the RPC handlers.
+// A nil *serverMetrics records nothing, so handlers can call it unconditionally.
+type serverMetrics struct {
+	acquires    *prometheus.CounterVec
+	lockWait    prometheus.Histogram
+	slowWaits   prometheus.Counter
+	fileAppends *prometheus.CounterVec
+
+	// The waiter queue across all locks, sampled on a timer so contention
+	// between scrapes shows up in the histogram
+	lm           *lock_manager.LockManager
+	queued       *prometheus.GaugeVec
+	queueSamples *prometheus.HistogramVec
+	stop         chan struct{}
+	stopOnce     sync.Once
+}
+
+// WithMetrics registers the server's Prometheus metrics with reg. Serve them
+// with promhttp.HandlerFor on a separate listener.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.metrics = reg
+	}
+}
+
+// WithQueueSampleInterval sets how often the waiter queue is sampled for the
+// dlm_queued_waiters metrics; 0 stops sampling. Only used with WithMetrics.
+func WithQueueSampleInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.queueSampleInterval = d
+	}
+}
+
+// newServerMetrics creates the server's collectors, registers them with reg and
+// starts sampling the waiter queue every sampleInterval, if it isn't 0
+func newServerMetrics(reg prometheus.Registerer, lm *lock_manager.LockManager, audit *auditor, sampleInterval time.Duration) *serverMetrics {
+	m := &serverMetrics{
+		acquires: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dlm_lock_acquires_total",
+			Help: "Lock acquire requests, by outcome.",
+		}, []string{"status"}),
+		lockWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "dlm_lock_wait_seconds",
+			Help:    "Time lock_acquire spent waiting for the lock.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		}),
+		slowWaits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "dlm_slow_lock_waits_total",
+			Help: "Lock acquire requests that waited longer than the slow wait threshold.",
+		}),
+		fileAppends: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dlm_file_appends_total",
+			Help: "File append requests, by outcome.",
+		}, []string{"status"}),
+		lm: lm,
+		queued: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dlm_queued_waiters",
+			Help: "Clients queued across all locks at the last sample, by requested mode.",
+		}, []string{"mode"}),
+		queueSamples: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "dlm_queued_waiters_sampled",
+			Help:    "Distribution of queued clients across all locks over every sample, by requested mode.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		}, []string{"mode"}),
+		stop: make(chan struct{}),
+	}
+
+	// Lock state is read at scrape time so it can never drift from the lock manager
+	held := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dlm_lock_held",
+		Help: "1 if the global lock is held exclusively, 0 otherwise.",
+	}, func() float64 {
+		if lm.Status(lock_manager.GlobalResource).Holder != -1 {
+			return 1
+		}
+		return 0
+	})
+	waiters := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dlm_waiters",
+		Help: "Clients queued for the global lock.",
+	}, func() float64 {
+		return float64(lm.Status(lock_manager.GlobalResource).Waiters)
+	})
+
+	deadlocks := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "dlm_deadlocks_total",
+		Help: "Acquires that would have closed a cycle of clients waiting for each other's locks.",
+	}, func() float64 {
+		return float64(lm.Deadlocks())
+	})
+
+	// Audit sink trouble is counted here rather than surfaced to clients
+	auditDropped := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "dlm_audit_events_dropped_total",
+		Help: "Audit events discarded because the audit sink fell behind.",
+	}, func() float64 {
+		if audit == nil {
+			return 0
+		}
+		return float64(audit.dropped.Load())
+	})
+	auditFailed := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "dlm_audit_events_failed_total",
+		Help: "Audit events the audit sink failed to record.",
+	}, func() float64 {
+		if audit == nil {
+			return 0
+		}
+		return float64(audit.failed.Load())
+	})
+
+	reg.MustRegister(m.acquires, m.lockWait, m.slowWaits, m.fileAppends, held, waiters, deadlocks, auditDropped, auditFailed,
+		m.queued, m.queueSamples, holdCollector{lm})
+
+	if sampleInterval > 0 {
+		m.sampleQueue()
+		go func() {
+			ticker := time.NewTicker(sampleInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					m.sampleQueue()
+				case <-m.stop:
+					return
+				}
+			}
+		}()
+	}
+	return m
+}
+
+// sampleQueue records how many clients are waiting in each mode
+func (m *serverMetrics) sampleQueue() {
+	for mode, n := range m.lm.QueuedByMode() {
+		m.queued.WithLabelValues(mode.String()).Set(float64(n))
+		m.queueSamples.WithLabelValues(mode.String()).Observe(float64(n))
+	}
+}
+
+// close stops sampling the waiter queue
+func (m *serverMetrics) close() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// observeAcquire records the outcome of a lock_acquire and how long it waited
+func (m *serverMetrics) observeAcquire(status pb.Status, wait time.Duration) {
+	if m == nil {
+		return
+	}
+	m.acquires.WithLabelValues(status.String()).Inc()
+	m.lockWait.Observe(wait.Seconds())
+}
+
+// observeSlowWait counts a lock_acquire that waited past the slow wait threshold
+func (m *serverMetrics) observeSlowWait() {
+	if m == nil {
+		return
+	}
+	m.slowWaits.Inc()
+}
+
+// observeAppend records the outcome of a file_append
+func (m *serverMetrics) observeAppend(status pb.Status) {
+	if m == nil {
+		return
+	}
+	m.fileAppends.WithLabelValues(status.String()).Inc()
+}
+
+// holdSeconds describes the dlm_lock_hold_seconds summary
+var holdSeconds = prometheus.NewDesc("dlm_lock_hold_seconds",
+	"How long clients held locks exclusively; quantiles are over the most recent holds.", nil, nil)
+
+// holdCollector reports the lock manager's hold times at scrape time, so the
+// timing is all taken under the lock manager's mutex
+type holdCollector struct {
+	lm *lock_manager.LockManager
+}
+
+func (c holdCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- holdSeconds
+}
+
+func (c holdCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.lm.HoldStats()
+	ch <- prometheus.MustNewConstSummary(holdSeconds, stats.Count, stats.Total.Seconds(), map[float64]float64{
+		0.5:  stats.P50.Seconds(),
+		0.95: stats.P95.Seconds(),
+		0.99: stats.P99.Seconds(),
+	})
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	s, _ := newTestServer(t, WithMetrics(reg))
+	ctx := context.Background()
+
+	endpoint := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	defer endpoint.Close()
+
+	scrape := func() string {
+		t.Helper()
+		resp, err := http.Get(endpoint.URL)
+		if err != nil {
+			t.Fatalf("Scrape failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Reading scrape failed: %v", err)
+		}
+		return string(body)
+	}
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("a\n")})
+	s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_1", Content: []byte("b\n")})
+	s.FileAppend(ctx, &pb.FileArgs{ClientId: 2, Filename: "file_0", Content: []byte("c\n")})
+
+	body := scrape()
+	for _, want := range []string{
+		`dlm_lock_acquires_total{status="SUCCESS"} 1`,
+		`dlm_lock_wait_seconds_count 1`,
+		`dlm_slow_lock_waits_total 0`,
+		`dlm_deadlocks_total 0`,
+		`dlm_file_appends_total{status="SUCCESS"} 2`,
+		`dlm_file_appends_total{status="PERMISSION_DENIED"} 1`,
+		`dlm_lock_held 1`,
+		`dlm_waiters 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Scrape missing %q", want)
+		}
+	}
+
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+	body = scrape()
+	if !strings.Contains(body, "dlm_lock_held 0") {
+		t.Error("Expected dlm_lock_held to drop to 0 after release")
+	}
+	if !strings.Contains(body, "dlm_lock_hold_seconds_count 1") {
+		t.Error("Expected the release to be counted in dlm_lock_hold_seconds")
+	}
+}
+
+func TestQueueSampling(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	s, _ := newTestServer(t, WithMetrics(reg), WithQueueSampleInterval(10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	endpoint := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	defer endpoint.Close()
+
+	// Two writers and a reader queue behind client 1 on one lock, and a writer
+	// behind a reader on another
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_0"})
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 5, Resource: "file_1", Mode: pb.LockMode_SHARED})
+	for _, args := range []*pb.LockArgs{
+		{ClientId: 2, Resource: "file_0"},
+		{ClientId: 3, Resource: "file_0"},
+		{ClientId: 4, Resource: "file_0", Mode: pb.LockMode_SHARED},
+		{ClientId: 6, Resource: "file_1"},
+	} {
+		go s.LockAcquire(ctx, args)
+	}
+
+	want := []string{
+		`dlm_queued_waiters{mode="exclusive"} 3`,
+		`dlm_queued_waiters{mode="shared"} 1`,
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := http.Get(endpoint.URL)
+		if err != nil {
+			t.Fatalf("Scrape failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		missing := ""
+		for _, w := range want {
+			if !strings.Contains(string(body), w) {
+				missing = w
+				break
+			}
+		}
+		if missing == "" {
+			// Every sample feeds the histogram, including those from before the queue filled
+			if !strings.Contains(string(body), `dlm_queued_waiters_sampled_count{mode="exclusive"}`) {
+				t.Error("Scrape missing the sampled waiter histogram")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Scrape never showed %q:\n%s", missing, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
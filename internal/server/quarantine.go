@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// quarantine is the set of clients an admin has cut off
+type quarantine struct {
+	mu      sync.RWMutex
+	clients map[int32]struct{}
+}
+
+// add quarantines clientID, reporting false if it already was
+func (q *quarantine) add(clientID int32) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.clients[clientID]; ok {
+		return false
+	}
+	if q.clients == nil {
+		q.clients = make(map[int32]struct{})
+	}
+	q.clients[clientID] = struct{}{}
+	return true
+}
+
+// remove lifts the quarantine on clientID, reporting false if there was none
+func (q *quarantine) remove(clientID int32) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.clients[clientID]; !ok {
+		return false
+	}
+	delete(q.clients, clientID)
+	return true
+}
+
+// has reports whether clientID is quarantined
+func (q *quarantine) has(clientID int32) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	_, ok := q.clients[clientID]
+	return ok
+}
+
+// checkQuarantine refuses a request naming a quarantined client with
+// PermissionDenied. Admin callers are let through so they can act on its behalf.
+func (s *LockServer) checkQuarantine(ctx context.Context, req interface{}) error {
+	clientID, _, ok := requestFields(req)
+	if !ok || !s.quarantined.has(clientID) || s.isAdmin(ctx) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "client %d is quarantined", clientID)
+}
+
+// AdminQuarantineClient handles the admin RPC cutting a client off: every RPC
+// naming it is refused with PermissionDenied until it is unquarantined, the
+// locks it holds are released and its waiting acquires are turned away
+func (s *LockServer) AdminQuarantineClient(ctx context.Context, args *pb.Int) (*pb.Response, error) {
+	if !s.isAdmin(ctx) {
+		s.logger.Printf("Quarantine refused: missing or invalid admin token")
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+
+	clientID := args.Rc
+	if s.quarantined.add(clientID) {
+		s.logger.Printf("Client %d quarantined", clientID)
+	}
+	// Evict even if already quarantined, in case an acquire slipped in as it started
+	s.lockManager.Evict(clientID)
+	return &pb.Response{Status: pb.Status_SUCCESS}, nil
+}
+
+// AdminUnquarantineClient handles the admin RPC restoring a quarantined client's access
+func (s *LockServer) AdminUnquarantineClient(ctx context.Context, args *pb.Int) (*pb.Response, error) {
+	if !s.isAdmin(ctx) {
+		s.logger.Printf("Unquarantine refused: missing or invalid admin token")
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+
+	if s.quarantined.remove(args.Rc) {
+		s.logger.Printf("Client %d let out of quarantine", args.Rc)
+	}
+	return &pb.Response{Status: pb.Status_SUCCESS}, nil
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestQuarantineClient(t *testing.T) {
+	const token = "incident-secret"
+	s, _ := newTestServer(t, WithAdminToken(token))
+	ctx := context.Background()
+	adminCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(AdminTokenHeader, token))
+
+	// Route calls through the interceptor, as a real gRPC server would
+	intercept := s.UnaryInterceptor()
+	acquire := func(ctx context.Context, args *pb.LockArgs) (*pb.Response, error) {
+		info := &grpc.UnaryServerInfo{FullMethod: "/lock_service.LockService/lock_acquire"}
+		resp, err := intercept(ctx, args, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return s.LockAcquire(ctx, req.(*pb.LockArgs))
+		})
+		r, _ := resp.(*pb.Response)
+		return r, err
+	}
+	appendAs := func(clientID int32) error {
+		info := &grpc.UnaryServerInfo{FullMethod: "/lock_service.LockService/file_append"}
+		_, err := intercept(ctx, &pb.FileArgs{ClientId: clientID, Filename: "file_0", Content: []byte("x")}, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.FileAppend(ctx, req.(*pb.FileArgs))
+			})
+		return err
+	}
+	initAs := func(clientID int32, order ...string) error {
+		info := &grpc.UnaryServerInfo{FullMethod: "/lock_service.LockService/client_init"}
+		_, err := intercept(ctx, &pb.InitArgs{Rc: clientID, LockOrder: order}, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.ClientInit(ctx, req.(*pb.InitArgs))
+			})
+		return err
+	}
+
+	// Client 1 holds the global lock and waits for file_1, which client 2 holds
+	if resp, err := acquire(ctx, &pb.LockArgs{ClientId: 1}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Acquire failed: %v %v", resp, err)
+	}
+	acquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_1"})
+	waitErr := make(chan error, 1)
+	go func() {
+		_, err := acquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"})
+		waitErr <- err
+	}()
+	deadline := time.Now().Add(time.Second)
+	for s.lockManager.Status("file_1").Waiters == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for client 1 to queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Quarantine is admin only
+	if resp, _ := s.AdminQuarantineClient(ctx, &pb.Int{Rc: 1}); resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Expected PERMISSION_DENIED without the admin token, got %v", resp.Status)
+	}
+	if resp, _ := s.AdminQuarantineClient(adminCtx, &pb.Int{Rc: 1}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Quarantine failed: %v", resp.Status)
+	}
+
+	// Its lock is freed and its waiting acquire turned away
+	if s.lockManager.IsLocked() {
+		t.Errorf("Quarantined client's lock should be released, held by %d", s.lockManager.CurrentHolder())
+	}
+	select {
+	case err := <-waitErr:
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Expected the waiting acquire to fail with PermissionDenied, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiting acquire wasn't turned away")
+	}
+	if s.lockManager.Status("file_1").Waiters != 0 {
+		t.Error("Quarantined client should have left the queue")
+	}
+
+	// Everything it sends is refused, while other clients carry on
+	if _, err := acquire(ctx, &pb.LockArgs{ClientId: 1}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a quarantined acquire, got %v", err)
+	}
+	if err := appendAs(1); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a quarantined append, got %v", err)
+	}
+	if err := initAs(1, "file_1", "file_2"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a quarantined client_init, got %v", err)
+	}
+	if resp, err := acquire(ctx, &pb.LockArgs{ClientId: 3}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Other clients should be unaffected, got %v %v", resp, err)
+	}
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 3})
+
+	// Lifting the quarantine restores access
+	if resp, _ := s.AdminUnquarantineClient(adminCtx, &pb.Int{Rc: 1}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Unquarantine failed: %v", resp.Status)
+	}
+	if resp, err := acquire(ctx, &pb.LockArgs{ClientId: 1}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Acquire after unquarantine failed: %v %v", resp, err)
+	}
+	if err := appendAs(1); err != nil {
+		t.Errorf("Append after unquarantine failed: %v", err)
+	}
+	if err := initAs(1, "file_1", "file_2"); err != nil {
+		t.Errorf("client_init after unquarantine failed: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRateLimitIdle is how long a client's append budget is kept after its
+// last append; a client returning after that starts again with a full burst
+const DefaultRateLimitIdle = time.Minute
+
+// WithRateLimit limits each client to rate appends per second, with bursts of
+// up to burst, answering RATE_LIMITED beyond that. A batch counts as one append.
+// The limit applies whether or not the client holds a lock; rate 0 disables it.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *config) {
+		c.appendRate = rate
+		c.appendBurst = burst
+	}
+}
+
+// bucket is one client's token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time // When tokens was last topped up
+}
+
+// rateLimiter keeps a token bucket per client. A nil *rateLimiter allows everything.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64 // Tokens added per second
+	burst     float64 // Most tokens a bucket holds
+	idle      time.Duration
+	buckets   map[int32]*bucket
+	lastPrune time.Time
+	now       func() time.Time // Replaced by tests
+}
+
+// newRateLimiter returns a limiter allowing rate appends per second per client
+// in bursts of up to burst, or nil if rate is 0
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		idle:    DefaultRateLimitIdle,
+		buckets: make(map[int32]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token from clientID's bucket, reporting false if it is empty
+func (r *rateLimiter) allow(clientID int32) bool {
+	if r == nil {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	r.prune(now)
+
+	b, ok := r.buckets[clientID]
+	if !ok {
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[clientID] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(r.burst, b.tokens+elapsed*r.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune forgets the buckets of clients idle for longer than r.idle, at most
+// once per idle period. Must be called with r.mu held.
+func (r *rateLimiter) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < r.idle {
+		return
+	}
+	r.lastPrune = now
+	for clientID, b := range r.buckets {
+		if now.Sub(b.last) >= r.idle {
+			delete(r.buckets, clientID)
+		}
+	}
+}
+
+// clients returns how many clients the limiter is tracking
+func (r *rateLimiter) clients() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.buckets)
+}
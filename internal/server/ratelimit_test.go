@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+func TestAppendRateLimit(t *testing.T) {
+	s, _ := newTestServer(t, WithRateLimit(2, 3))
+	now := time.Now()
+	s.limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_1"})
+
+	appendAs := func(clientID int32, filename string) pb.Status {
+		resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: clientID, Filename: filename, Content: []byte("x")})
+		return resp.Status
+	}
+
+	// The burst goes through, then the holder is throttled like anyone else
+	for i := 0; i < 3; i++ {
+		if status := appendAs(1, "file_0"); status != pb.Status_SUCCESS {
+			t.Fatalf("Append %d within the burst failed: %v", i, status)
+		}
+	}
+	if status := appendAs(1, "file_0"); status != pb.Status_RATE_LIMITED {
+		t.Errorf("Expected RATE_LIMITED past the burst, got %v", status)
+	}
+	resp, _ := s.FileAppendBatch(ctx, &pb.BatchArgs{ClientId: 1, Entries: []*pb.BatchEntry{{Filename: "file_0", Content: []byte("x")}}})
+	if resp.Status != pb.Status_RATE_LIMITED {
+		t.Errorf("Expected RATE_LIMITED for a batch past the burst, got %v", resp.Status)
+	}
+
+	// Other clients have their own budget
+	if status := appendAs(2, "file_1"); status != pb.Status_SUCCESS {
+		t.Errorf("Another client's append should be unaffected, got %v", status)
+	}
+
+	// Tokens come back at the configured rate
+	now = now.Add(500 * time.Millisecond)
+	if status := appendAs(1, "file_0"); status != pb.Status_SUCCESS {
+		t.Errorf("Expected one append after half a second, got %v", status)
+	}
+	if status := appendAs(1, "file_0"); status != pb.Status_RATE_LIMITED {
+		t.Errorf("Expected RATE_LIMITED once the refill is spent, got %v", status)
+	}
+
+	// Idle clients are forgotten
+	now = now.Add(DefaultRateLimitIdle)
+	if status := appendAs(2, "file_1"); status != pb.Status_SUCCESS {
+		t.Errorf("Append after a long pause failed: %v", status)
+	}
+	if n := s.limiter.clients(); n != 1 {
+		t.Errorf("Expected only the active client to be tracked, got %d", n)
+	}
+}
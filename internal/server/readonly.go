@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+// WithReadOnly starts the server read-only: locks can be taken and released and
+// files read, but appends, writes and truncates are refused with READ_ONLY
+// until an admin turns it off with admin_set_read_only
+func WithReadOnly() Option {
+	return func(c *config) {
+		c.readOnly = true
+	}
+}
+
+// ReadOnly reports whether the server is refusing file changes
+func (s *LockServer) ReadOnly() bool {
+	return s.readOnly.Load()
+}
+
+// AdminSetReadOnly handles the admin RPC turning read-only mode on or off, for
+// freezing the data files during a backup without stopping the service
+func (s *LockServer) AdminSetReadOnly(ctx context.Context, args *pb.ReadOnlyArgs) (*pb.Response, error) {
+	if !s.isAdmin(ctx) {
+		s.logger.Printf("Set read-only refused: missing or invalid admin token")
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+
+	if s.readOnly.Swap(args.ReadOnly) != args.ReadOnly {
+		if args.ReadOnly {
+			s.logger.Printf("Server is now read-only: appends, writes and truncates are refused")
+		} else {
+			s.logger.Printf("Server is writable again")
+		}
+	}
+	return &pb.Response{Status: pb.Status_SUCCESS}, nil
+}
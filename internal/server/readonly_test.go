@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	const token = "maintenance-secret"
+	s, _ := newTestServer(t, WithAdminToken(token))
+	ctx := context.Background()
+	adminCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(AdminTokenHeader, token))
+
+	appendStatus := func() pb.Status {
+		resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("x\n")})
+		return resp.Status
+	}
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("LockAcquire failed: %v", resp.Status)
+	}
+	if st := appendStatus(); st != pb.Status_SUCCESS {
+		t.Fatalf("Append failed: %v", st)
+	}
+
+	// Only admins may switch modes
+	if resp, _ := s.AdminSetReadOnly(ctx, &pb.ReadOnlyArgs{ReadOnly: true}); resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Expected PERMISSION_DENIED without the admin token, got %v", resp.Status)
+	}
+	if resp, _ := s.AdminSetReadOnly(adminCtx, &pb.ReadOnlyArgs{ReadOnly: true}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("AdminSetReadOnly failed: %v", resp.Status)
+	}
+	if !s.ReadOnly() {
+		t.Error("Expected the server to report read-only")
+	}
+
+	// Every file change is refused...
+	if st := appendStatus(); st != pb.Status_READ_ONLY {
+		t.Errorf("Expected READ_ONLY from an append, got %v", st)
+	}
+	batch := &pb.BatchArgs{ClientId: 1, Entries: []*pb.BatchEntry{{Filename: "file_0", Content: []byte("y\n")}}}
+	if resp, _ := s.FileAppendBatch(ctx, batch); resp.Status != pb.Status_READ_ONLY {
+		t.Errorf("Expected READ_ONLY from a batch append, got %v", resp.Status)
+	}
+	if resp, _ := s.FileWrite(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("z\n")}); resp.Status != pb.Status_READ_ONLY {
+		t.Errorf("Expected READ_ONLY from a write, got %v", resp.Status)
+	}
+	if resp, _ := s.FileTruncate(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0"}); resp.Status != pb.Status_READ_ONLY {
+		t.Errorf("Expected READ_ONLY from a truncate, got %v", resp.Status)
+	}
+
+	// ...while reads and locks carry on
+	if resp, _ := s.FileRead(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0"}); resp.Status != pb.Status_SUCCESS || string(resp.Content) != "x\n" {
+		t.Errorf("Expected the read to return the content from before, got %v %q", resp.Status, resp.Content)
+	}
+	if resp, _ := s.LockRelease(ctx, &pb.LockArgs{ClientId: 1}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("LockRelease failed: %v", resp.Status)
+	}
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("LockAcquire failed: %v", resp.Status)
+	}
+
+	// Turning it off lets changes through again
+	if resp, _ := s.AdminSetReadOnly(adminCtx, &pb.ReadOnlyArgs{ReadOnly: false}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("AdminSetReadOnly failed: %v", resp.Status)
+	}
+	if st := appendStatus(); st != pb.Status_SUCCESS {
+		t.Errorf("Expected appends to work again, got %v", st)
+	}
+}
+
+func TestStartReadOnly(t *testing.T) {
+	s, _ := newTestServer(t, WithReadOnly())
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("x\n")}); resp.Status != pb.Status_READ_ONLY {
+		t.Errorf("Expected READ_ONLY from a server started read-only, got %v", resp.Status)
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"context"
+	"path"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryInterceptor returns a gRPC interceptor that turns a panic in a unary
+// handler into a codes.Internal error for that one call, logging the stack,
+// instead of letting it take the whole server down. Chain it after
+// UnaryInterceptor so the failed call is still logged and audited.
+func (s *LockServer) RecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Printf("Panic in %s: %v\n%s", path.Base(info.FullMethod), r, debug.Stack())
+				resp, err = nil, status.Errorf(codes.Internal, "internal error in %s", path.Base(info.FullMethod))
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Distributed-Lock-Manager/internal/file_manager"
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// panicFS is the real filesystem, except that opening the named file panics
+type panicFS struct {
+	file_manager.OSFS
+	name string
+}
+
+func (p panicFS) OpenFile(name string, flag int, perm os.FileMode) (file_manager.File, error) {
+	if filepath.Base(name) == p.name {
+		panic("injected panic opening " + name)
+	}
+	return p.OSFS.OpenFile(name, flag, perm)
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	s, dataDir := newTestServer(t, WithFS(panicFS{name: "file_1"}))
+	ctx := context.Background()
+
+	// Chained as in cmd/server, recovery innermost
+	chained := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return s.UnaryInterceptor()(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return s.RecoveryInterceptor()(ctx, req, info, handler)
+		})
+	}
+	appendTo := func(filename, requestID string) (*pb.Response, error) {
+		info := &grpc.UnaryServerInfo{FullMethod: "/lock_service.LockService/file_append"}
+		resp, err := chained(ctx, &pb.FileArgs{ClientId: 1, Filename: filename, Content: []byte("x\n"), RequestId: requestID}, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return s.FileAppend(ctx, req.(*pb.FileArgs))
+			})
+		r, _ := resp.(*pb.Response)
+		return r, err
+	}
+
+	if resp, err := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("LockAcquire failed: %v, %v", resp, err)
+	}
+	if _, err := appendTo("file_1", "r1"); status.Code(err) != codes.Internal {
+		t.Fatalf("Expected a panicking handler to fail with Internal, got %v", err)
+	}
+
+	// A retry of the panicked request is run again, not left waiting on it
+	retryCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	ctx = retryCtx
+	if _, err := appendTo("file_1", "r1"); status.Code(err) != codes.Internal {
+		t.Fatalf("Expected the retried append to panic again and fail with Internal, got %v", err)
+	}
+
+	// The server carries on serving
+	if resp, err := appendTo("file_2", "r2"); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("FileAppend after the panic failed: %v, %v", resp, err)
+	}
+	content, err := os.ReadFile(filepath.Join(dataDir, "file_2"))
+	if err != nil || string(content) != "x\n" {
+		t.Errorf("Expected file_2 to hold %q, got %q (%v)", "x\n", content, err)
+	}
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"Distributed-Lock-Manager/internal/lock_manager"
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultReplicateTimeout bounds how long a primary waits for one backup to
+// confirm a lock state update
+const DefaultReplicateTimeout = 2 * time.Second
+
+// errStandby is reported by the readiness check of a backup that hasn't been promoted
+var errStandby = errors.New("standing by as a backup")
+
+// WithBackups makes the server a primary that pushes its lock state to the
+// servers at addrs after every change. No grant, whether by acquire,
+// try-acquire, compare-and-acquire or transfer, is answered until every backup
+// has confirmed it; if that takes longer than the persist threshold the grant
+// is undone with SERVER_BUSY, whatever WithPersistPolicy says, so a granted
+// lock is always on every backup. Backups must share the primary's admin token.
+func WithBackups(addrs []string, dialOpts ...grpc.DialOption) Option {
+	return func(c *config) {
+		c.backups = addrs
+		c.backupDialOpts = dialOpts
+	}
+}
+
+// WithBackupRole starts the server as a backup: it accepts lock state from a
+// primary and refuses lock requests with UNAVAILABLE, and reports itself not
+// ready, until it is promoted with the promote RPC
+func WithBackupRole() Option {
+	return func(c *config) {
+		c.backupRole = true
+	}
+}
+
+// replicator is a lock_manager.Persister sending every snapshot to the backups
+type replicator struct {
+	backups []backupConn
+	token   string
+	timeout time.Duration
+	epoch   int64 // When this primary started, so backups can tell a restart from a stale update
+	logger  *log.Logger
+}
+
+// backupConn is the connection to one backup
+type backupConn struct {
+	addr   string
+	conn   *grpc.ClientConn
+	client pb.LockServiceClient
+}
+
+// newReplicator connects to the backups at addrs, or returns nil if there are none.
+// Connections are made lazily, so a backup that is down doesn't stop the primary starting.
+func newReplicator(addrs []string, dialOpts []grpc.DialOption, token string, logger *log.Logger) (*replicator, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+	r := &replicator{token: token, timeout: DefaultReplicateTimeout, epoch: time.Now().UnixNano(), logger: logger}
+	for _, addr := range addrs {
+		conn, err := grpc.Dial(addr, dialOpts...)
+		if err != nil {
+			r.close()
+			return nil, fmt.Errorf("backup %s: %v", addr, err)
+		}
+		r.backups = append(r.backups, backupConn{addr: addr, conn: conn, client: pb.NewLockServiceClient(conn)})
+	}
+	return r, nil
+}
+
+// Save sends snap to every backup at once and fails unless all of them confirm it
+func (r *replicator) Save(snap lock_manager.Snapshot) error {
+	args := snapshotToProto(snap)
+	args.Epoch = r.epoch
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, AdminTokenHeader, r.token)
+
+	errs := make([]error, len(r.backups))
+	var wg sync.WaitGroup
+	for i, b := range r.backups {
+		wg.Add(1)
+		go func(i int, b backupConn) {
+			defer wg.Done()
+			resp, err := b.client.ReplicateState(ctx, args)
+			switch {
+			case err != nil:
+				errs[i] = fmt.Errorf("backup %s: %v", b.addr, err)
+			case resp.Status != pb.Status_SUCCESS:
+				errs[i] = fmt.Errorf("backup %s: %v", b.addr, resp.Status)
+			}
+		}(i, b)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// close drops the connections to the backups
+func (r *replicator) close() {
+	if r == nil {
+		return
+	}
+	for _, b := range r.backups {
+		b.conn.Close()
+	}
+}
+
+// persisters saves to each of several persisters in turn
+type persisters []lock_manager.Persister
+
+// Save hands snap to every persister, even after one fails, and reports all failures
+func (ps persisters) Save(snap lock_manager.Snapshot) error {
+	var errs []error
+	for _, p := range ps {
+		if err := p.Save(snap); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// replica is the state of a backup server. A nil *replica is a server that
+// was never a backup.
+type replica struct {
+	mu       sync.Mutex
+	promoted bool
+	epoch    int64                 // Epoch of the primary latest came from
+	latest   lock_manager.Snapshot // Most recent state received from the primary
+}
+
+// standby reports whether lock requests should be refused because the server
+// is a backup that hasn't been promoted
+func (r *replica) standby() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.promoted
+}
+
+// ReplicateState handles the admin RPC a primary uses to push its lock state.
+// Updates older than the one already held are acknowledged and ignored. A
+// primary that restarted has a newer epoch, and its state replaces whatever
+// was held whatever the version; a primary older than that is refused.
+func (s *LockServer) ReplicateState(ctx context.Context, args *pb.ReplicateArgs) (*pb.Response, error) {
+	if !s.isAdmin(ctx) {
+		s.logger.Printf("Replicated state refused: missing or invalid admin token")
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+	if s.replica == nil {
+		s.logger.Printf("Replicated state refused: not a backup")
+		return &pb.Response{Status: pb.Status_PRECONDITION_FAILED}, nil
+	}
+
+	s.replica.mu.Lock()
+	defer s.replica.mu.Unlock()
+	if s.replica.promoted {
+		s.logger.Printf("Replicated state refused: already promoted to primary")
+		return &pb.Response{Status: pb.Status_PRECONDITION_FAILED}, nil
+	}
+	switch {
+	case args.Epoch < s.replica.epoch:
+		s.logger.Printf("Replicated state refused: from a primary older than the current one")
+		return &pb.Response{Status: pb.Status_PRECONDITION_FAILED}, nil
+	case args.Epoch > s.replica.epoch:
+		if s.replica.epoch != 0 {
+			s.logger.Printf("Primary restarted: replacing lock state version %d with version %d", s.replica.latest.Version, args.Version)
+		}
+		s.replica.epoch = args.Epoch
+		s.replica.latest = snapshotFromProto(args)
+	case args.Version > s.replica.latest.Version:
+		s.replica.latest = snapshotFromProto(args)
+	}
+	return &pb.Response{Status: pb.Status_SUCCESS}, nil
+}
+
+// Promote handles the admin RPC that turns a backup into a primary: it takes
+// over the last replicated lock state and starts serving lock requests
+func (s *LockServer) Promote(ctx context.Context, args *pb.Empty) (*pb.Response, error) {
+	if !s.isAdmin(ctx) {
+		s.logger.Printf("Promotion refused: missing or invalid admin token")
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+	if s.replica == nil {
+		return &pb.Response{Status: pb.Status_PRECONDITION_FAILED}, nil
+	}
+
+	s.replica.mu.Lock()
+	defer s.replica.mu.Unlock()
+	if s.replica.promoted {
+		return &pb.Response{Status: pb.Status_SUCCESS}, nil
+	}
+	s.lockManager.Adopt(s.replica.latest)
+	s.replica.promoted = true
+	s.logger.Printf("Promoted to primary at lock state version %d", s.replica.latest.Version)
+	return &pb.Response{Status: pb.Status_SUCCESS}, nil
+}
+
+// ReplicaState returns the last lock state replicated to a backup, and false
+// if the server isn't a backup
+func (s *LockServer) ReplicaState() (lock_manager.Snapshot, bool) {
+	if s.replica == nil {
+		return lock_manager.Snapshot{}, false
+	}
+	s.replica.mu.Lock()
+	defer s.replica.mu.Unlock()
+	return s.replica.latest, true
+}
+
+// snapshotToProto converts a lock state snapshot for replicate_state
+func snapshotToProto(snap lock_manager.Snapshot) *pb.ReplicateArgs {
+	args := &pb.ReplicateArgs{
+		Version:   snap.Version,
+		Holders:   snap.Holders,
+		Readers:   make(map[string]*pb.ReaderIds, len(snap.Readers)),
+		Tokens:    snap.Tokens,
+		LastToken: snap.LastToken,
+	}
+	for resource, readers := range snap.Readers {
+		args.Readers[resource] = &pb.ReaderIds{Ids: readers}
+	}
+	return args
+}
+
+// snapshotFromProto converts replicate_state arguments back to a snapshot
+func snapshotFromProto(args *pb.ReplicateArgs) lock_manager.Snapshot {
+	snap := lock_manager.Snapshot{
+		Version:   args.Version,
+		Holders:   make(map[string]int32, len(args.Holders)),
+		Readers:   make(map[string][]int32, len(args.Readers)),
+		Tokens:    make(map[string]uint64, len(args.Tokens)),
+		LastToken: args.LastToken,
+	}
+	for resource, holder := range args.Holders {
+		snap.Holders[resource] = holder
+	}
+	for resource, readers := range args.Readers {
+		snap.Readers[resource] = readers.GetIds()
+	}
+	for resource, token := range args.Tokens {
+		snap.Tokens[resource] = token
+	}
+	return snap
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"Distributed-Lock-Manager/internal/lock_manager"
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// startBackup serves a backup server on a local port and returns it with its address
+func startBackup(t *testing.T, token string) (*LockServer, string) {
+	t.Helper()
+	backup, _ := newTestServer(t, WithAdminToken(token), WithBackupRole())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	gs := grpc.NewServer()
+	pb.RegisterLockServiceServer(gs, backup)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+	return backup, lis.Addr().String()
+}
+
+// sortedReaders returns snap with its reader lists in a fixed order, so snapshots can be compared
+func sortedReaders(snap lock_manager.Snapshot) lock_manager.Snapshot {
+	for _, readers := range snap.Readers {
+		slices.Sort(readers)
+	}
+	return snap
+}
+
+func TestBackupMirrorsPrimary(t *testing.T) {
+	const token = "replication-secret"
+	backup, addr := startBackup(t, token)
+	primary, _ := newTestServer(t, WithAdminToken(token),
+		WithBackups([]string{addr}, grpc.WithTransportCredentials(insecure.NewCredentials())))
+	ctx := context.Background()
+
+	// A mix of exclusive, shared, released and transferred locks
+	steps := []func(){
+		func() { primary.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}) },
+		func() {
+			primary.LockAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_1", Mode: pb.LockMode_SHARED})
+		},
+		func() {
+			primary.LockAcquire(ctx, &pb.LockArgs{ClientId: 3, Resource: "file_1", Mode: pb.LockMode_SHARED})
+		},
+		func() { primary.LockAcquire(ctx, &pb.LockArgs{ClientId: 4, Resource: "file_2"}) },
+		func() { primary.LockRelease(ctx, &pb.LockArgs{ClientId: 1}) },
+		func() {
+			primary.LockTransfer(ctx, &pb.TransferArgs{FromClientId: 4, ToClientId: 5, Resource: "file_2"})
+		},
+		func() { primary.LockAcquire(ctx, &pb.LockArgs{ClientId: 6, Resource: "file_3"}) },
+	}
+	for _, step := range steps {
+		step()
+	}
+
+	// Releases aren't waited for, so give the last update a moment to land
+	want := sortedReaders(primary.lockManager.Snapshot())
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := backup.ReplicaState()
+		if got.Version == want.Version {
+			if !reflect.DeepEqual(sortedReaders(got), want) {
+				t.Fatalf("Backup state differs from the primary:\n got %+v\nwant %+v", got, want)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Backup stuck at version %d, primary at %d", got.Version, want.Version)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Until promoted, the backup serves no locks and isn't ready
+	if resp, _ := backup.LockAcquire(ctx, &pb.LockArgs{ClientId: 7}); resp.Status != pb.Status_UNAVAILABLE {
+		t.Errorf("Expected UNAVAILABLE from a standby backup, got %v", resp.Status)
+	}
+	if err := backup.Ready(); err == nil {
+		t.Error("A standby backup shouldn't report ready")
+	}
+
+	// Promotion needs the admin token
+	if resp, _ := backup.Promote(ctx, &pb.Empty{}); resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Expected PERMISSION_DENIED without the admin token, got %v", resp.Status)
+	}
+	adminCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(AdminTokenHeader, token))
+	if resp, _ := backup.Promote(adminCtx, &pb.Empty{}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Promote failed: %v", resp.Status)
+	}
+
+	// The promoted backup carries on where the primary left off
+	if err := backup.Ready(); err != nil {
+		t.Errorf("Promoted backup should be ready: %v", err)
+	}
+	if holder := backup.lockManager.ResourceHolder("file_2"); holder != 5 {
+		t.Errorf("Expected client 5 to hold file_2 after failover, got %d", holder)
+	}
+	if !backup.lockManager.HasSharedLock("file_1", 2) || !backup.lockManager.HasSharedLock("file_1", 3) {
+		t.Error("Shared holders of file_1 should survive failover")
+	}
+	resp, _ := backup.LockAcquire(ctx, &pb.LockArgs{ClientId: 7})
+	if resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Acquire on the promoted backup failed: %v", resp.Status)
+	}
+	if resp.FencingToken <= want.LastToken {
+		t.Errorf("Fencing token %d reissued after failover; primary had reached %d", resp.FencingToken, want.LastToken)
+	}
+
+	// The old primary can no longer overwrite the new one's state
+	if resp, _ := backup.ReplicateState(adminCtx, snapshotToProto(want)); resp.Status != pb.Status_PRECONDITION_FAILED {
+		t.Errorf("Expected PRECONDITION_FAILED replicating to a promoted backup, got %v", resp.Status)
+	}
+}
+
+func TestUnconfirmedGrantsAreUndone(t *testing.T) {
+	// A backup that is never there
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	// Backups make grants strict even when PersistAsync is asked for
+	primary, _ := newTestServer(t, WithAdminToken("replication-secret"),
+		WithPersistPolicy(50*time.Millisecond, lock_manager.PersistAsync),
+		WithBackups([]string{addr}, grpc.WithTransportCredentials(insecure.NewCredentials())))
+	ctx := context.Background()
+
+	if resp, _ := primary.LockTryAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"}); resp.Status != pb.Status_SERVER_BUSY {
+		t.Errorf("Expected SERVER_BUSY from an unreplicated try-acquire, got %v", resp.Status)
+	}
+	if holder := primary.lockManager.ResourceHolder("file_1"); holder != -1 {
+		t.Errorf("Expected the try-acquire to be undone, held by %d", holder)
+	}
+	if resp, _ := primary.LockCompareAndAcquire(ctx, &pb.CasArgs{ExpectedHolder: -1, NewHolder: 2, Resource: "file_2"}); resp.Status != pb.Status_SERVER_BUSY {
+		t.Errorf("Expected SERVER_BUSY from an unreplicated compare-and-acquire, got %v", resp.Status)
+	}
+	if holder := primary.lockManager.ResourceHolder("file_2"); holder != -1 {
+		t.Errorf("Expected the compare-and-acquire to be undone, held by %d", holder)
+	}
+}
+
+func TestRestartedPrimaryReplacesBackupState(t *testing.T) {
+	const token = "replication-secret"
+	backup, addr := startBackup(t, token)
+	newPrimary := func() *LockServer {
+		s, _ := newTestServer(t, WithAdminToken(token),
+			WithBackups([]string{addr}, grpc.WithTransportCredentials(insecure.NewCredentials())))
+		return s
+	}
+	ctx := context.Background()
+
+	old := newPrimary()
+	for i := int32(1); i <= 5; i++ {
+		resource := fmt.Sprintf("file_%d", i)
+		if resp, _ := old.LockAcquire(ctx, &pb.LockArgs{ClientId: i, Resource: resource}); resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("Acquire of %s failed: %v", resource, resp.Status)
+		}
+	}
+
+	// A restart without a state file counts versions from the start again, below the backup's
+	restarted := newPrimary()
+	if resp, _ := restarted.LockAcquire(ctx, &pb.LockArgs{ClientId: 9, Resource: "file_9"}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Acquire on the restarted primary failed: %v", resp.Status)
+	}
+	got, _ := backup.ReplicaState()
+	if want := map[string]int32{"file_9": 9}; !reflect.DeepEqual(got.Holders, want) {
+		t.Errorf("Expected the backup to hold the restarted primary's state %v, got %v", want, got.Holders)
+	}
+
+	// The primary from before the restart can't overwrite it, so grants there fail
+	if resp, _ := old.LockAcquire(ctx, &pb.LockArgs{ClientId: 6, Resource: "file_6"}); resp.Status != pb.Status_SERVER_BUSY {
+		t.Errorf("Expected SERVER_BUSY from the old primary, got %v", resp.Status)
+	}
+	if got, _ := backup.ReplicaState(); got.Holders["file_6"] == 6 {
+		t.Error("The old primary's grant reached the backup")
+	}
+}
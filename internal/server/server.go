@@ -2,108 +2,1151 @@ package server
 
 import (
 	"context"
+	"errors"
+	"hash/crc32"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"Distributed-Lock-Manager/internal/file_manager"
 	"Distributed-Lock-Manager/internal/lock_manager"
 	pb "Distributed-Lock-Manager/proto"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // Accept gzip-compressed requests and answer in kind
+
+	"google.golang.org/grpc/status"
 )
 
+// AssignClientID is passed as the client ID to client_init to have the server
+// assign one
+const AssignClientID = -1
+
+// FirstAssignedClientID is the first ID the server assigns. Clients choosing
+// their own IDs should stay below it to be sure of never colliding with one.
+const FirstAssignedClientID = 1 << 20
+
+// backupChunkSize bounds the data carried by one BackupChunk
+const backupChunkSize = 64 * 1024
+
 // LockServer implements the LockServiceServer interface
 type LockServer struct {
 	pb.UnimplementedLockServiceServer
 	lockManager *lock_manager.LockManager
 	fileManager *file_manager.FileManager
 	logger      *log.Logger
+	slog        *slog.Logger // Structured logger for per-RPC records
+	shutdown    shutdownHooks
+	health      healthState
+	metrics     *serverMetrics // nil unless WithMetrics is set
+	transforms  []Transform    // Applied in order to appended content
+	adminToken  string
+	dedup       *dedupCache  // Recent append request IDs, nil if disabled
+	audit       *auditor     // nil unless WithAuditSink is set
+	memory      *memoryGuard // nil unless WithMemoryLimit is set
+	replicator  *replicator  // nil unless WithBackups is set
+	replica     *replica     // nil unless WithBackupRole is set
+	limiter     *rateLimiter // nil unless WithRateLimit is set
+	quarantined quarantine   // Clients cut off by an admin
+	watchers    *watchers    // watch_lock subscribers
+	maxAppend   int          // Most content one append may carry, 0 for no limit
+
+	readOnly  atomic.Bool   // Refuse appends, writes and truncates with READ_ONLY
+	slowWait  time.Duration // Acquires waiting longer than this are logged; 0 disables
+	slowWaits atomic.Uint64 // Acquires that did
+
+	idMu   sync.Mutex // Protects nextID
+	nextID int32      // Next client ID to try assigning
+
+	// quiesce pauses file operations during maintenance such as a restore:
+	// file RPCs hold it shared, maintenance holds it exclusively
+	quiesce sync.RWMutex
+
+	// yield, if set, is called at points where other RPCs could interleave,
+	// letting tests force a particular order. Always nil outside tests.
+	yield func(point string, clientID int32)
+}
+
+// config collects the settings applied by Option before the managers are built
+type config struct {
+	lockOpts      []lock_manager.Option
+	hookTimeout   time.Duration
+	stateFile     string
+	adminToken    string
+	fileCount     int
+	validator     file_manager.FilenameValidator
+	maxOpenFiles  int
+	maxFileSize   int64
+	maxAppend     int
+	fileRetries   int
+	fs            file_manager.FS
+	syncWrites    bool
+	logger        *slog.Logger
+	metrics       prometheus.Registerer
+	transforms    []Transform
+	dedupCapacity int
+	auditSink     AuditSink
+	auditBuffer   int
+	memoryLimit   uint64
+	memoryReader  MemoryReader
+	appendRate    float64
+	appendBurst   int
+	slowWait      time.Duration
+	readOnly      bool
+	stampAppends  bool
+
+	queueSampleInterval time.Duration
+	backups             []string
+	backupDialOpts      []grpc.DialOption
+	backupRole          bool
+	persistThreshold    time.Duration
+	persistPolicy       lock_manager.PersistPolicy
+}
+
+// Option configures optional LockServer settings
+type Option func(*config)
+
+// WithAntiAffinity hands a released lock to a waiter other than its last holder when possible
+func WithAntiAffinity() Option {
+	return func(c *config) {
+		c.lockOpts = append(c.lockOpts, lock_manager.WithAntiAffinity())
+	}
+}
+
+// WithDeadlockBreaking answers DEADLOCK to a lock_acquire that would complete
+// a cycle of clients waiting for each other's locks, instead of leaving them
+// all to time out. Deadlocks are logged either way.
+func WithDeadlockBreaking() Option {
+	return func(c *config) {
+		c.lockOpts = append(c.lockOpts, lock_manager.WithDeadlockBreaking())
+	}
+}
+
+// WithPriorityAging raises a waiting lock_acquire's priority by one for every d
+// it waits, so low-priority clients aren't starved; see lock_manager.DefaultPriorityAging.
+// 0 makes priorities strict.
+func WithPriorityAging(d time.Duration) Option {
+	return func(c *config) {
+		c.lockOpts = append(c.lockOpts, lock_manager.WithPriorityAging(d))
+	}
+}
+
+// WithMaxWaiters caps how many lock_acquire calls may wait at once; beyond
+// that, acquires of a held lock are answered SERVER_BUSY straight away
+func WithMaxWaiters(n int) Option {
+	return func(c *config) {
+		c.lockOpts = append(c.lockOpts, lock_manager.WithMaxWaiters(n))
+	}
+}
+
+// WithLease releases a client's locks once it has gone lease without acquiring or sending keep_alive
+func WithLease(lease time.Duration) Option {
+	return func(c *config) {
+		c.lockOpts = append(c.lockOpts, lock_manager.WithLease(lease))
+	}
+}
+
+// WithResourceLeases gives the locks in leases, keyed by resource name ("" for
+// the global lock), leases of their own in place of WithLease's, so that, say,
+// file_99 can be held longer between keep_alives than the other files
+func WithResourceLeases(leases map[string]time.Duration) Option {
+	return func(c *config) {
+		for resource, d := range leases {
+			c.lockOpts = append(c.lockOpts, lock_manager.WithResourceLease(resourceName(resource), d))
+		}
+	}
+}
+
+// WithPersister saves lock ownership to p after every change
+func WithPersister(p lock_manager.Persister) Option {
+	return func(c *config) {
+		c.lockOpts = append(c.lockOpts, lock_manager.WithPersister(p))
+	}
+}
+
+// WithPersistPolicy sets how long a grant waits to be persisted and whether it
+// then stands anyway (PersistAsync) or is undone with SERVER_BUSY (PersistReject).
+// With WithBackups the policy is always PersistReject.
+func WithPersistPolicy(threshold time.Duration, policy lock_manager.PersistPolicy) Option {
+	return func(c *config) {
+		c.persistThreshold = threshold
+		c.persistPolicy = policy
+	}
+}
+
+// WithFileCount sets how many files the server manages, file_0 to file_<n-1>
+func WithFileCount(n int) Option {
+	return func(c *config) {
+		c.fileCount = n
+	}
+}
+
+// WithFilenameValidator lets clients use the file names validate accepts in
+// place of file_0 to file_<n-1>; see file_manager.MatchFilenames. Names with
+// path separators or ".." are always refused with INVALID_FILENAME.
+func WithFilenameValidator(validate file_manager.FilenameValidator) Option {
+	return func(c *config) {
+		c.validator = validate
+	}
+}
+
+// WithMaxOpenFiles caps how many file handles the server keeps open between
+// appends; the least recently used are closed first. 0 removes the cap.
+func WithMaxOpenFiles(n int) Option {
+	return func(c *config) {
+		c.maxOpenFiles = n
+	}
+}
+
+// WithFileRetries sets how many times an append retries opening or writing its
+// file after a transient error, such as too many open files, with a short
+// backoff that doubles each time, before failing. 0 fails straight away.
+func WithFileRetries(n int) Option {
+	return func(c *config) {
+		c.fileRetries = n
+	}
 }
 
-// NewLockServer initializes a new lock server
-func NewLockServer() *LockServer {
+// WithFS keeps the data files on fsys instead of the operating system's
+// filesystem, so tests can inject filesystem failures
+func WithFS(fsys file_manager.FS) Option {
+	return func(c *config) {
+		c.fs = fsys
+	}
+}
+
+// WithMaxFileSize refuses appends and writes that would make a file larger than
+// n bytes with FILE_TOO_LARGE. 0, the default, removes the limit.
+func WithMaxFileSize(n int64) Option {
+	return func(c *config) {
+		c.maxFileSize = n
+	}
+}
+
+// DefaultMaxAppendBytes is the most content a single append may carry, well
+// under gRPC's default 4 MiB message limit
+const DefaultMaxAppendBytes = 1 << 20
+
+// DefaultMaxMsgBytes is the largest gRPC message cmd/server accepts or sends
+// unless told otherwise, raised from gRPC's own 4 MiB so that appends can be
+// made bigger with -max-append-bytes
+const DefaultMaxMsgBytes = 16 << 20
+
+// WithMaxAppendBytes refuses appends carrying more than n bytes of content with
+// APPEND_TOO_LARGE; in a batch the limit applies to each entry. 0 removes the limit.
+func WithMaxAppendBytes(n int) Option {
+	return func(c *config) {
+		c.maxAppend = n
+	}
+}
+
+// WithAppendStamps prefixes every append with the time it was written and the
+// client that wrote it, as "<time> client=<id> ". Bytes in the response still
+// counts only what the client sent; Size and Offset include the stamp.
+func WithAppendStamps() Option {
+	return func(c *config) {
+		c.stampAppends = true
+	}
+}
+
+// WithSyncWrites fsyncs every append, write and truncate before answering, so
+// acknowledged data survives a crash of the machine, not just of the server.
+// Off by default, which is much faster: a graceful stop still flushes everything.
+func WithSyncWrites() Option {
+	return func(c *config) {
+		c.syncWrites = true
+	}
+}
+
+// WithStateFile saves lock ownership and fencing tokens to path on every change
+// and reloads them from there on startup. Reloaded holders must check in (acquire
+// or keep_alive) within the restore lease or lose their locks.
+func WithStateFile(path string) Option {
+	return func(c *config) {
+		c.stateFile = path
+	}
+}
+
+// NewLockServer initializes a new lock server storing files in dataDir
+func NewLockServer(dataDir string, opts ...Option) *LockServer {
+	cfg := &config{
+		hookTimeout:   DefaultShutdownHookTimeout,
+		fileCount:     file_manager.DefaultFileCount,
+		maxOpenFiles:  file_manager.DefaultMaxOpenFiles,
+		maxAppend:     DefaultMaxAppendBytes,
+		fileRetries:   file_manager.DefaultTransientRetries,
+		dedupCapacity: DefaultDedupCapacity,
+		auditBuffer:   DefaultAuditBuffer,
+		slowWait:      DefaultSlowWaitThreshold,
+
+		queueSampleInterval: DefaultQueueSampleInterval,
+		persistThreshold:    lock_manager.DefaultPersistThreshold,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	logger := log.New(os.Stdout, "[LockServer] ", log.LstdFlags)
+	fileOpts := []file_manager.Option{
+		file_manager.WithDataDir(dataDir),
+		file_manager.WithFileCount(cfg.fileCount),
+		file_manager.WithMaxOpenFiles(cfg.maxOpenFiles),
+		file_manager.WithMaxFileSize(cfg.maxFileSize),
+		file_manager.WithTransientRetries(cfg.fileRetries, file_manager.DefaultRetryBackoff),
+		file_manager.WithFilenameValidator(cfg.validator),
+	}
+	if cfg.fs != nil {
+		fileOpts = append(fileOpts, file_manager.WithFS(cfg.fs))
+	}
+	if cfg.stampAppends {
+		fileOpts = append(fileOpts, file_manager.WithAppendStamps())
+	}
+	lockLogger := logger
+	structured := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	if cfg.logger != nil {
+		structured = cfg.logger
+		logger = legacyLogger(structured, "server")
+		lockLogger = legacyLogger(structured, "lock_manager")
+		fileOpts = append(fileOpts, file_manager.WithLogger(legacyLogger(structured, "file_manager")))
+	}
+	var persist persisters
+	if cfg.stateFile != "" {
+		p, opts := stateFileOptions(cfg.stateFile, logger)
+		persist = append(persist, p)
+		cfg.lockOpts = append(cfg.lockOpts, opts...)
+	}
+	repl, err := newReplicator(cfg.backups, cfg.backupDialOpts, cfg.adminToken, logger)
+	if err != nil {
+		logger.Printf("Warning: not replicating lock state: %v", err)
+	} else if repl != nil {
+		persist = append(persist, repl)
+		// A grant the backups didn't confirm would be lost on failover, so it
+		// is never acknowledged
+		if cfg.persistPolicy != lock_manager.PersistReject {
+			logger.Printf("Replicating to backups: grants are undone with SERVER_BUSY unless every backup confirms them within %v", cfg.persistThreshold)
+		}
+		cfg.persistPolicy = lock_manager.PersistReject
+	}
+	cfg.lockOpts = append(cfg.lockOpts, lock_manager.WithPersistPolicy(cfg.persistThreshold, cfg.persistPolicy))
+	switch len(persist) {
+	case 0:
+	case 1:
+		cfg.lockOpts = append(cfg.lockOpts, lock_manager.WithPersister(persist[0]))
+	default:
+		cfg.lockOpts = append(cfg.lockOpts, lock_manager.WithPersister(persist))
+	}
+
+	watch := &watchers{}
+	cfg.lockOpts = append(cfg.lockOpts, lock_manager.WithEventHandler(watch.publish))
+
 	s := &LockServer{
-		lockManager: lock_manager.NewLockManager(logger),
-		fileManager: file_manager.NewFileManager(false), // Disable sync for better performance
+		lockManager: lock_manager.NewLockManager(lockLogger, cfg.lockOpts...),
+		fileManager: file_manager.NewFileManager(cfg.syncWrites, fileOpts...),
 		logger:      logger,
+		slog:        structured,
+		shutdown:    shutdownHooks{timeout: cfg.hookTimeout},
+		transforms:  cfg.transforms,
+		adminToken:  cfg.adminToken,
+		dedup:       newDedupCache(cfg.dedupCapacity),
+		audit:       newAuditor(cfg.auditSink, cfg.auditBuffer, logger),
+		nextID:      FirstAssignedClientID,
+		replicator:  repl,
+		limiter:     newRateLimiter(cfg.appendRate, cfg.appendBurst),
+		watchers:    watch,
+		maxAppend:   cfg.maxAppend,
+		slowWait:    cfg.slowWait,
+	}
+	s.readOnly.Store(cfg.readOnly)
+	if cfg.backupRole {
+		s.replica = &replica{}
+		s.AddReadinessCheck("replica", func() error {
+			if s.replica.standby() {
+				return errStandby
+			}
+			return nil
+		})
 	}
+	if cfg.metrics != nil {
+		s.metrics = newServerMetrics(cfg.metrics, s.lockManager, s.audit, cfg.queueSampleInterval)
+	}
+	s.memory = newMemoryGuard(cfg.memoryLimit, cfg.memoryReader, DefaultMemoryCheckInterval, s.fileManager.Flush, logger)
 	return s
 }
 
+// stateFileOptions returns a persister saving to path, and the options to
+// reload any lock state already saved there
+func stateFileOptions(path string, logger *log.Logger) (lock_manager.Persister, []lock_manager.Option) {
+	p := lock_manager.NewFilePersister(path)
+	var opts []lock_manager.Option
+
+	snap, ok, err := p.Load()
+	switch {
+	case err != nil:
+		logger.Printf("Warning: ignoring unreadable lock state, starting with no locks held: %v", err)
+	case ok:
+		logger.Printf("Reloading lock state from %s", path)
+		opts = append(opts, lock_manager.WithRestoredState(snap))
+	}
+	return p, opts
+}
+
+// grantResponse builds the reply to a successful acquire, attaching the fencing
+// token for exclusive holds
+func (s *LockServer) grantResponse(resource string, clientID int32) *pb.Response {
+	resp := &pb.Response{Status: pb.Status_SUCCESS}
+	if token, ok := s.lockManager.FencingToken(resource, clientID); ok {
+		resp.FencingToken = token
+	}
+	return resp
+}
+
 // ClientInit handles the client initialization RPC
-func (s *LockServer) ClientInit(ctx context.Context, args *pb.Int) (*pb.Int, error) {
+func (s *LockServer) ClientInit(ctx context.Context, args *pb.InitArgs) (*pb.Int, error) {
+	if args.Rc == AssignClientID {
+		clientID := s.assignClientID()
+		if err := s.lockManager.SetLockOrder(clientID, args.LockOrder); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		s.logger.Printf("Client %d initialized with an assigned ID", clientID)
+		return &pb.Int{Rc: clientID}, nil
+	}
+
+	if err := s.lockManager.SetLockOrder(args.Rc, args.LockOrder); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "client %d: %v", args.Rc, err)
+	}
 	s.logger.Printf("Client %d initialized", args.Rc)
 	// Simple handshake: return 0 to acknowledge
 	return &pb.Int{Rc: 0}, nil
 }
 
+// assignClientID hands out the next unused client ID, skipping any the lock
+// manager already knows, such as holders restored from the state file
+func (s *LockServer) assignClientID() int32 {
+	s.idMu.Lock()
+	defer s.idMu.Unlock()
+	for {
+		clientID := s.nextID
+		s.nextID++
+		if !s.lockManager.KnowsClient(clientID) {
+			return clientID
+		}
+	}
+}
+
+// resourceName maps the resource named in a request to a lock name,
+// falling back to the global lock for clients that don't name one
+func resourceName(resource string) string {
+	if resource == "" {
+		return lock_manager.GlobalResource
+	}
+	return resource
+}
+
 // LockAcquire handles the lock acquisition RPC
-func (s *LockServer) LockAcquire(ctx context.Context, args *pb.LockArgs) (*pb.Response, error) {
+func (s *LockServer) LockAcquire(ctx context.Context, args *pb.LockArgs) (resp *pb.Response, err error) {
 	clientID := args.ClientId
+	resource := resourceName(args.Resource)
 
-	s.logger.Printf("Client %d attempting to acquire lock with timeout", clientID)
+	start := time.Now()
+	defer func() {
+		wait := time.Since(start)
+		s.metrics.observeAcquire(resp.GetStatus(), wait)
+		s.checkWait(clientID, resource, wait, resp.GetStatus())
+	}()
+
+	s.logger.Printf("Client %d attempting to acquire %s lock %q with timeout", clientID, args.Mode, resource)
+	if s.replica.standby() {
+		return &pb.Response{Status: pb.Status_UNAVAILABLE}, nil
+	}
+	if s.memory.overLimit() {
+		s.logger.Printf("Client %d refused lock %q: memory use over limit", clientID, resource)
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
 
 	// Use the context-aware acquire method with timeout
-	success := s.lockManager.AcquireWithTimeout(clientID, ctx)
-	if success {
-		s.logger.Printf("Lock acquired by client %d", clientID)
-		return &pb.Response{Status: pb.Status_SUCCESS}, nil
+	mode := lock_manager.Exclusive
+	if args.Mode == pb.LockMode_SHARED {
+		mode = lock_manager.Shared
+	}
+	if err := s.lockManager.AcquirePriority(resource, clientID, mode, args.Priority, ctx); err == nil {
+		s.logger.Printf("Lock %q acquired by client %d", resource, clientID)
+		return s.grantResponse(resource, clientID), nil
+	} else if errors.Is(err, lock_manager.ErrServerBusy) {
+		s.logger.Printf("Client %d refused lock %q: persistence is slow", clientID, resource)
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	} else if errors.Is(err, lock_manager.ErrTooManyWaiters) {
+		s.logger.Printf("Client %d refused lock %q: too many clients waiting", clientID, resource)
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	} else if errors.Is(err, lock_manager.ErrShuttingDown) {
+		s.logger.Printf("Client %d refused lock %q: server is shutting down", clientID, resource)
+		return &pb.Response{Status: pb.Status_UNAVAILABLE}, nil
+	} else if errors.Is(err, lock_manager.ErrLockOrder) {
+		return &pb.Response{Status: pb.Status_LOCK_ORDER_VIOLATION}, nil
+	} else if errors.Is(err, lock_manager.ErrAlreadyHeld) {
+		return &pb.Response{Status: pb.Status_ALREADY_HELD}, nil
+	} else if errors.Is(err, lock_manager.ErrDeadlock) {
+		s.logger.Printf("Client %d refused lock %q: %v", clientID, resource, err)
+		return &pb.Response{Status: pb.Status_DEADLOCK}, nil
+	} else if errors.Is(err, lock_manager.ErrEvicted) {
+		return nil, status.Errorf(codes.PermissionDenied, "client %d is quarantined", clientID)
 	}
 
-	s.logger.Printf("Client %d timed out waiting for lock", clientID)
+	s.logger.Printf("Client %d timed out waiting for lock %q", clientID, resource)
 	return &pb.Response{Status: pb.Status_TIMEOUT}, nil
 }
 
+// LockTryAcquire handles the non-blocking lock acquisition RPC
+func (s *LockServer) LockTryAcquire(ctx context.Context, args *pb.LockArgs) (*pb.Response, error) {
+	mode := lock_manager.Exclusive
+	if args.Mode == pb.LockMode_SHARED {
+		mode = lock_manager.Shared
+	}
+	resource := resourceName(args.Resource)
+	if s.replica.standby() {
+		return &pb.Response{Status: pb.Status_UNAVAILABLE}, nil
+	}
+	if s.memory.overLimit() {
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
+	if s.lockManager.HasResourceLock(resource, args.ClientId) || s.lockManager.HasSharedLock(resource, args.ClientId) {
+		return &pb.Response{Status: pb.Status_ALREADY_HELD}, nil
+	}
+	if s.lockManager.CheckLockOrder(resource, args.ClientId) != nil {
+		return &pb.Response{Status: pb.Status_LOCK_ORDER_VIOLATION}, nil
+	}
+	err := s.lockManager.TryAcquireMode(resource, args.ClientId, mode)
+	if err == nil {
+		return s.grantResponse(resource, args.ClientId), nil
+	}
+	if errors.Is(err, lock_manager.ErrServerBusy) {
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
+
+	return &pb.Response{Status: pb.Status_LOCK_BUSY}, nil
+}
+
+// LockCompareAndAcquire handles the compare-and-swap lock handoff RPC
+func (s *LockServer) LockCompareAndAcquire(ctx context.Context, args *pb.CasArgs) (*pb.Response, error) {
+	resource := resourceName(args.Resource)
+	if s.replica.standby() {
+		return &pb.Response{Status: pb.Status_UNAVAILABLE}, nil
+	}
+	if s.lockManager.CheckLockOrder(resource, args.NewHolder) != nil {
+		return &pb.Response{Status: pb.Status_LOCK_ORDER_VIOLATION}, nil
+	}
+	err := s.lockManager.HandOff(resource, args.ExpectedHolder, args.NewHolder)
+	if err == nil {
+		return s.grantResponse(resource, args.NewHolder), nil
+	}
+	if errors.Is(err, lock_manager.ErrServerBusy) {
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
+
+	return &pb.Response{Status: pb.Status_PRECONDITION_FAILED}, nil
+}
+
+// LockTransfer handles the lock transfer RPC, handing the lock from its holder
+// straight to a chosen successor
+func (s *LockServer) LockTransfer(ctx context.Context, args *pb.TransferArgs) (*pb.Response, error) {
+	resource := resourceName(args.Resource)
+	if s.replica.standby() {
+		return &pb.Response{Status: pb.Status_UNAVAILABLE}, nil
+	}
+	if s.lockManager.CheckLockOrder(resource, args.ToClientId) != nil {
+		return &pb.Response{Status: pb.Status_LOCK_ORDER_VIOLATION}, nil
+	}
+	if args.FromClientId == -1 {
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+	err := s.lockManager.HandOff(resource, args.FromClientId, args.ToClientId)
+	if err == nil {
+		return s.grantResponse(resource, args.ToClientId), nil
+	}
+	if errors.Is(err, lock_manager.ErrServerBusy) {
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
+
+	return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+}
+
 // LockRelease handles the lock release RPC
 func (s *LockServer) LockRelease(ctx context.Context, args *pb.LockArgs) (*pb.Response, error) {
-	clientID := args.ClientId
-
-	success := s.lockManager.Release(clientID)
-	if success {
+	release := s.lockManager.ReleaseResource
+	if args.Mode == pb.LockMode_SHARED {
+		release = s.lockManager.ReleaseShared
+	}
+	if release(resourceName(args.Resource), args.ClientId) {
 		return &pb.Response{Status: pb.Status_SUCCESS}, nil
 	}
 
 	return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
 }
 
+// LockReleaseAll handles the release-all RPC, freeing every lock client rc holds
+func (s *LockServer) LockReleaseAll(ctx context.Context, args *pb.Int) (*pb.Response, error) {
+	released := s.lockManager.ReleaseAll(args.Rc)
+	return &pb.Response{Status: pb.Status_SUCCESS, Released: int32(released)}, nil
+}
+
+// holdsFileLock reports whether the client may write filename: it must hold
+// either the lock named after the file or the global lock. The two are
+// independent, so clients sharing a file should agree on which one they use.
+func (s *LockServer) holdsFileLock(clientID int32, filename string) bool {
+	return s.lockManager.HasResourceLock(filename, clientID) ||
+		s.lockManager.HasResourceLock(lock_manager.GlobalResource, clientID)
+}
+
+// holdsFileReadLock reports whether the client may read filename: like
+// holdsFileLock, but a shared hold on either lock is enough
+func (s *LockServer) holdsFileReadLock(clientID int32, filename string) bool {
+	return s.holdsFileLock(clientID, filename) ||
+		s.lockManager.HasSharedLock(filename, clientID) ||
+		s.lockManager.HasSharedLock(lock_manager.GlobalResource, clientID)
+}
+
+// pause runs the test scheduling hook, if any, at the named point
+func (s *LockServer) pause(point string, clientID int32) {
+	if s.yield != nil {
+		s.yield(point, clientID)
+	}
+}
+
+// writeErrorStatus tells clients why an append or write failed, and so whether
+// it is worth retrying: only RETRYABLE_ERROR is
+func writeErrorStatus(err error) pb.Status {
+	var errno syscall.Errno
+	switch {
+	case errors.Is(err, file_manager.ErrInvalidFilename):
+		return pb.Status_INVALID_FILENAME
+	case errors.Is(err, file_manager.ErrFileTooLarge):
+		return pb.Status_FILE_TOO_LARGE
+	case errors.Is(err, os.ErrPermission):
+		return pb.Status_PERMISSION_DENIED
+	case file_manager.IsTransient(err):
+		return pb.Status_RETRYABLE_ERROR
+	case errors.As(err, &errno):
+		return pb.Status_IO_ERROR
+	}
+	return pb.Status_FILE_ERROR
+}
+
+// requestID returns the ID to record an append under: the client's own, or a
+// new one if it sent none, so even those appends can be retried safely once
+// the client has the answer. Without deduplication there is nothing to record.
+func (s *LockServer) requestID(clientRequestID string) string {
+	if clientRequestID != "" || s.dedup == nil {
+		return clientRequestID
+	}
+	return uuid.NewString()
+}
+
+// checkAppendContent refuses empty content with INVALID_ARGUMENT, since
+// appending nothing would succeed without doing anything, and content over the
+// per-call maximum with APPEND_TOO_LARGE
+func (s *LockServer) checkAppendContent(content []byte) pb.Status {
+	switch {
+	case len(content) == 0:
+		return pb.Status_INVALID_ARGUMENT
+	case s.maxAppend > 0 && len(content) > s.maxAppend:
+		return pb.Status_APPEND_TOO_LARGE
+	}
+	return pb.Status_SUCCESS
+}
+
+// castagnoli is the CRC-32C table content checksums are computed with
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumMatches reports whether content matches the CRC-32C the client sent
+// with it, if any
+func checksumMatches(args *pb.FileArgs) bool {
+	return args.Crc32C == nil || crc32.Checksum(args.Content, castagnoli) == *args.Crc32C
+}
+
 // FileAppend handles the file append RPC
-func (s *LockServer) FileAppend(ctx context.Context, args *pb.FileArgs) (*pb.Response, error) {
+func (s *LockServer) FileAppend(ctx context.Context, args *pb.FileArgs) (resp *pb.Response, err error) {
 	clientID := args.ClientId
+	defer func() { s.metrics.observeAppend(resp.GetStatus()) }()
 
-	// Check if this client holds the lock
-	if !s.lockManager.HasLock(clientID) {
-		s.logger.Printf("File append failed: client %d doesn't hold the lock", clientID)
+	if !s.limiter.allow(clientID) {
+		s.logger.Printf("File append refused: client %d is over its rate limit", clientID)
+		return &pb.Response{Status: pb.Status_RATE_LIMITED}, nil
+	}
+
+	// A retried request that already succeeded gets the original answer
+	requestID := s.requestID(args.RequestId)
+	entry, prev, claimed, err := s.dedup.begin(ctx, dedupKey{clientID: clientID, requestID: requestID})
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	if !claimed {
+		s.logger.Printf("File append %s from client %d already applied, not writing again", requestID, clientID)
+		return prev, nil
+	}
+	defer func() { s.dedup.finish(entry, resp) }()
+	defer func() { // Runs first, so the remembered answer carries it too; resp is nil if the handler panicked
+		if resp != nil {
+			resp.RequestId = requestID
+		}
+	}()
+
+	if !s.quiesce.TryRLock() {
+		s.logger.Printf("File append refused: server is in maintenance")
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
+	defer s.quiesce.RUnlock()
+	if s.readOnly.Load() {
+		return &pb.Response{Status: pb.Status_READ_ONLY}, nil
+	}
+
+	if st := s.checkAppendContent(args.Content); st != pb.Status_SUCCESS {
+		s.logger.Printf("File append refused: %d bytes of content from client %d for %s", len(args.Content), clientID, args.Filename)
+		return &pb.Response{Status: st}, nil
+	}
+	// The file manager checks this too; refusing here keeps traversal attempts
+	// away from the lock table as well as the filesystem
+	if err := file_manager.ValidatePath(s.fileManager.DataDir(), args.Filename); err != nil {
+		s.logger.Printf("File append refused: client %d sent %q: %v", clientID, args.Filename, err)
+		return &pb.Response{Status: pb.Status_INVALID_FILENAME}, nil
+	}
+	if !checksumMatches(args) {
+		s.logger.Printf("File append refused: content from client %d for %s doesn't match its checksum", clientID, args.Filename)
+		return &pb.Response{Status: pb.Status_CHECKSUM_MISMATCH}, nil
+	}
+
+	// Check if this client holds the lock for this file
+	if !s.holdsFileLock(clientID, args.Filename) {
+		s.logger.Printf("File append failed: client %d doesn't hold the lock for %s", clientID, args.Filename)
 		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
 	}
 
-	err := s.fileManager.AppendToFile(args.Filename, args.Content)
+	content, err := s.applyTransforms(args.Filename, args.Content)
 	if err != nil {
 		s.logger.Printf("File append error: %v", err)
-		return &pb.Response{Status: pb.Status_FILE_ERROR}, nil
+		return &pb.Response{Status: pb.Status_PERMANENT_ERROR}, nil
+	}
+	s.pause("file_append.authorized", clientID)
+
+	// With an expected offset the append only happens if nobody else has
+	// appended since the client last looked at the file
+	expected := int64(-1)
+	if args.ExpectedOffset != nil {
+		expected = *args.ExpectedOffset
+	}
+	offset, size, err := s.fileManager.AppendAtAs(clientID, args.Filename, content, expected)
+	if errors.Is(err, file_manager.ErrOffsetMismatch) {
+		return &pb.Response{Status: pb.Status_OFFSET_MISMATCH, Size: size}, nil
+	}
+	if err != nil {
+		s.logger.Printf("File append error: %v", err)
+		return &pb.Response{Status: writeErrorStatus(err)}, nil
+	}
+
+	// Report what the client sent, not what the transforms turned it into
+	return &pb.Response{Status: pb.Status_SUCCESS, Bytes: int64(len(args.Content)), Size: size, Offset: offset}, nil
+}
+
+// FileAppendBatch handles the batched append RPC. Permission, filenames and
+// transforms are checked for every entry before any is written; if a write
+// then fails, FailedEntry says which one, and the entries before it stay written.
+func (s *LockServer) FileAppendBatch(ctx context.Context, args *pb.BatchArgs) (resp *pb.Response, err error) {
+	clientID := args.ClientId
+
+	if !s.limiter.allow(clientID) {
+		s.logger.Printf("Batch append refused: client %d is over its rate limit", clientID)
+		return &pb.Response{Status: pb.Status_RATE_LIMITED}, nil
+	}
+
+	// A retried request that already succeeded gets the original answer
+	requestID := s.requestID(args.RequestId)
+	entry, prev, claimed, err := s.dedup.begin(ctx, dedupKey{clientID: clientID, requestID: requestID})
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	if !claimed {
+		s.logger.Printf("Batch append %s from client %d already applied, not writing again", requestID, clientID)
+		return prev, nil
+	}
+	defer func() { s.dedup.finish(entry, resp) }()
+	defer func() { // Runs first, so the remembered answer carries it too; resp is nil if the handler panicked
+		if resp != nil {
+			resp.RequestId = requestID
+		}
+	}()
+
+	if !s.quiesce.TryRLock() {
+		s.logger.Printf("Batch append refused: server is in maintenance")
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
+	defer s.quiesce.RUnlock()
+	if s.readOnly.Load() {
+		return &pb.Response{Status: pb.Status_READ_ONLY}, nil
+	}
+
+	appends := make([]file_manager.Append, len(args.Entries))
+	var total int64
+	for i, e := range args.Entries {
+		if st := s.checkAppendContent(e.Content); st != pb.Status_SUCCESS {
+			s.logger.Printf("Batch append refused: %d bytes of content from client %d for %s", len(e.Content), clientID, e.Filename)
+			return &pb.Response{Status: st, FailedEntry: int32(i)}, nil
+		}
+		if err := file_manager.ValidatePath(s.fileManager.DataDir(), e.Filename); err != nil {
+			s.logger.Printf("Batch append refused: client %d sent %q: %v", clientID, e.Filename, err)
+			return &pb.Response{Status: pb.Status_INVALID_FILENAME, FailedEntry: int32(i)}, nil
+		}
+		if !s.holdsFileLock(clientID, e.Filename) {
+			s.logger.Printf("Batch append failed: client %d doesn't hold the lock for %s", clientID, e.Filename)
+			return &pb.Response{Status: pb.Status_PERMISSION_DENIED, FailedEntry: int32(i)}, nil
+		}
+		content, err := s.applyTransforms(e.Filename, e.Content)
+		if err != nil {
+			s.logger.Printf("Batch append error: %v", err)
+			return &pb.Response{Status: pb.Status_PERMANENT_ERROR, FailedEntry: int32(i)}, nil
+		}
+		appends[i] = file_manager.Append{Filename: e.Filename, Content: content}
+		total += int64(len(e.Content))
+	}
+
+	if failed, err := s.fileManager.AppendBatchAs(clientID, appends); err != nil {
+		s.logger.Printf("Batch append error at entry %d: %v", failed, err)
+		return &pb.Response{Status: writeErrorStatus(err), FailedEntry: int32(failed)}, nil
+	}
+
+	s.logger.Printf("Client %d appended to %d files in one batch", clientID, len(appends))
+	return &pb.Response{Status: pb.Status_SUCCESS, Bytes: total}, nil
+}
+
+// FileWrite handles the file write RPC, replacing the file's contents. It
+// follows the same lock rules as FileAppend and runs the same transforms.
+func (s *LockServer) FileWrite(ctx context.Context, args *pb.FileArgs) (*pb.Response, error) {
+	clientID := args.ClientId
+
+	if !s.quiesce.TryRLock() {
+		s.logger.Printf("File write refused: server is in maintenance")
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
+	defer s.quiesce.RUnlock()
+	if s.readOnly.Load() {
+		return &pb.Response{Status: pb.Status_READ_ONLY}, nil
+	}
+
+	if !checksumMatches(args) {
+		s.logger.Printf("File write refused: content from client %d for %s doesn't match its checksum", clientID, args.Filename)
+		return &pb.Response{Status: pb.Status_CHECKSUM_MISMATCH}, nil
+	}
+	if !s.holdsFileLock(clientID, args.Filename) {
+		s.logger.Printf("File write failed: client %d doesn't hold the lock for %s", clientID, args.Filename)
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+
+	content, err := s.applyTransforms(args.Filename, args.Content)
+	if err != nil {
+		s.logger.Printf("File write error: %v", err)
+		return &pb.Response{Status: pb.Status_PERMANENT_ERROR}, nil
+	}
+	if err := s.fileManager.WriteFileAs(clientID, args.Filename, content); err != nil {
+		s.logger.Printf("File write error: %v", err)
+		return &pb.Response{Status: writeErrorStatus(err)}, nil
+	}
+
+	return &pb.Response{Status: pb.Status_SUCCESS, Bytes: int64(len(args.Content))}, nil
+}
+
+// FileTruncate handles the file truncate RPC, emptying the file in place. It
+// follows the same lock rules as FileAppend.
+func (s *LockServer) FileTruncate(ctx context.Context, args *pb.FileArgs) (*pb.Response, error) {
+	clientID := args.ClientId
+
+	if !s.quiesce.TryRLock() {
+		s.logger.Printf("File truncate refused: server is in maintenance")
+		return &pb.Response{Status: pb.Status_SERVER_BUSY}, nil
+	}
+	defer s.quiesce.RUnlock()
+	if s.readOnly.Load() {
+		return &pb.Response{Status: pb.Status_READ_ONLY}, nil
+	}
+
+	if !s.holdsFileLock(clientID, args.Filename) {
+		s.logger.Printf("File truncate failed: client %d doesn't hold the lock for %s", clientID, args.Filename)
+		return &pb.Response{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+	if err := s.fileManager.TruncateFileAs(clientID, args.Filename); err != nil {
+		s.logger.Printf("File truncate error: %v", err)
+		return &pb.Response{Status: writeErrorStatus(err)}, nil
 	}
 
 	return &pb.Response{Status: pb.Status_SUCCESS}, nil
 }
 
+// FileRead handles the file read RPC
+func (s *LockServer) FileRead(ctx context.Context, args *pb.FileArgs) (*pb.FileContent, error) {
+	clientID := args.ClientId
+
+	if !s.quiesce.TryRLock() {
+		s.logger.Printf("File read refused: server is in maintenance")
+		return &pb.FileContent{Status: pb.Status_SERVER_BUSY}, nil
+	}
+	defer s.quiesce.RUnlock()
+
+	// Reads need at least a shared hold so they never race a writer
+	if !s.holdsFileReadLock(clientID, args.Filename) {
+		s.logger.Printf("File read failed: client %d doesn't hold the lock for %s", clientID, args.Filename)
+		return &pb.FileContent{Status: pb.Status_PERMISSION_DENIED}, nil
+	}
+
+	content, err := s.fileManager.ReadFile(args.Filename)
+	if err != nil {
+		s.logger.Printf("File read error: %v", err)
+		return &pb.FileContent{Status: pb.Status_FILE_ERROR}, nil
+	}
+
+	return &pb.FileContent{Status: pb.Status_SUCCESS, Content: content, Size: int64(len(content))}, nil
+}
+
+// FileStats handles the file metadata RPC. It doesn't require the lock.
+func (s *LockServer) FileStats(ctx context.Context, args *pb.FileArgs) (*pb.FileStats, error) {
+	stat := s.fileManager.Stat
+	if args.Scan {
+		stat = s.fileManager.Scan
+	}
+	stats, err := stat(args.Filename)
+	if err != nil {
+		s.logger.Printf("File stats error: %v", err)
+		return &pb.FileStats{Status: pb.Status_FILE_ERROR, LastWriter: -1}, nil
+	}
+
+	resp := &pb.FileStats{
+		Status:          pb.Status_SUCCESS,
+		Size:            stats.Size,
+		LastWriter:      stats.LastWriter,
+		ModTimeUnixNano: stats.ModTime.UnixNano(),
+		LineCount:       stats.Lines,
+		Sha256:          stats.SHA256,
+	}
+	if !stats.LastWrite.IsZero() {
+		resp.LastWriteUnixNano = stats.LastWrite.UnixNano()
+	}
+	return resp, nil
+}
+
+// ListFiles handles the list RPC, reporting the size of every data file that
+// exists. Like FileStats it needs no lock, so sizes may change straight after.
+func (s *LockServer) ListFiles(ctx context.Context, args *pb.Empty) (*pb.FileList, error) {
+	filenames, err := s.fileManager.Filenames()
+	if err != nil {
+		s.logger.Printf("List files error: %v", err)
+		return &pb.FileList{Status: pb.Status_FILE_ERROR}, nil
+	}
+
+	list := &pb.FileList{Status: pb.Status_SUCCESS}
+	for _, filename := range filenames {
+		stats, err := s.fileManager.Stat(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			s.logger.Printf("List files error: %v", err)
+			return &pb.FileList{Status: pb.Status_FILE_ERROR}, nil
+		}
+		list.Entries = append(list.Entries, &pb.FileEntry{
+			Name:            filename,
+			Size:            stats.Size,
+			ModTimeUnixNano: stats.ModTime.UnixNano(),
+		})
+	}
+	return list, nil
+}
+
+// ValidateFilenames handles the dry-run RPC checking names the way FileAppend
+// does, so clients can refuse bad input before starting work. Nothing is
+// written and no lock is needed.
+func (s *LockServer) ValidateFilenames(ctx context.Context, args *pb.FilenamesArgs) (*pb.ValidationResult, error) {
+	result := &pb.ValidationResult{Status: pb.Status_SUCCESS}
+	for _, filename := range args.Filenames {
+		check := &pb.FilenameCheck{Name: filename, Ok: true}
+		if err := s.fileManager.ValidateFilename(filename); err != nil {
+			check.Ok = false
+			check.Reason = err.Error()
+		}
+		result.Results = append(result.Results, check)
+	}
+	return result, nil
+}
+
+// KeepAlive handles the liveness RPC, extending the lease on the client's locks
+func (s *LockServer) KeepAlive(ctx context.Context, args *pb.Int) (*pb.Response, error) {
+	s.lockManager.Heartbeat(args.Rc)
+	return &pb.Response{Status: pb.Status_SUCCESS}, nil
+}
+
+// BackupStream handles the backup RPC, streaming every data file in chunks
+func (s *LockServer) BackupStream(args *pb.BackupArgs, stream grpc.ServerStreamingServer[pb.BackupChunk]) error {
+	s.logger.Printf("Starting backup (consistent: %v)", args.Consistent)
+
+	files := 0
+	err := s.fileManager.ForEachFile(args.Consistent, func(filename string, content []byte) error {
+		files++
+		size := int64(len(content))
+		for offset := 0; ; offset += backupChunkSize {
+			end := min(offset+backupChunkSize, len(content))
+			chunk := &pb.BackupChunk{Filename: filename, Size: size, Data: content[offset:end]}
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+			if end == len(content) {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		s.logger.Printf("Backup failed: %v", err)
+		return status.Errorf(codes.Internal, "backup failed: %v", err)
+	}
+
+	s.logger.Printf("Backup complete: %d files", files)
+	return nil
+}
+
+// RestoreStream handles the admin restore RPC, writing the files carried by a
+// backup stream. The stream is buffered in full first so file operations are
+// only paused for the write itself.
+func (s *LockServer) RestoreStream(stream grpc.ClientStreamingServer[pb.RestoreArgs, pb.RestoreResult]) error {
+	if !s.isAdmin(stream.Context()) {
+		s.logger.Printf("Restore refused: missing or invalid admin token")
+		return stream.SendAndClose(&pb.RestoreResult{Status: pb.Status_PERMISSION_DENIED})
+	}
+
+	files := make(map[string][]byte)
+	sizes := make(map[string]int64)
+	force, first := false, true
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			force, first = msg.Force, false
+		}
+		if chunk := msg.Chunk; chunk != nil {
+			files[chunk.Filename] = append(files[chunk.Filename], chunk.Data...)
+			sizes[chunk.Filename] = chunk.Size
+		}
+	}
+	for filename, content := range files {
+		if int64(len(content)) != sizes[filename] {
+			s.logger.Printf("Restore failed: %s is truncated (%d of %d bytes)", filename, len(content), sizes[filename])
+			return stream.SendAndClose(&pb.RestoreResult{Status: pb.Status_FILE_ERROR})
+		}
+	}
+
+	// Wait for in-flight file operations to drain and hold off new ones
+	s.quiesce.Lock()
+	defer s.quiesce.Unlock()
+	s.logger.Printf("Entering maintenance: restoring %d files (force: %v)", len(files), force)
+
+	err := s.fileManager.RestoreFiles(files, force)
+	switch {
+	case errors.Is(err, file_manager.ErrWouldOverwrite):
+		s.logger.Printf("Restore refused: %v", err)
+		return stream.SendAndClose(&pb.RestoreResult{Status: pb.Status_PRECONDITION_FAILED})
+	case err != nil:
+		s.logger.Printf("Restore failed: %v", err)
+		return stream.SendAndClose(&pb.RestoreResult{Status: pb.Status_FILE_ERROR})
+	}
+	return stream.SendAndClose(&pb.RestoreResult{Status: pb.Status_SUCCESS, Files: int32(len(files))})
+}
+
+// GetLockStatus handles the lock inspection RPC for the global lock
+func (s *LockServer) GetLockStatus(ctx context.Context, args *pb.Empty) (*pb.LockStatusResponse, error) {
+	st := s.lockManager.Status(lock_manager.GlobalResource)
+	return &pb.LockStatusResponse{
+		Holder:           st.Holder,
+		Waiters:          int32(st.Waiters),
+		Readers:          int32(st.Readers),
+		LeaseRemainingMs: st.LeaseRemaining.Milliseconds(),
+	}, nil
+}
+
+// GetAllLocks handles the bulk lock inspection RPC, listing every lock that is
+// held or waited for from one snapshot of the lock manager
+func (s *LockServer) GetAllLocks(ctx context.Context, args *pb.Empty) (*pb.AllLocksResponse, error) {
+	locks := s.lockManager.AllLocks()
+	resp := &pb.AllLocksResponse{Entries: make([]*pb.LockEntry, 0, len(locks))}
+	for _, l := range locks {
+		resp.Entries = append(resp.Entries, &pb.LockEntry{
+			Resource: l.Resource,
+			Holder:   l.Holder,
+			Token:    l.Token,
+			Waiters:  int32(l.Waiters),
+			Readers:  int32(l.Readers),
+		})
+	}
+	return resp, nil
+}
+
+// GetQueuePosition handles the queue position RPC, reporting how far a waiting
+// client is from the head of the queue for the lock it's waiting on
+func (s *LockServer) GetQueuePosition(ctx context.Context, args *pb.LockArgs) (*pb.PositionResponse, error) {
+	ahead, queued := s.lockManager.QueuePosition(resourceName(args.Resource), args.ClientId)
+	if !queued {
+		return &pb.PositionResponse{Status: pb.Status_PRECONDITION_FAILED}, nil
+	}
+	return &pb.PositionResponse{Status: pb.Status_SUCCESS, Position: int32(ahead) + 1, AheadCount: int32(ahead)}, nil
+}
+
+// VerifyToken handles the fencing token check RPC. A token is valid only while
+// the client still holds the lock exclusively under that same grant.
+func (s *LockServer) VerifyToken(ctx context.Context, args *pb.TokenArgs) (*pb.TokenValidity, error) {
+	token, held := s.lockManager.FencingToken(resourceName(args.Resource), args.ClientId)
+	return &pb.TokenValidity{Valid: held && token != 0 && token == args.Token}, nil
+}
+
 // ClientClose handles the client close RPC
 func (s *LockServer) ClientClose(ctx context.Context, args *pb.Int) (*pb.Int, error) {
 	clientID := args.Rc
 	s.logger.Printf("Client %d closing connection", clientID)
 
-	// If this client holds the lock, release it
+	// Release any locks this client still holds
 	s.lockManager.ReleaseLockIfHeld(clientID)
+	s.lockManager.ForgetClient(clientID)
 
 	// Simple acknowledgment: return 0
 	return &pb.Int{Rc: 0}, nil
 }
 
-// CreateFiles ensures the 100 files exist - now delegates to file manager
-func CreateFiles() {
-	fm := file_manager.NewFileManager(false)
-	fm.CreateFiles()
+// CreateFiles ensures file_0 to file_<fileCount-1> exist in dataDir - now delegates to file manager
+func CreateFiles(dataDir string, fileCount int) error {
+	return CreateSeededFiles(dataDir, fileCount, nil)
+}
+
+// CreateSeededFiles is CreateFiles writing seed into each file it creates;
+// files that already exist keep their content
+func CreateSeededFiles(dataDir string, fileCount int, seed []byte) error {
+	fm := file_manager.NewFileManager(false, file_manager.WithDataDir(dataDir), file_manager.WithFileCount(fileCount),
+		file_manager.WithSeed(seed))
+	defer fm.Cleanup()
+	return fm.CreateFiles()
 }
 
 // Cleanup closes any open files and performs other cleanup tasks
 func (s *LockServer) Cleanup() {
+	s.watchers.close()
+	s.stopHealth()
+	s.memory.close()
+	s.metrics.close()
+	s.audit.close()
+	s.lockManager.Close()
+	s.replicator.close()
 	s.fileManager.Cleanup()
 	s.logger.Println("Server cleanup complete")
 }
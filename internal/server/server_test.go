@@ -0,0 +1,1241 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"Distributed-Lock-Manager/internal/file_manager"
+	"Distributed-Lock-Manager/internal/lock_manager"
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestServer creates a lock server backed by a fresh temporary data directory
+func newTestServer(t *testing.T, opts ...Option) (*LockServer, string) {
+	dataDir, err := os.MkdirTemp("", "server_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dataDir) })
+
+	s := NewLockServer(dataDir, opts...)
+	t.Cleanup(s.Cleanup)
+	return s, dataDir
+}
+
+func TestConfiguredDataDir(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+
+	resp, err := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("LockAcquire failed: %v, %v", resp, err)
+	}
+
+	content := []byte("hello from client 1\n")
+	resp, err = s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_3", Content: content})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("FileAppend failed: %v, %v", resp, err)
+	}
+
+	// The append must land in the configured directory
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_3"))
+	if err != nil {
+		t.Fatalf("Failed to read appended file: %v", err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("File content mismatch. Got %q, want %q", got, content)
+	}
+
+	// Nothing should be written to the default directory
+	if _, err := os.Stat(filepath.Join("data", "file_3")); !os.IsNotExist(err) {
+		t.Errorf("Append should not touch the default data directory")
+	}
+}
+
+func TestCreateFilesInDataDir(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "nested", "data")
+
+	if err := CreateFiles(dataDir, 100); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
+
+	for _, name := range []string{"file_0", "file_99"} {
+		if _, err := os.Stat(filepath.Join(dataDir, name)); err != nil {
+			t.Errorf("File %s was not created in %s: %v", name, dataDir, err)
+		}
+	}
+
+	// Failures are reported, not fatal
+	if err := CreateFiles(filepath.Join(dataDir, "file_0", "data"), 1); err == nil {
+		t.Error("Expected an error creating files under a regular file")
+	}
+}
+
+func TestConfiguredFileCount(t *testing.T) {
+	s, dataDir := newTestServer(t, WithFileCount(10))
+	ctx := context.Background()
+
+	if err := CreateFiles(dataDir, 10); err != nil {
+		t.Fatalf("CreateFiles failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "file_9")); err != nil {
+		t.Errorf("file_9 was not created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "file_10")); !os.IsNotExist(err) {
+		t.Errorf("file_10 should not be created with 10 files, got %v", err)
+	}
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	defer s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+
+	resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_9", Content: []byte("ok\n")})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Expected file_9 to be accepted, got %v, %v", resp, err)
+	}
+	resp, err = s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_10", Content: []byte("no\n")})
+	if err != nil || resp.Status != pb.Status_INVALID_FILENAME {
+		t.Errorf("Expected file_10 to be rejected, got %v, %v", resp, err)
+	}
+}
+
+func TestLockTryAcquire(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := context.Background()
+
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Client 1 failed to acquire lock: %v", resp.Status)
+	}
+
+	// Lock is held, so try-acquire reports busy
+	resp, err := s.LockTryAcquire(ctx, &pb.LockArgs{ClientId: 2})
+	if err != nil {
+		t.Fatalf("LockTryAcquire returned error: %v", err)
+	}
+	if resp.Status != pb.Status_LOCK_BUSY {
+		t.Errorf("Expected LOCK_BUSY while lock is held, got %v", resp.Status)
+	}
+
+	// After the holder releases, try-acquire succeeds
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+	resp, _ = s.LockTryAcquire(ctx, &pb.LockArgs{ClientId: 2})
+	if resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Expected SUCCESS after release, got %v", resp.Status)
+	}
+}
+
+func TestLockCompareAndAcquire(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := context.Background()
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	// Successful handoff from the expected predecessor
+	resp, err := s.LockCompareAndAcquire(ctx, &pb.CasArgs{ExpectedHolder: 1, NewHolder: 2})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Expected successful handoff, got %v, %v", resp, err)
+	}
+
+	// The new holder can append; the old one can't
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 2, Filename: "file_0", Content: []byte("x")}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("New holder append failed: %v", resp.Status)
+	}
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("x")}); resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Old holder append should be denied, got %v", resp.Status)
+	}
+
+	// Handoff fails when the holder differs from the expected one
+	resp, _ = s.LockCompareAndAcquire(ctx, &pb.CasArgs{ExpectedHolder: 1, NewHolder: 3})
+	if resp.Status != pb.Status_PRECONDITION_FAILED {
+		t.Errorf("Expected PRECONDITION_FAILED, got %v", resp.Status)
+	}
+}
+
+func TestLockTransfer(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := context.Background()
+
+	first, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	// Client 3 is already queued when client 1 hands the lock to client 2
+	queued := make(chan *pb.Response)
+	go func() {
+		resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 3})
+		queued <- resp
+	}()
+	for s.lockManager.Status(lock_manager.GlobalResource).Waiters != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	resp, err := s.LockTransfer(ctx, &pb.TransferArgs{FromClientId: 1, ToClientId: 2})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Transfer failed: %v, %v", resp, err)
+	}
+	if holder := s.lockManager.CurrentHolder(); holder != 2 {
+		t.Fatalf("Expected client 2 to hold the lock after the transfer, got %d", holder)
+	}
+	if resp.FencingToken <= first.FencingToken {
+		t.Errorf("Transfer should issue a new fencing token, got %d after %d", resp.FencingToken, first.FencingToken)
+	}
+	select {
+	case <-queued:
+		t.Fatal("Queued client 3 got the lock during the transfer")
+	default:
+	}
+
+	// Only the holder can transfer
+	if resp, _ := s.LockTransfer(ctx, &pb.TransferArgs{FromClientId: 1, ToClientId: 4}); resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Expected PERMISSION_DENIED transferring a lock not held, got %v", resp.Status)
+	}
+
+	// Client 3 keeps its place and gets the lock once client 2 is done
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 2})
+	if resp := <-queued; resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Queued client should get the lock after the new holder releases, got %v", resp.Status)
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := context.Background()
+
+	verify := func(clientID int32, token uint64) bool {
+		t.Helper()
+		resp, err := s.VerifyToken(ctx, &pb.TokenArgs{ClientId: clientID, Token: token, Resource: "file_1"})
+		if err != nil {
+			t.Fatalf("VerifyToken returned error: %v", err)
+		}
+		return resp.Valid
+	}
+
+	resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"})
+	first := resp.FencingToken
+	if !verify(1, first) {
+		t.Errorf("Expected client 1's current token %d to verify", first)
+	}
+	if verify(2, first) {
+		t.Errorf("Token %d verified for client 2, which doesn't hold the lock", first)
+	}
+
+	// Once the lock has moved on, the old token is superseded, even if client 1 takes it again
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"})
+	if verify(1, first) {
+		t.Errorf("Token %d still verified after release", first)
+	}
+	resp, _ = s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"})
+	if verify(1, first) {
+		t.Errorf("Superseded token %d verified after reacquiring under token %d", first, resp.FencingToken)
+	}
+	if !verify(1, resp.FencingToken) {
+		t.Errorf("Expected new token %d to verify", resp.FencingToken)
+	}
+}
+
+func TestPerFileLocksDontSerialize(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Two clients hold locks on different files at the same time
+	var wg sync.WaitGroup
+	for i, filename := range []string{"file_3", "file_47"} {
+		wg.Add(1)
+		go func(clientID int32, filename string) {
+			defer wg.Done()
+			resp, err := s.LockAcquire(ctx, &pb.LockArgs{ClientId: clientID, Resource: filename})
+			if err != nil || resp.Status != pb.Status_SUCCESS {
+				t.Errorf("Client %d failed to lock %s: %v, %v", clientID, filename, resp, err)
+			}
+		}(int32(i+1), filename)
+	}
+	wg.Wait()
+
+	// Each client can append to the file it locked
+	for i, filename := range []string{"file_3", "file_47"} {
+		clientID := int32(i + 1)
+		resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: clientID, Filename: filename, Content: []byte(filename)})
+		if resp.Status != pb.Status_SUCCESS {
+			t.Errorf("Client %d append to %s failed: %v", clientID, filename, resp.Status)
+		}
+		if got, _ := os.ReadFile(filepath.Join(dataDir, filename)); string(got) != filename {
+			t.Errorf("%s content mismatch: got %q", filename, got)
+		}
+	}
+
+	// But not to a file locked by the other client
+	resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_47", Content: []byte("x")})
+	if resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Append without holding the file lock should be denied, got %v", resp.Status)
+	}
+}
+
+func TestFileWriteReplacesContent(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+
+	// Writing needs the lock, exactly like appending
+	resp, _ := s.FileWrite(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_2", Content: []byte("x")})
+	if resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Write without the lock should be denied, got %v", resp.Status)
+	}
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_2", Content: []byte("old content\n")}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Append failed: %v", resp.Status)
+	}
+	resp, err := s.FileWrite(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_2", Content: []byte("new\n")})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Write failed: %v, %v", resp, err)
+	}
+	if resp.Bytes != 4 {
+		t.Errorf("Expected 4 bytes written, got %d", resp.Bytes)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dataDir, "file_2")); string(got) != "new\n" {
+		t.Errorf("Expected the old content to be replaced, got %q", got)
+	}
+
+	// Appends after a write land after the new content
+	s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_2", Content: []byte("more\n")})
+	if got, _ := os.ReadFile(filepath.Join(dataDir, "file_2")); string(got) != "new\nmore\n" {
+		t.Errorf("Expected append after write, got %q", got)
+	}
+
+	// Filenames are validated like appends
+	if resp, _ := s.FileWrite(ctx, &pb.FileArgs{ClientId: 1, Filename: "../escape", Content: []byte("x")}); resp.Status == pb.Status_SUCCESS {
+		t.Errorf("Write to an invalid filename should fail")
+	}
+}
+
+func TestConditionalAppend(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	offset := func(n int64) *int64 { return &n }
+
+	// Appending at the current end of an empty file succeeds and reports the new size
+	resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_4", Content: []byte("first\n"), ExpectedOffset: offset(0)})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Append at matching offset failed: %v, %v", resp, err)
+	}
+	if resp.Size != 6 {
+		t.Errorf("Expected size 6 after the append, got %d", resp.Size)
+	}
+
+	// Another append makes offset 6 stale
+	s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_4", Content: []byte("other\n")})
+	resp, _ = s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_4", Content: []byte("lost\n"), ExpectedOffset: offset(6)})
+	if resp.Status != pb.Status_OFFSET_MISMATCH {
+		t.Fatalf("Expected OFFSET_MISMATCH for a stale offset, got %v", resp.Status)
+	}
+	if resp.Size != 12 {
+		t.Errorf("Expected the actual size 12 in the response, got %d", resp.Size)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dataDir, "file_4")); string(got) != "first\nother\n" {
+		t.Errorf("Rejected append should not write, got %q", got)
+	}
+
+	// Retrying at the size the server reported succeeds
+	resp, _ = s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_4", Content: []byte("retry\n"), ExpectedOffset: offset(resp.Size)})
+	if resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Append at the reported size failed: %v", resp.Status)
+	}
+}
+
+func TestLockOrderViolation(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := context.Background()
+	if _, err := s.ClientInit(ctx, &pb.InitArgs{Rc: 1, LockOrder: []string{"file_1", "file_2"}}); err != nil {
+		t.Fatalf("ClientInit failed: %v", err)
+	}
+
+	// In order: file_1 then file_2
+	for _, resource := range []string{"file_1", "file_2"} {
+		if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: resource}); resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("In-order acquire of %s failed: %v", resource, resp.Status)
+		}
+	}
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"})
+
+	// Out of order: file_1 while holding file_2
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"}); resp.Status != pb.Status_LOCK_ORDER_VIOLATION {
+		t.Errorf("Expected LOCK_ORDER_VIOLATION, got %v", resp.Status)
+	}
+	if resp, _ := s.LockTryAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"}); resp.Status != pb.Status_LOCK_ORDER_VIOLATION {
+		t.Errorf("Expected LOCK_ORDER_VIOLATION from try-acquire, got %v", resp.Status)
+	}
+
+	// An order naming a lock twice is rejected outright
+	if _, err := s.ClientInit(ctx, &pb.InitArgs{Rc: 2, LockOrder: []string{"a", "a"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a duplicate lock, got %v", err)
+	}
+}
+
+func TestDeadlockIsBroken(t *testing.T) {
+	s, _ := newTestServer(t, WithDeadlockBreaking())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Client 1 holds file_1 and waits for file_2, which client 2 holds
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"})
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_2"})
+	waited := make(chan *pb.Response, 1)
+	go func() {
+		resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_2"})
+		waited <- resp
+	}()
+	for s.lockManager.Status("file_2").Waiters == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Client 2 going for file_1 would close the cycle
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_1"}); resp.Status != pb.Status_DEADLOCK {
+		t.Fatalf("Expected DEADLOCK, got %v", resp.Status)
+	}
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_2"})
+	if resp := <-waited; resp.GetStatus() != pb.Status_SUCCESS {
+		t.Errorf("Expected client 1 to get file_2 once client 2 backed off, got %v", resp)
+	}
+}
+
+func TestAcquireOfHeldLockIsRejected(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Acquire failed: %v", resp.Status)
+	}
+	// A second acquire by the holder would otherwise wait on itself until it timed out
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); resp.Status != pb.Status_ALREADY_HELD {
+		t.Errorf("Expected ALREADY_HELD, got %v", resp.Status)
+	}
+	if resp, _ := s.LockTryAcquire(ctx, &pb.LockArgs{ClientId: 1}); resp.Status != pb.Status_ALREADY_HELD {
+		t.Errorf("Expected ALREADY_HELD from try-acquire, got %v", resp.Status)
+	}
+	if ctx.Err() != nil {
+		t.Error("Re-acquire should be refused straight away")
+	}
+	if !s.lockManager.HasLock(1) {
+		t.Error("Client 1 should still hold the lock")
+	}
+}
+
+func TestAssignedClientIDs(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := context.Background()
+
+	// The first ID in the assigned range is already in use
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: FirstAssignedClientID})
+
+	seen := make(map[int32]bool)
+	for i := 0; i < 2; i++ {
+		resp, err := s.ClientInit(ctx, &pb.InitArgs{Rc: AssignClientID})
+		if err != nil {
+			t.Fatalf("ClientInit failed: %v", err)
+		}
+		if resp.Rc < FirstAssignedClientID {
+			t.Errorf("Assigned ID %d is below the assigned range", resp.Rc)
+		}
+		if resp.Rc == FirstAssignedClientID {
+			t.Errorf("Assigned ID %d already belongs to a lock holder", resp.Rc)
+		}
+		if seen[resp.Rc] {
+			t.Errorf("ID %d assigned twice", resp.Rc)
+		}
+		seen[resp.Rc] = true
+	}
+
+	// Clients choosing their own ID still just get an acknowledgement
+	if resp, _ := s.ClientInit(ctx, &pb.InitArgs{Rc: 1}); resp.Rc != 0 {
+		t.Errorf("Expected 0 for a client-chosen ID, got %d", resp.Rc)
+	}
+}
+
+func TestFileTruncate(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+
+	if resp, _ := s.FileTruncate(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_3"}); resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Truncate without the lock should be denied, got %v", resp.Status)
+	}
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_3", Content: []byte("some content\n")})
+	path := filepath.Join(dataDir, "file_3")
+	before, _ := os.Stat(path)
+
+	resp, err := s.FileTruncate(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_3"})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Truncate failed: %v, %v", resp, err)
+	}
+	after, err := os.Stat(path)
+	if err != nil || after.Size() != 0 {
+		t.Fatalf("Expected an empty file after truncate, got %v, %v", after, err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("Truncate should empty the file in place, not replace it")
+	}
+
+	// The append handle cached by the first append now writes from offset 0
+	s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_3", Content: []byte("fresh\n")})
+	if got, _ := os.ReadFile(path); string(got) != "fresh\n" {
+		t.Errorf("Expected the append after truncate at offset 0, got %q", got)
+	}
+}
+
+func TestFileAppendBatch(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	args := &pb.BatchArgs{ClientId: 1}
+	for i := 0; i < 5; i++ {
+		args.Entries = append(args.Entries, &pb.BatchEntry{Filename: fmt.Sprintf("file_%d", i), Content: []byte(fmt.Sprintf("entry %d\n", i))})
+	}
+	resp, err := s.FileAppendBatch(ctx, args)
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Batch append failed: %v, %v", resp, err)
+	}
+	for i := 0; i < 5; i++ {
+		got, _ := os.ReadFile(filepath.Join(dataDir, fmt.Sprintf("file_%d", i)))
+		if want := fmt.Sprintf("entry %d\n", i); string(got) != want {
+			t.Errorf("file_%d: expected %q, got %q", i, want, got)
+		}
+	}
+
+	// A bad filename anywhere in the batch fails it before anything is written
+	bad := &pb.BatchArgs{ClientId: 1, Entries: []*pb.BatchEntry{
+		{Filename: "file_7", Content: []byte("x")},
+		{Filename: "file_1000", Content: []byte("x")},
+	}}
+	resp, _ = s.FileAppendBatch(ctx, bad)
+	if resp.Status != pb.Status_INVALID_FILENAME || resp.FailedEntry != 1 {
+		t.Errorf("Expected INVALID_FILENAME at entry 1, got %v at %d", resp.Status, resp.FailedEntry)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "file_7")); !os.IsNotExist(err) {
+		t.Errorf("file_7 was written even though the batch was rejected")
+	}
+
+	// So does a file the client holds no lock for
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_8"})
+	resp, _ = s.FileAppendBatch(ctx, &pb.BatchArgs{ClientId: 1, Entries: []*pb.BatchEntry{
+		{Filename: "file_8", Content: []byte("x")},
+		{Filename: "file_9", Content: []byte("x")},
+	}})
+	if resp.Status != pb.Status_PERMISSION_DENIED || resp.FailedEntry != 1 {
+		t.Errorf("Expected PERMISSION_DENIED at entry 1, got %v at %d", resp.Status, resp.FailedEntry)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "file_8")); !os.IsNotExist(err) {
+		t.Errorf("file_8 was written even though the batch was rejected")
+	}
+}
+
+func TestWriteErrorStatus(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	// A file that can never be appended to: its path is a directory
+	if err := os.Mkdir(filepath.Join(dataDir, "file_4"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_4", Content: []byte("x")})
+	if resp.Status != pb.Status_IO_ERROR {
+		t.Errorf("Expected IO_ERROR appending to a directory, got %v", resp.Status)
+	}
+
+	resp, _ = s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "../file_4", Content: []byte("x")})
+	if resp.Status != pb.Status_INVALID_FILENAME {
+		t.Errorf("Expected INVALID_FILENAME for a bad name, got %v", resp.Status)
+	}
+
+	// A read-only data directory; root ignores permissions, so only check as another user
+	if runtime.GOOS != "windows" && os.Geteuid() != 0 {
+		if err := os.Chmod(dataDir, 0555); err != nil {
+			t.Fatalf("Failed to change directory permissions: %v", err)
+		}
+		resp, _ = s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_5", Content: []byte("x")})
+		os.Chmod(dataDir, 0755)
+		if resp.Status != pb.Status_PERMISSION_DENIED {
+			t.Errorf("Expected PERMISSION_DENIED in a read-only directory, got %v", resp.Status)
+		}
+	}
+
+	// Failures that may clear up are reported as retryable
+	for _, errno := range []syscall.Errno{syscall.ENOSPC, syscall.EMFILE, syscall.EAGAIN} {
+		err := &os.PathError{Op: "write", Path: "file_0", Err: errno}
+		if got := writeErrorStatus(err); got != pb.Status_RETRYABLE_ERROR {
+			t.Errorf("Expected RETRYABLE_ERROR for %v, got %v", errno, got)
+		}
+	}
+	tests := []struct {
+		err  error
+		want pb.Status
+	}{
+		{&os.PathError{Op: "open", Path: "file_0", Err: syscall.EACCES}, pb.Status_PERMISSION_DENIED},
+		{&os.PathError{Op: "open", Path: "file_0", Err: syscall.EROFS}, pb.Status_IO_ERROR},
+		{&os.PathError{Op: "write", Path: "file_0", Err: syscall.EIO}, pb.Status_IO_ERROR},
+		{fmt.Errorf("%w format", file_manager.ErrInvalidFilename), pb.Status_INVALID_FILENAME},
+		{errors.New("something else"), pb.Status_FILE_ERROR},
+	}
+	for _, tt := range tests {
+		if got := writeErrorStatus(tt.err); got != tt.want {
+			t.Errorf("writeErrorStatus(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMaxFileSize(t *testing.T) {
+	s, dataDir := newTestServer(t, WithMaxFileSize(10))
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	// Appends up to the limit go through
+	for _, content := range []string{"12345", "67890"} {
+		resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte(content)})
+		if resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("Append within the limit failed: %v", resp.Status)
+		}
+	}
+
+	// One more byte is refused and the file is left alone
+	resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("x")})
+	if resp.Status != pb.Status_FILE_TOO_LARGE {
+		t.Errorf("Expected FILE_TOO_LARGE past the limit, got %v", resp.Status)
+	}
+	if resp, _ := s.FileWrite(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_1", Content: []byte("12345678901")}); resp.Status != pb.Status_FILE_TOO_LARGE {
+		t.Errorf("Expected FILE_TOO_LARGE for an oversized write, got %v", resp.Status)
+	}
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file_0: %v", err)
+	}
+	if string(got) != "1234567890" {
+		t.Errorf("Expected the file to stay at the limit, got %q", got)
+	}
+}
+
+func TestAppendContentSize(t *testing.T) {
+	s, dataDir := newTestServer(t, WithMaxAppendBytes(8))
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    pb.Status
+	}{
+		{"empty", []byte{}, pb.Status_INVALID_ARGUMENT},
+		{"nil", nil, pb.Status_INVALID_ARGUMENT},
+		{"normal", []byte("12345678"), pb.Status_SUCCESS},
+		{"oversized", []byte("123456789"), pb.Status_APPEND_TOO_LARGE},
+	}
+	for _, tt := range tests {
+		resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: tt.content})
+		if err != nil {
+			t.Fatalf("%s append returned error: %v", tt.name, err)
+		}
+		if resp.Status != tt.want {
+			t.Errorf("%s append: expected %v, got %v", tt.name, tt.want, resp.Status)
+		}
+	}
+
+	// Each batch entry is checked on its own, and a bad one stops the whole batch
+	resp, _ := s.FileAppendBatch(ctx, &pb.BatchArgs{ClientId: 1, Entries: []*pb.BatchEntry{
+		{Filename: "file_1", Content: []byte("ok")},
+		{Filename: "file_2", Content: []byte("far too long")},
+	}})
+	if resp.Status != pb.Status_APPEND_TOO_LARGE || resp.FailedEntry != 1 {
+		t.Errorf("Expected APPEND_TOO_LARGE at entry 1, got %v at %d", resp.Status, resp.FailedEntry)
+	}
+
+	// Only the normal append reached the disk
+	for filename, want := range map[string]string{"file_0": "12345678", "file_1": ""} {
+		got, err := os.ReadFile(filepath.Join(dataDir, filename))
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Failed to read %s: %v", filename, err)
+		}
+		if string(got) != want {
+			t.Errorf("Expected %s to hold %q, got %q", filename, want, got)
+		}
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	s, dataDir := newTestServer(t)
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	content := []byte("critical record\n")
+	sum := crc32.Checksum(content, crc32.MakeTable(crc32.Castagnoli))
+
+	// Content damaged after its checksum was computed is refused
+	corrupted := bytes.Clone(content)
+	corrupted[0] ^= 0x20
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: corrupted, Crc32C: &sum}); resp.Status != pb.Status_CHECKSUM_MISMATCH {
+		t.Errorf("Expected CHECKSUM_MISMATCH for a corrupted append, got %v", resp.Status)
+	}
+	if resp, _ := s.FileWrite(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_1", Content: corrupted, Crc32C: &sum}); resp.Status != pb.Status_CHECKSUM_MISMATCH {
+		t.Errorf("Expected CHECKSUM_MISMATCH for a corrupted write, got %v", resp.Status)
+	}
+
+	// Intact content goes through, as does content sent without a checksum
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: content, Crc32C: &sum}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Append with a matching checksum failed: %v", resp.Status)
+	}
+	if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: content}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Append without a checksum failed: %v", resp.Status)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file_0: %v", err)
+	}
+	if want := string(content) + string(content); string(got) != want {
+		t.Errorf("Expected only the intact appends in file_0, got %q", got)
+	}
+}
+
+func TestAppendCreatesMissingFile(t *testing.T) {
+	s, dataDir := newTestServer(t, WithFileCount(10))
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	// Nothing is created up front, as with -create-on-append, so the first
+	// append creates file_5; removing it afterwards makes the next append create it again
+	appendContent := func(content string) {
+		t.Helper()
+		resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_5", Content: []byte(content)})
+		if err != nil || resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("FileAppend failed: %v, %v", resp, err)
+		}
+	}
+	appendContent("first\n")
+	path := filepath.Join(dataDir, "file_5")
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove file_5: %v", err)
+	}
+	appendContent("second\n")
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected file_5 to be recreated: %v", err)
+	}
+	if string(got) != "second\n" {
+		t.Errorf("Expected the recreated file_5 to hold %q, got %q", "second\n", got)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		// The umask can only take permissions away
+		if perm := info.Mode().Perm(); perm&^0644 != 0 {
+			t.Errorf("Expected file_5 to be created with at most 0644, got %v", perm)
+		}
+	}
+}
+
+func TestPathTraversalRejected(t *testing.T) {
+	parent := t.TempDir()
+	dataDir := filepath.Join(parent, "data")
+	canaries := map[string]string{"passwd": "root:x:0:0\n", "file_0": "outside\n"}
+	for name, content := range canaries {
+		if err := os.WriteFile(filepath.Join(parent, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write canary %s: %v", name, err)
+		}
+	}
+	crafted := []string{
+		"file_0/../../passwd",
+		"../passwd",
+		"../file_0",
+		"..",
+		".",
+		"./file_0",
+		"file_0/../file_1",
+		"data/../../passwd",
+		"..\\passwd",
+		"file_0\x00",
+		filepath.Join(parent, "passwd"),
+		"/etc/passwd",
+	}
+
+	// The default scheme and one relaxed to accept anything both refuse them
+	schemes := map[string][]Option{
+		"default": nil,
+		"relaxed": {WithFilenameValidator(func(string) error { return nil })},
+	}
+	for scheme, opts := range schemes {
+		s := NewLockServer(dataDir, opts...)
+		ctx := context.Background()
+		s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+		for _, filename := range crafted {
+			resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: filename, Content: []byte("pwned\n")})
+			if resp.Status != pb.Status_INVALID_FILENAME {
+				t.Errorf("%s: append to %q answered %v, want INVALID_FILENAME", scheme, filename, resp.Status)
+			}
+			batch, _ := s.FileAppendBatch(ctx, &pb.BatchArgs{ClientId: 1, Entries: []*pb.BatchEntry{{Filename: filename, Content: []byte("pwned\n")}}})
+			if batch.Status != pb.Status_INVALID_FILENAME {
+				t.Errorf("%s: batch append to %q answered %v, want INVALID_FILENAME", scheme, filename, batch.Status)
+			}
+		}
+		s.Cleanup()
+	}
+
+	// Nothing outside the data directory was touched
+	for name, content := range canaries {
+		got, err := os.ReadFile(filepath.Join(parent, name))
+		if err != nil || string(got) != content {
+			t.Errorf("Canary %s changed: %q, %v", name, got, err)
+		}
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatalf("Failed to list %s: %v", parent, err)
+	}
+	for _, e := range entries {
+		if _, canary := canaries[e.Name()]; !canary && e.Name() != "data" {
+			t.Errorf("Unexpected file %s created next to the data directory", e.Name())
+		}
+	}
+}
+
+func TestFilenameValidator(t *testing.T) {
+	validate, err := file_manager.MatchFilenames(`log_[a-z]+`)
+	if err != nil {
+		t.Fatalf("MatchFilenames failed: %v", err)
+	}
+	s, _ := newTestServer(t, WithFilenameValidator(validate))
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	for filename, want := range map[string]pb.Status{
+		"log_app":          pb.Status_SUCCESS,
+		"file_0":           pb.Status_INVALID_FILENAME,
+		"../log_app":       pb.Status_INVALID_FILENAME,
+		"log_app/../log_x": pb.Status_INVALID_FILENAME,
+	} {
+		resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: filename, Content: []byte("entry\n")})
+		if resp.Status != want {
+			t.Errorf("Append to %q: expected %v, got %v", filename, want, resp.Status)
+		}
+	}
+
+	list, _ := s.ListFiles(ctx, &pb.Empty{})
+	if len(list.Entries) != 1 || list.Entries[0].Name != "log_app" || list.Entries[0].Size != 6 {
+		t.Errorf("Expected list_files to report log_app at 6 bytes, got %v", list.Entries)
+	}
+}
+
+func TestAppendMatchesFileManager(t *testing.T) {
+	s, serverDir := newTestServer(t, WithFileCount(10))
+	fmDir := t.TempDir()
+	fm := file_manager.NewFileManager(false, file_manager.WithDataDir(fmDir), file_manager.WithFileCount(10))
+	defer fm.Cleanup()
+	ctx := context.Background()
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	// The same appends, valid and not, through the RPC and straight to a file manager.
+	// Empty content is left out: the server refuses it before the file manager sees it.
+	inputs := []struct {
+		filename string
+		content  string
+	}{
+		{"file_0", "first\n"},
+		{"file_0", "second\n"},
+		{"file_3", "multi\nline\n"},
+		{"file_10", "out of range"},
+		{"data/file_1", "prefixed"},
+		{"../file_1", "escaping"},
+		{"file_01", "leading zero"},
+	}
+	for _, in := range inputs {
+		resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: in.filename, Content: []byte(in.content)})
+		if err != nil {
+			t.Fatalf("FileAppend(%q) returned error: %v", in.filename, err)
+		}
+		fmErr := fm.AppendToFileAs(1, in.filename, []byte(in.content))
+		want := pb.Status_SUCCESS
+		if fmErr != nil {
+			want = writeErrorStatus(fmErr)
+		}
+		if resp.Status != want {
+			t.Errorf("Append to %q: server answered %v, file manager returned %v", in.filename, resp.Status, fmErr)
+		}
+	}
+
+	// Both leave the same files behind
+	for i := 0; i < 10; i++ {
+		filename := fmt.Sprintf("file_%d", i)
+		fromServer, serverErr := os.ReadFile(filepath.Join(serverDir, filename))
+		fromFM, fmErr := os.ReadFile(filepath.Join(fmDir, filename))
+		if !bytes.Equal(fromServer, fromFM) || os.IsNotExist(serverErr) != os.IsNotExist(fmErr) {
+			t.Errorf("%s differs: server wrote %q (%v), file manager wrote %q (%v)", filename, fromServer, serverErr, fromFM, fmErr)
+		}
+	}
+}
+
+func TestFileRead(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := context.Background()
+
+	// Reading requires holding a lock
+	resp, err := s.FileRead(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0"})
+	if err != nil || resp.Status != pb.Status_PERMISSION_DENIED {
+		t.Fatalf("Read without the lock should be denied, got %v, %v", resp, err)
+	}
+
+	// Append known content under the exclusive lock and read it back
+	content := []byte("known content\n")
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_0"})
+	s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: content})
+
+	resp, _ = s.FileRead(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0"})
+	if resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("FileRead failed: %v", resp.Status)
+	}
+	if string(resp.Content) != string(content) || resp.Size != int64(len(content)) {
+		t.Errorf("FileRead returned %q (size %d), want %q (size %d)", resp.Content, resp.Size, content, len(content))
+	}
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_0"})
+
+	// A shared lock is enough to read
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_0", Mode: pb.LockMode_SHARED})
+	resp, _ = s.FileRead(ctx, &pb.FileArgs{ClientId: 2, Filename: "file_0"})
+	if resp.Status != pb.Status_SUCCESS || string(resp.Content) != string(content) {
+		t.Errorf("Shared holder read failed: %v %q", resp.Status, resp.Content)
+	}
+
+	// But not to append
+	appendResp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 2, Filename: "file_0", Content: []byte("x")})
+	if appendResp.Status != pb.Status_PERMISSION_DENIED {
+		t.Errorf("Shared holder append should be denied, got %v", appendResp.Status)
+	}
+}
+
+func TestFileStatsLastWriter(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx := context.Background()
+
+	// Unwritten files have no known writer
+	resp, err := s.FileStats(ctx, &pb.FileArgs{Filename: "file_1"})
+	if err != nil {
+		t.Fatalf("FileStats returned error: %v", err)
+	}
+	if resp.Status == pb.Status_SUCCESS && resp.LastWriter != -1 {
+		t.Errorf("Expected no last writer, got %d", resp.LastWriter)
+	}
+
+	// Two clients append in turn
+	before := time.Now()
+	for _, clientID := range []int32{1, 2} {
+		s.LockAcquire(ctx, &pb.LockArgs{ClientId: clientID})
+		s.FileAppend(ctx, &pb.FileArgs{ClientId: clientID, Filename: "file_1", Content: []byte("entry\n")})
+		s.LockRelease(ctx, &pb.LockArgs{ClientId: clientID})
+	}
+
+	// The most recent writer is reported
+	resp, _ = s.FileStats(ctx, &pb.FileArgs{Filename: "file_1"})
+	if resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("FileStats failed: %v", resp.Status)
+	}
+	if resp.LastWriter != 2 {
+		t.Errorf("Last writer should be client 2, got %d", resp.LastWriter)
+	}
+	if resp.Size != int64(len("entry\n")*2) {
+		t.Errorf("Size should be %d, got %d", len("entry\n")*2, resp.Size)
+	}
+	if at := time.Unix(0, resp.LastWriteUnixNano); at.Before(before) || at.After(time.Now()) {
+		t.Errorf("Last write time %v out of range", at)
+	}
+}
+
+// stalledPersister never finishes a save until released
+type stalledPersister struct {
+	release chan struct{}
+}
+
+func (p *stalledPersister) Save(snap lock_manager.Snapshot) error {
+	<-p.release
+	return nil
+}
+
+func TestMaxWaiters(t *testing.T) {
+	const maxWaiters = 3
+	s, _ := newTestServer(t, WithMaxWaiters(maxWaiters))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+
+	// Fill the queue to the limit
+	results := make(chan pb.Status, maxWaiters)
+	for id := int32(2); id < 2+maxWaiters; id++ {
+		go func(id int32) {
+			resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: id})
+			results <- resp.Status
+		}(id)
+	}
+	deadline := time.Now().Add(time.Second)
+	for s.lockManager.Status(lock_manager.GlobalResource).Waiters < maxWaiters {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the queue to fill")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The next acquire is turned away instead of queueing, on any lock
+	start := time.Now()
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 9}); resp.Status != pb.Status_SERVER_BUSY {
+		t.Errorf("Expected SERVER_BUSY with the queue full, got %v", resp.Status)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Rejection should be immediate, took %v", elapsed)
+	}
+	// Free locks can still be taken
+	if resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 9, Resource: "file_1"}); resp.Status != pb.Status_SUCCESS {
+		t.Errorf("Acquire of a free lock should succeed with the queue full, got %v", resp.Status)
+	}
+
+	// Once a waiter leaves, there is room again
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+	if status := <-results; status != pb.Status_SUCCESS {
+		t.Errorf("Expected the first waiter to get the lock, got %v", status)
+	}
+	go func() {
+		resp, _ := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 10})
+		results <- resp.Status
+	}()
+	deadline = time.Now().Add(time.Second)
+	for s.lockManager.Status(lock_manager.GlobalResource).Waiters < maxWaiters {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for a freed slot to be reused")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueuePosition(t *testing.T) {
+	s, _ := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	if resp, _ := s.GetQueuePosition(ctx, &pb.LockArgs{ClientId: 1}); resp.Status != pb.Status_PRECONDITION_FAILED {
+		t.Errorf("Expected PRECONDITION_FAILED for the holder, got %v", resp.Status)
+	}
+
+	// Queue three clients one at a time so their order is known
+	for id := int32(2); id <= 4; id++ {
+		go s.LockAcquire(ctx, &pb.LockArgs{ClientId: id})
+		deadline := time.Now().Add(time.Second)
+		for s.lockManager.Status(lock_manager.GlobalResource).Waiters < int(id-1) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for client %d to queue", id)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	for id := int32(2); id <= 4; id++ {
+		resp, _ := s.GetQueuePosition(ctx, &pb.LockArgs{ClientId: id})
+		if resp.Status != pb.Status_SUCCESS || resp.Position != id-1 || resp.AheadCount != id-2 {
+			t.Errorf("Client %d: got %v at position %d with %d ahead, want position %d with %d ahead",
+				id, resp.Status, resp.Position, resp.AheadCount, id-1, id-2)
+		}
+	}
+	// Queues are per lock
+	if resp, _ := s.GetQueuePosition(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_1"}); resp.Status != pb.Status_PRECONDITION_FAILED {
+		t.Errorf("Expected PRECONDITION_FAILED for a lock client 2 isn't waiting on, got %v", resp.Status)
+	}
+
+	// Everyone moves up when the head is served
+	s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+	deadline := time.Now().Add(time.Second)
+	for s.lockManager.CurrentHolder() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for client 2 to get the lock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if resp, _ := s.GetQueuePosition(ctx, &pb.LockArgs{ClientId: 4}); resp.Position != 2 || resp.AheadCount != 1 {
+		t.Errorf("Expected client 4 at position 2 with 1 ahead, got %d with %d ahead", resp.Position, resp.AheadCount)
+	}
+}
+
+func TestLockAcquireServerBusy(t *testing.T) {
+	p := &stalledPersister{release: make(chan struct{})}
+	s, _ := newTestServer(t, WithPersister(p), WithPersistPolicy(20*time.Millisecond, lock_manager.PersistReject))
+	defer close(p.release) // Runs before t.Cleanup, so the server's final flush can finish
+
+	resp, err := s.LockAcquire(context.Background(), &pb.LockArgs{ClientId: 1})
+	if err != nil {
+		t.Fatalf("LockAcquire returned error: %v", err)
+	}
+	if resp.Status != pb.Status_SERVER_BUSY {
+		t.Fatalf("Expected SERVER_BUSY, got %v", resp.Status)
+	}
+	if s.lockManager.HasLock(1) {
+		t.Error("A rejected acquire must not leave the lock held")
+	}
+}
+
+func TestLockStateSurvivesRestart(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "locks.json")
+	ctx := context.Background()
+
+	s1, dataDir := newTestServer(t, WithStateFile(stateFile))
+	resp, err := s1.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("LockAcquire failed: %v, %v", resp, err)
+	}
+	token := resp.FencingToken
+	if token == 0 {
+		t.Fatal("Expected a fencing token with the grant")
+	}
+	if resp, err := s1.LockAcquire(ctx, &pb.LockArgs{ClientId: 2, Resource: "file_2", Mode: pb.LockMode_SHARED}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Shared LockAcquire failed: %v, %v", resp, err)
+	}
+	s1.Cleanup() // Flushes the state file like a clean shutdown would
+
+	// A fresh server reading the same file knows who held what
+	s2 := NewLockServer(dataDir, WithStateFile(stateFile))
+	defer s2.Cleanup()
+
+	if !s2.lockManager.HasResourceLock("file_1", 1) {
+		t.Error("Client 1's exclusive lock was not recovered")
+	}
+	if !s2.lockManager.HasSharedLock("file_2", 2) {
+		t.Error("Client 2's shared lock was not recovered")
+	}
+	if got, _ := s2.lockManager.FencingToken("file_1", 1); got != token {
+		t.Errorf("Expected recovered fencing token %d, got %d", token, got)
+	}
+
+	// The recovered holder can still use its lock, and new tokens keep increasing
+	if resp, err := s2.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_1", Content: []byte("after restart\n")}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Errorf("FileAppend by recovered holder failed: %v, %v", resp, err)
+	}
+	if resp, err := s2.LockRelease(ctx, &pb.LockArgs{ClientId: 1, Resource: "file_1"}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("LockRelease failed: %v, %v", resp, err)
+	}
+	resp, err = s2.LockAcquire(ctx, &pb.LockArgs{ClientId: 3, Resource: "file_1"})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("LockAcquire after restart failed: %v, %v", resp, err)
+	}
+	if resp.FencingToken <= token {
+		t.Errorf("Fencing token went backwards across restart: %d after %d", resp.FencingToken, token)
+	}
+}
+
+func TestRestoredHolderExpires(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "locks.json")
+	ctx := context.Background()
+
+	s1, dataDir := newTestServer(t, WithStateFile(stateFile))
+	if resp, err := s1.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("LockAcquire failed: %v, %v", resp, err)
+	}
+	s1.Cleanup()
+
+	// Client 1 never comes back, so the lock frees up once the restore lease runs out
+	s2 := NewLockServer(dataDir, WithStateFile(stateFile), WithLease(100*time.Millisecond))
+	defer s2.Cleanup()
+
+	acquireCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	resp, err := s2.LockAcquire(acquireCtx, &pb.LockArgs{ClientId: 2})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Expected client 2 to get the lock after the restored lease expired: %v, %v", resp, err)
+	}
+}
+
+func TestResourceLeases(t *testing.T) {
+	s, _ := newTestServer(t, WithResourceLeases(map[string]time.Duration{
+		"file_1":  100 * time.Millisecond,
+		"file_99": time.Minute,
+	}))
+	ctx := context.Background()
+
+	for _, resource := range []string{"file_1", "file_99"} {
+		if resp, err := s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1, Resource: resource}); err != nil || resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("LockAcquire(%s) failed: %v, %v", resource, resp, err)
+		}
+	}
+
+	// Client 2 gets file_1 once its short lease runs out
+	acquireCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if resp, err := s.LockAcquire(acquireCtx, &pb.LockArgs{ClientId: 2, Resource: "file_1"}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Expected client 2 to get file_1 after its lease expired: %v, %v", resp, err)
+	}
+	if holder := s.lockManager.Status("file_99").Holder; holder != 1 {
+		t.Errorf("Expected client 1 to keep file_99 on its longer lease, held by %d", holder)
+	}
+}
+
+func TestAppendStamps(t *testing.T) {
+	s, dataDir := newTestServer(t, WithAppendStamps())
+	ctx := context.Background()
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 7})
+	defer s.LockRelease(ctx, &pb.LockArgs{ClientId: 7})
+
+	before := time.Now().Truncate(time.Millisecond)
+	var offsets []int64
+	for _, content := range []string{"first\n", "second\n"} {
+		resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 7, Filename: "file_0", Content: []byte(content)})
+		if err != nil || resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("FileAppend(%q) failed: %v, %v", content, resp, err)
+		}
+		if resp.Bytes != int64(len(content)) {
+			t.Errorf("Expected Bytes %d, got %d", len(content), resp.Bytes)
+		}
+		offsets = append(offsets, resp.Offset)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	lines := strings.SplitAfter(string(got), "\n")
+	lines = lines[:len(lines)-1]
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", got)
+	}
+	var offset int64
+	for i, want := range []string{"first", "second"} {
+		fields := strings.Fields(lines[i])
+		if len(fields) != 3 {
+			t.Fatalf("Expected line %d to be stamp, client and content, got %q", i, lines[i])
+		}
+		at, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil {
+			t.Errorf("Line %d doesn't start with an RFC 3339 time: %v", i, err)
+		} else if at.Before(before) || at.After(time.Now()) {
+			t.Errorf("Line %d stamped %v, outside the test", i, at)
+		}
+		if fields[1] != "client=7" || fields[2] != want {
+			t.Errorf("Expected line %d to be stamped by client 7 with %q, got %q", i, want, lines[i])
+		}
+		if offsets[i] != offset {
+			t.Errorf("Expected append %d at offset %d, got %d", i, offset, offsets[i])
+		}
+		offset += int64(len(lines[i]))
+	}
+}
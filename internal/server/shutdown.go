@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// DefaultShutdownHookTimeout bounds how long each shutdown hook may run
+const DefaultShutdownHookTimeout = 5 * time.Second
+
+// ShutdownHook is a cleanup callback run when the server shuts down.
+// The context expires when the hook's time budget runs out.
+type ShutdownHook func(ctx context.Context) error
+
+// namedHook is a registered shutdown hook and the name it is logged under
+type namedHook struct {
+	name string
+	fn   ShutdownHook
+}
+
+// shutdownHooks is the registry of callbacks run by GracefulStop
+type shutdownHooks struct {
+	mu      sync.Mutex
+	hooks   []namedHook
+	timeout time.Duration
+}
+
+// WithShutdownHookTimeout sets how long each shutdown hook may run before it is abandoned
+func WithShutdownHookTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.hookTimeout = d
+	}
+}
+
+// RegisterShutdownHook adds a callback to run during GracefulStop. Hooks run in
+// reverse registration order, so resources are torn down after anything
+// registered later that may depend on them.
+func (s *LockServer) RegisterShutdownHook(name string, hook ShutdownHook) {
+	s.shutdown.mu.Lock()
+	defer s.shutdown.mu.Unlock()
+	s.shutdown.hooks = append(s.shutdown.hooks, namedHook{name: name, fn: hook})
+}
+
+// GracefulStop stops gs from accepting new RPCs and waits for in-flight ones to
+// finish, then runs the registered shutdown hooks and releases server resources,
+// closing and flushing open files. Readiness turns NOT_SERVING first, so load
+// balancers stop sending work while the drain runs, and clients waiting in
+// lock_acquire are answered UNAVAILABLE straight away rather than holding it up.
+// gs may be nil when the server isn't being served over gRPC.
+func (s *LockServer) GracefulStop(gs *grpc.Server) {
+	s.health.draining.Store(true)
+	s.UpdateHealth()
+	s.lockManager.Drain()
+	s.watchers.close() // Watch streams never finish on their own
+	if gs != nil {
+		gs.GracefulStop()
+	}
+	s.runShutdownHooks()
+	s.Cleanup()
+}
+
+// runShutdownHooks runs every registered hook once, newest first. A hook that
+// overruns its timeout is abandoned so it can't block the rest of shutdown.
+func (s *LockServer) runShutdownHooks() {
+	s.shutdown.mu.Lock()
+	hooks := s.shutdown.hooks
+	s.shutdown.hooks = nil
+	s.shutdown.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		ctx, cancel := context.WithTimeout(context.Background(), s.shutdown.timeout)
+
+		done := make(chan error, 1)
+		go func() { done <- hook.fn(ctx) }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				s.logger.Printf("Shutdown hook %q failed: %v", hook.name, err)
+			} else {
+				s.logger.Printf("Shutdown hook %q completed", hook.name)
+			}
+		case <-ctx.Done():
+			s.logger.Printf("Shutdown hook %q timed out after %v", hook.name, s.shutdown.timeout)
+		}
+		cancel()
+	}
+}
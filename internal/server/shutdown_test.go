@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"Distributed-Lock-Manager/internal/lock_manager"
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestShutdownHooksRunLIFO(t *testing.T) {
+	s := NewLockServer(t.TempDir(), WithShutdownHookTimeout(50*time.Millisecond))
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) ShutdownHook {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	s.RegisterShutdownHook("metrics", record("metrics"))
+	s.RegisterShutdownHook("audit", record("audit"))
+	// A failing hook and one that overruns its budget must not stop the others
+	s.RegisterShutdownHook("failing", func(ctx context.Context) error {
+		record("failing")(ctx)
+		return errors.New("flush failed")
+	})
+	s.RegisterShutdownHook("stuck", func(ctx context.Context) error {
+		record("stuck")(ctx)
+		<-ctx.Done()
+		time.Sleep(time.Second) // Ignores cancellation for a while
+		return nil
+	})
+	s.RegisterShutdownHook("notify", record("notify"))
+
+	start := time.Now()
+	s.GracefulStop(grpc.NewServer())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Shutdown took %v; the stuck hook should have been abandoned", elapsed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"notify", "stuck", "failing", "audit", "metrics"}
+	if len(order) != len(want) {
+		t.Fatalf("Hooks ran in order %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Hooks ran in order %v, want %v", order, want)
+		}
+	}
+}
+
+func TestGracefulStopDrainsWaitersAndClosesFiles(t *testing.T) {
+	s, _ := newTestServer(t)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	gs := grpc.NewServer()
+	pb.RegisterLockServiceServer(gs, s)
+	go gs.Serve(lis)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewLockServiceClient(conn)
+	ctx := context.Background()
+
+	// Client 1 holds the lock and has a file open; client 2 queues behind it
+	if resp, err := client.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Client 1 failed to acquire: %v, %v", resp, err)
+	}
+	if resp, err := client.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("x")}); err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("Append failed: %v, %v", resp, err)
+	}
+	if s.fileManager.OpenFileCount() == 0 {
+		t.Fatalf("Expected the append to leave a file open")
+	}
+
+	waited := make(chan *pb.Response, 1)
+	go func() {
+		resp, err := client.LockAcquire(ctx, &pb.LockArgs{ClientId: 2})
+		if err != nil {
+			t.Errorf("Waiting acquire failed: %v", err)
+		}
+		waited <- resp
+	}()
+	for s.lockManager.Status(lock_manager.GlobalResource).Waiters == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop(gs)
+		close(stopped)
+	}()
+
+	select {
+	case resp := <-waited:
+		if resp.GetStatus() != pb.Status_UNAVAILABLE {
+			t.Errorf("Expected the waiting client to get UNAVAILABLE, got %v", resp.GetStatus())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Waiting client was not released by shutdown")
+	}
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("GracefulStop did not finish")
+	}
+
+	if n := s.fileManager.OpenFileCount(); n != 0 {
+		t.Errorf("Expected Cleanup to close every file, %d still open", n)
+	}
+}
+
+// serveLocal serves s over gRPC on a local port and returns the gRPC server and a client for it
+func serveLocal(t *testing.T, s *LockServer) (*grpc.Server, pb.LockServiceClient) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	gs := grpc.NewServer()
+	pb.RegisterLockServiceServer(gs, s)
+	go gs.Serve(lis)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gs, pb.NewLockServiceClient(conn)
+}
+
+func TestAppendsSurviveGracefulRestart(t *testing.T) {
+	modes := []struct {
+		name string
+		opts []Option
+	}{
+		{"buffered", nil},
+		{"sync", []Option{WithSyncWrites()}},
+		{"one open file", []Option{WithMaxOpenFiles(1)}},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			ctx := context.Background()
+			s1, dataDir := newTestServer(t, mode.opts...)
+			gs, client := serveLocal(t, s1)
+
+			// Appends to several files, so some handles are still open at shutdown
+			want := map[string]string{}
+			if resp, err := client.LockAcquire(ctx, &pb.LockArgs{ClientId: 1}); err != nil || resp.Status != pb.Status_SUCCESS {
+				t.Fatalf("Acquire failed: %v, %v", resp, err)
+			}
+			for i := 0; i < 20; i++ {
+				filename := fmt.Sprintf("file_%d", i%3)
+				line := fmt.Sprintf("record %d\n", i)
+				resp, err := client.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: filename, Content: []byte(line)})
+				if err != nil || resp.Status != pb.Status_SUCCESS {
+					t.Fatalf("Append %d failed: %v, %v", i, resp, err)
+				}
+				want[filename] += line
+			}
+			s1.GracefulStop(gs)
+
+			// A new server over the same data directory reads back every acknowledged append
+			s2 := NewLockServer(dataDir, mode.opts...)
+			t.Cleanup(s2.Cleanup)
+			gs2, client2 := serveLocal(t, s2)
+			defer gs2.Stop()
+			if resp, err := client2.LockAcquire(ctx, &pb.LockArgs{ClientId: 2}); err != nil || resp.Status != pb.Status_SUCCESS {
+				t.Fatalf("Acquire after restart failed: %v, %v", resp, err)
+			}
+			for filename, content := range want {
+				resp, err := client2.FileRead(ctx, &pb.FileArgs{ClientId: 2, Filename: filename})
+				if err != nil || resp.Status != pb.Status_SUCCESS {
+					t.Fatalf("Read of %s after restart failed: %v, %v", filename, resp, err)
+				}
+				if string(resp.Content) != content {
+					t.Errorf("%s after restart is %q, want %q", filename, resp.Content, content)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncWritesReachFileManager(t *testing.T) {
+	ctx := context.Background()
+	for _, sync := range []bool{false, true} {
+		var opts []Option
+		if sync {
+			opts = append(opts, WithSyncWrites())
+		}
+		s, _ := newTestServer(t, opts...)
+		if got := s.fileManager.SyncEnabled(); got != sync {
+			t.Errorf("WithSyncWrites set %v: file manager sync is %v", sync, got)
+		}
+
+		// Appends go through the file manager, which records who wrote last
+		s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+		if resp, _ := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("x")}); resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("Append failed: %v", resp.Status)
+		}
+		if stats, err := s.fileManager.Stat("file_0"); err != nil || stats.LastWriter != 1 || stats.Size != 1 {
+			t.Errorf("Expected the file manager to have written file_0 for client 1, got %+v, %v", stats, err)
+		}
+	}
+}
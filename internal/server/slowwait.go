@@ -0,0 +1,36 @@
+package server
+
+import (
+	"time"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+// DefaultSlowWaitThreshold is how long a lock_acquire may wait for its lock
+// before the wait is logged and counted as slow
+const DefaultSlowWaitThreshold = 5 * time.Second
+
+// WithSlowWaitThreshold logs a warning and counts a slow wait, in SlowWaits and
+// dlm_slow_lock_waits_total, whenever a lock_acquire waits longer than d for
+// its lock, whether or not it gets it in the end. 0 disables the check.
+func WithSlowWaitThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.slowWait = d
+	}
+}
+
+// SlowWaits returns how many lock_acquire calls have waited longer than the slow wait threshold
+func (s *LockServer) SlowWaits() uint64 {
+	return s.slowWaits.Load()
+}
+
+// checkWait warns about an acquire that waited past the slow wait threshold
+func (s *LockServer) checkWait(clientID int32, resource string, wait time.Duration, status pb.Status) {
+	if s.slowWait <= 0 || wait <= s.slowWait {
+		return
+	}
+	s.slowWaits.Add(1)
+	s.metrics.observeSlowWait()
+	s.logger.Printf("Warning: client %d waited %v for lock %q (%v), over the slow wait threshold of %v",
+		clientID, wait.Round(time.Millisecond), resource, status, s.slowWait)
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// maxTransformGrowth bounds how many bytes a transform may add to the content it is given
+const maxTransformGrowth = 4096
+
+// Transform rewrites the content of a file_append before it is written.
+// Transforms must be deterministic, so a retried append writes the same bytes,
+// and cheap, since they run on every append while the client holds its lock.
+type Transform interface {
+	Apply(filename string, content []byte) ([]byte, error)
+}
+
+// TransformFunc adapts an ordinary function to the Transform interface
+type TransformFunc func(filename string, content []byte) ([]byte, error)
+
+// Apply calls f(filename, content)
+func (f TransformFunc) Apply(filename string, content []byte) ([]byte, error) {
+	return f(filename, content)
+}
+
+// WithTransforms applies ts, in order, to every append before it is written.
+// By default content is written unchanged.
+func WithTransforms(ts ...Transform) Option {
+	return func(c *config) {
+		c.transforms = append(c.transforms, ts...)
+	}
+}
+
+// MaskTransform replaces every match of re with mask, taken literally, e.g. to
+// redact secrets
+func MaskTransform(re *regexp.Regexp, mask string) Transform {
+	return TransformFunc(func(filename string, content []byte) ([]byte, error) {
+		return re.ReplaceAllLiteral(content, []byte(mask)), nil
+	})
+}
+
+// EnsureNewlineTransform makes content end in exactly one newline, adding one
+// if it is missing and dropping any extras, so line-oriented files stay well
+// formed whatever clients send. Binary appends need it left off.
+func EnsureNewlineTransform() Transform {
+	return TransformFunc(func(filename string, content []byte) ([]byte, error) {
+		trimmed := bytes.TrimRight(content, "\n")
+		out := make([]byte, len(trimmed)+1)
+		copy(out, trimmed)
+		out[len(trimmed)] = '\n'
+		return out, nil
+	})
+}
+
+// applyTransforms runs content through the configured pipeline, rejecting any
+// transform that grows it by more than maxTransformGrowth bytes
+func (s *LockServer) applyTransforms(filename string, content []byte) ([]byte, error) {
+	for i, t := range s.transforms {
+		out, err := t.Apply(filename, content)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d: %w", i, err)
+		}
+		if len(out) > len(content)+maxTransformGrowth {
+			return nil, fmt.Errorf("transform %d grew %d bytes to %d, over the %d byte limit",
+				i, len(content), len(out), maxTransformGrowth)
+		}
+		content = out
+	}
+	return content, nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	pb "Distributed-Lock-Manager/proto"
+)
+
+func TestAppendTransforms(t *testing.T) {
+	mask := MaskTransform(regexp.MustCompile(`password=\S+`), "password=***")
+	prefix := TransformFunc(func(filename string, content []byte) ([]byte, error) {
+		return append([]byte(filename+": "), content...), nil
+	})
+	s, dataDir := newTestServer(t, WithTransforms(mask, prefix))
+	ctx := context.Background()
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	defer s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+
+	content := []byte("login user=bob password=hunter2\n")
+	resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: content})
+	if err != nil || resp.Status != pb.Status_SUCCESS {
+		t.Fatalf("FileAppend failed: %v, %v", resp, err)
+	}
+	if resp.Bytes != int64(len(content)) {
+		t.Errorf("Expected the response to report %d bytes sent, got %d", len(content), resp.Bytes)
+	}
+
+	// Transforms run in order: the mask sees the raw content, the prefix goes on last
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if want := "file_0: login user=bob password=***\n"; string(got) != want {
+		t.Errorf("Expected %q on disk, got %q", want, got)
+	}
+}
+
+func TestMaskTransformIsLiteral(t *testing.T) {
+	// A $ in the mask is written as is, not read as a group reference
+	mask := MaskTransform(regexp.MustCompile(`secret=\S+`), "secret=$REDACTED")
+	got, err := mask.Apply("file_0", []byte("a secret=hunter2 b"))
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if want := "a secret=$REDACTED b"; string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestTransformGrowthIsBounded(t *testing.T) {
+	bloat := TransformFunc(func(filename string, content []byte) ([]byte, error) {
+		return bytes.Repeat(content, 1000), nil
+	})
+	s, dataDir := newTestServer(t, WithTransforms(bloat))
+	ctx := context.Background()
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	defer s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+
+	resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte("0123456789")})
+	if err != nil || resp.Status != pb.Status_PERMANENT_ERROR {
+		t.Errorf("Expected PERMANENT_ERROR from an unbounded transform, got %v, %v", resp, err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "file_0")); !os.IsNotExist(err) {
+		t.Errorf("Nothing should be written when a transform fails, got %v", err)
+	}
+}
+
+func TestEnsureNewlineTransform(t *testing.T) {
+	s, dataDir := newTestServer(t, WithTransforms(EnsureNewlineTransform()))
+	ctx := context.Background()
+
+	s.LockAcquire(ctx, &pb.LockArgs{ClientId: 1})
+	defer s.LockRelease(ctx, &pb.LockArgs{ClientId: 1})
+
+	for _, content := range []string{"none", "one\n", "many\n\n\n", "\n"} {
+		resp, err := s.FileAppend(ctx, &pb.FileArgs{ClientId: 1, Filename: "file_0", Content: []byte(content)})
+		if err != nil || resp.Status != pb.Status_SUCCESS {
+			t.Fatalf("FileAppend(%q) failed: %v, %v", content, resp, err)
+		}
+	}
+
+	got, err := os.ReadFile(filepath.Join(dataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if want := "none\none\nmany\n\n"; string(got) != want {
+		t.Errorf("Expected %q on disk, got %q", want, got)
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"sync"
+
+	"Distributed-Lock-Manager/internal/lock_manager"
+	pb "Distributed-Lock-Manager/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultWatchBuffer is how many lock events may queue for one watch_lock
+// subscriber before it is considered too slow and disconnected
+const DefaultWatchBuffer = 256
+
+// watcher is one watch_lock subscriber
+type watcher struct {
+	events  chan *pb.LockEvent
+	dropped bool // Set, and events closed, if the subscriber fell behind
+}
+
+// watchers fans lock events out to the watch_lock subscribers
+type watchers struct {
+	mu     sync.Mutex
+	subs   map[*watcher]struct{}
+	closed bool // Set at shutdown; no new subscribers are taken
+}
+
+// subscribe registers a new subscriber, or returns nil if the server is shutting down
+func (ws *watchers) subscribe() *watcher {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.closed {
+		return nil
+	}
+	if ws.subs == nil {
+		ws.subs = make(map[*watcher]struct{})
+	}
+	w := &watcher{events: make(chan *pb.LockEvent, DefaultWatchBuffer)}
+	ws.subs[w] = struct{}{}
+	return w
+}
+
+// unsubscribe removes a subscriber that has gone away
+func (ws *watchers) unsubscribe(w *watcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	delete(ws.subs, w)
+}
+
+// publish hands ev to every subscriber without waiting. A subscriber whose
+// buffer is full is disconnected rather than silently missing events.
+func (ws *watchers) publish(ev lock_manager.Event) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if len(ws.subs) == 0 {
+		return
+	}
+
+	msg := lockEventToProto(ev)
+	for w := range ws.subs {
+		select {
+		case w.events <- msg:
+		default:
+			w.dropped = true
+			close(w.events)
+			delete(ws.subs, w)
+		}
+	}
+}
+
+// close ends every subscription and refuses new ones
+func (ws *watchers) close() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.closed = true
+	for w := range ws.subs {
+		close(w.events)
+		delete(ws.subs, w)
+	}
+}
+
+// lockEventToProto converts a lock manager event for watch_lock
+func lockEventToProto(ev lock_manager.Event) *pb.LockEvent {
+	msg := &pb.LockEvent{
+		Resource:     ev.Resource,
+		ClientId:     ev.ClientID,
+		TimeUnixNano: ev.Time.UnixNano(),
+	}
+	switch ev.Kind {
+	case lock_manager.Acquired:
+		msg.Type = pb.LockEventType_ACQUIRED
+	case lock_manager.Released:
+		msg.Type = pb.LockEventType_RELEASED
+	case lock_manager.Expired:
+		msg.Type = pb.LockEventType_EXPIRED
+	}
+	if ev.Mode == lock_manager.Shared {
+		msg.Mode = pb.LockMode_SHARED
+	}
+	return msg
+}
+
+// WatchLock handles the lock event subscription RPC, streaming every grant,
+// release and lease expiry on any lock until the client hangs up. A subscriber
+// that can't keep up is cut off with ResourceExhausted.
+func (s *LockServer) WatchLock(args *pb.Empty, stream grpc.ServerStreamingServer[pb.LockEvent]) error {
+	w := s.watchers.subscribe()
+	if w == nil {
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+	defer s.watchers.unsubscribe(w)
+	// Headers tell the client the subscription is in place, so it knows no
+	// later event will be missed
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case ev, ok := <-w.events:
+			if !ok {
+				s.watchers.mu.Lock()
+				dropped := w.dropped
+				s.watchers.mu.Unlock()
+				if dropped {
+					return status.Error(codes.ResourceExhausted, "fell too far behind the lock events")
+				}
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	s := StartServer(t)
+	c := s.NewClient(t, 1)
+
+	if err := c.AcquireLock(); err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	if err := c.AppendFile("file_0", []byte("in memory\n")); err != nil {
+		t.Fatalf("AppendFile failed: %v", err)
+	}
+	if err := c.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(s.DataDir, "file_0"))
+	if err != nil {
+		t.Fatalf("Failed to read file_0: %v", err)
+	}
+	if string(got) != "in memory\n" {
+		t.Errorf("Expected file_0 to hold the append, got %q", got)
+	}
+
+	// A second client gets the lock once the first has let go
+	other := s.NewClient(t, 2)
+	if ok, err := other.TryAcquireLock(); err != nil || !ok {
+		t.Errorf("Second client couldn't take the released lock: %v, %v", ok, err)
+	}
+}
+
+func BenchmarkAcquireAppendRelease(b *testing.B) {
+	s := StartServer(b)
+	c := s.NewClient(b, 1)
+	record := []byte("benchmark record\n")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.AcquireLock(); err != nil {
+			b.Fatalf("AcquireLock failed: %v", err)
+		}
+		if err := c.AppendFile("file_0", record); err != nil {
+			b.Fatalf("AppendFile failed: %v", err)
+		}
+		if err := c.ReleaseLock(); err != nil {
+			b.Fatalf("ReleaseLock failed: %v", err)
+		}
+	}
+}
@@ -19,22 +19,106 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	LockService_ClientInit_FullMethodName  = "/lock_service.LockService/client_init"
-	LockService_LockAcquire_FullMethodName = "/lock_service.LockService/lock_acquire"
-	LockService_LockRelease_FullMethodName = "/lock_service.LockService/lock_release"
-	LockService_FileAppend_FullMethodName  = "/lock_service.LockService/file_append"
-	LockService_ClientClose_FullMethodName = "/lock_service.LockService/client_close"
+	LockService_ClientInit_FullMethodName              = "/lock_service.LockService/client_init"
+	LockService_LockAcquire_FullMethodName             = "/lock_service.LockService/lock_acquire"
+	LockService_LockRelease_FullMethodName             = "/lock_service.LockService/lock_release"
+	LockService_LockReleaseAll_FullMethodName          = "/lock_service.LockService/lock_release_all"
+	LockService_LockTryAcquire_FullMethodName          = "/lock_service.LockService/lock_try_acquire"
+	LockService_LockCompareAndAcquire_FullMethodName   = "/lock_service.LockService/lock_compare_and_acquire"
+	LockService_LockTransfer_FullMethodName            = "/lock_service.LockService/lock_transfer"
+	LockService_FileAppend_FullMethodName              = "/lock_service.LockService/file_append"
+	LockService_FileAppendBatch_FullMethodName         = "/lock_service.LockService/file_append_batch"
+	LockService_FileWrite_FullMethodName               = "/lock_service.LockService/file_write"
+	LockService_FileTruncate_FullMethodName            = "/lock_service.LockService/file_truncate"
+	LockService_FileRead_FullMethodName                = "/lock_service.LockService/file_read"
+	LockService_FileStats_FullMethodName               = "/lock_service.LockService/file_stats"
+	LockService_ListFiles_FullMethodName               = "/lock_service.LockService/list_files"
+	LockService_ValidateFilenames_FullMethodName       = "/lock_service.LockService/validate_filenames"
+	LockService_KeepAlive_FullMethodName               = "/lock_service.LockService/keep_alive"
+	LockService_BackupStream_FullMethodName            = "/lock_service.LockService/backup_stream"
+	LockService_RestoreStream_FullMethodName           = "/lock_service.LockService/restore_stream"
+	LockService_WatchLock_FullMethodName               = "/lock_service.LockService/watch_lock"
+	LockService_GetLockStatus_FullMethodName           = "/lock_service.LockService/get_lock_status"
+	LockService_GetAllLocks_FullMethodName             = "/lock_service.LockService/get_all_locks"
+	LockService_GetQueuePosition_FullMethodName        = "/lock_service.LockService/get_queue_position"
+	LockService_VerifyToken_FullMethodName             = "/lock_service.LockService/verify_token"
+	LockService_ClientClose_FullMethodName             = "/lock_service.LockService/client_close"
+	LockService_Ping_FullMethodName                    = "/lock_service.LockService/ping"
+	LockService_ReplicateState_FullMethodName          = "/lock_service.LockService/replicate_state"
+	LockService_Promote_FullMethodName                 = "/lock_service.LockService/promote"
+	LockService_AdminQuarantineClient_FullMethodName   = "/lock_service.LockService/admin_quarantine_client"
+	LockService_AdminUnquarantineClient_FullMethodName = "/lock_service.LockService/admin_unquarantine_client"
+	LockService_AdminForceRelease_FullMethodName       = "/lock_service.LockService/admin_force_release"
+	LockService_AdminSetReadOnly_FullMethodName        = "/lock_service.LockService/admin_set_read_only"
 )
 
 // LockServiceClient is the client API for LockService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LockServiceClient interface {
-	ClientInit(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Int, error)
+	ClientInit(ctx context.Context, in *InitArgs, opts ...grpc.CallOption) (*Int, error)
 	LockAcquire(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*Response, error)
 	LockRelease(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*Response, error)
+	// releases every lock client rc holds, in either mode, waking each lock's
+	// next waiter; SUCCESS even if it held none. Its waiting acquires are left alone
+	LockReleaseAll(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Response, error)
+	// non-blocking acquire: LOCK_BUSY instead of waiting when the lock is taken
+	LockTryAcquire(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*Response, error)
+	// atomic holder swap: PRECONDITION_FAILED when the current holder isn't expected_holder
+	LockCompareAndAcquire(ctx context.Context, in *CasArgs, opts ...grpc.CallOption) (*Response, error)
+	// holder-initiated handoff that skips the queue: PERMISSION_DENIED when from_client_id doesn't hold the lock
+	LockTransfer(ctx context.Context, in *TransferArgs, opts ...grpc.CallOption) (*Response, error)
 	FileAppend(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*Response, error)
+	// appends to several files in one call; every entry is checked before any is written
+	FileAppendBatch(ctx context.Context, in *BatchArgs, opts ...grpc.CallOption) (*Response, error)
+	// replaces the file's contents atomically; same lock rules as file_append
+	FileWrite(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*Response, error)
+	// empties the file in place; same lock rules as file_append, content is ignored
+	FileTruncate(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*Response, error)
+	// requires the file's lock or the global lock, in either mode
+	FileRead(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*FileContent, error)
+	// metadata only, no lock required; last_writer is -1 if unknown
+	FileStats(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*FileStats, error)
+	// every data file that exists with its size, no lock required; files not yet created are left out
+	ListFiles(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*FileList, error)
+	// dry run of the file name checks file_append makes; writes nothing and needs no lock
+	ValidateFilenames(ctx context.Context, in *FilenamesArgs, opts ...grpc.CallOption) (*ValidationResult, error)
+	// liveness ping (rc = client id); a holder that stops sending these loses its locks once its lease runs out
+	KeepAlive(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Response, error)
+	// streams every data file in chunks, in file number order
+	BackupStream(ctx context.Context, in *BackupArgs, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupChunk], error)
+	// admin only: writes the files from a backup stream while file operations are paused
+	RestoreStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreArgs, RestoreResult], error)
+	// streams every grant, release and lease expiry on any lock as it happens
+	WatchLock(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LockEvent], error)
+	// read-only view of the global lock; doesn't acquire anything
+	GetLockStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*LockStatusResponse, error)
+	// read-only view of every lock at one point in time; doesn't acquire anything
+	GetAllLocks(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AllLocksResponse, error)
+	// where client_id stands in the queue for resource; doesn't acquire anything
+	GetQueuePosition(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*PositionResponse, error)
+	// cheap fencing check: valid only while the client still holds the lock under that token
+	VerifyToken(ctx context.Context, in *TokenArgs, opts ...grpc.CallOption) (*TokenValidity, error)
 	ClientClose(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Int, error)
+	// cheap health check: is the data directory writable? Answers even while shutting down
+	Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Pong, error)
+	// admin only, between servers: a primary pushes its lock state to a backup;
+	// PRECONDITION_FAILED unless the receiver is a backup that hasn't been promoted
+	ReplicateState(ctx context.Context, in *ReplicateArgs, opts ...grpc.CallOption) (*Response, error)
+	// admin only: a backup takes over the last lock state replicated to it and
+	// starts serving lock requests
+	Promote(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Response, error)
+	// admin only: refuse every RPC naming client rc with PermissionDenied, release
+	// its locks and turn away its waiting acquires, until it is unquarantined
+	AdminQuarantineClient(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Response, error)
+	// admin only: lift a quarantine
+	AdminUnquarantineClient(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Response, error)
+	// admin only: break a lock whoever holds it, moving its fencing token on and
+	// waking the next waiter; PRECONDITION_FAILED if nobody holds it
+	AdminForceRelease(ctx context.Context, in *AdminArgs, opts ...grpc.CallOption) (*Response, error)
+	// admin only: make the server refuse appends, writes and truncates with READ_ONLY,
+	// or accept them again
+	AdminSetReadOnly(ctx context.Context, in *ReadOnlyArgs, opts ...grpc.CallOption) (*Response, error)
 }
 
 type lockServiceClient struct {
@@ -45,7 +129,7 @@ func NewLockServiceClient(cc grpc.ClientConnInterface) LockServiceClient {
 	return &lockServiceClient{cc}
 }
 
-func (c *lockServiceClient) ClientInit(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Int, error) {
+func (c *lockServiceClient) ClientInit(ctx context.Context, in *InitArgs, opts ...grpc.CallOption) (*Int, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Int)
 	err := c.cc.Invoke(ctx, LockService_ClientInit_FullMethodName, in, out, cOpts...)
@@ -75,6 +159,46 @@ func (c *lockServiceClient) LockRelease(ctx context.Context, in *LockArgs, opts
 	return out, nil
 }
 
+func (c *lockServiceClient) LockReleaseAll(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_LockReleaseAll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) LockTryAcquire(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_LockTryAcquire_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) LockCompareAndAcquire(ctx context.Context, in *CasArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_LockCompareAndAcquire_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) LockTransfer(ctx context.Context, in *TransferArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_LockTransfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lockServiceClient) FileAppend(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*Response, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Response)
@@ -85,6 +209,177 @@ func (c *lockServiceClient) FileAppend(ctx context.Context, in *FileArgs, opts .
 	return out, nil
 }
 
+func (c *lockServiceClient) FileAppendBatch(ctx context.Context, in *BatchArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_FileAppendBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) FileWrite(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_FileWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) FileTruncate(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_FileTruncate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) FileRead(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*FileContent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileContent)
+	err := c.cc.Invoke(ctx, LockService_FileRead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) FileStats(ctx context.Context, in *FileArgs, opts ...grpc.CallOption) (*FileStats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileStats)
+	err := c.cc.Invoke(ctx, LockService_FileStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) ListFiles(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*FileList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileList)
+	err := c.cc.Invoke(ctx, LockService_ListFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) ValidateFilenames(ctx context.Context, in *FilenamesArgs, opts ...grpc.CallOption) (*ValidationResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidationResult)
+	err := c.cc.Invoke(ctx, LockService_ValidateFilenames_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) KeepAlive(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_KeepAlive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) BackupStream(ctx context.Context, in *BackupArgs, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LockService_ServiceDesc.Streams[0], LockService_BackupStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BackupArgs, BackupChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LockService_BackupStreamClient = grpc.ServerStreamingClient[BackupChunk]
+
+func (c *lockServiceClient) RestoreStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreArgs, RestoreResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LockService_ServiceDesc.Streams[1], LockService_RestoreStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RestoreArgs, RestoreResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LockService_RestoreStreamClient = grpc.ClientStreamingClient[RestoreArgs, RestoreResult]
+
+func (c *lockServiceClient) WatchLock(ctx context.Context, in *Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LockEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LockService_ServiceDesc.Streams[2], LockService_WatchLock_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Empty, LockEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LockService_WatchLockClient = grpc.ServerStreamingClient[LockEvent]
+
+func (c *lockServiceClient) GetLockStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*LockStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LockStatusResponse)
+	err := c.cc.Invoke(ctx, LockService_GetLockStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) GetAllLocks(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AllLocksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AllLocksResponse)
+	err := c.cc.Invoke(ctx, LockService_GetAllLocks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) GetQueuePosition(ctx context.Context, in *LockArgs, opts ...grpc.CallOption) (*PositionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PositionResponse)
+	err := c.cc.Invoke(ctx, LockService_GetQueuePosition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) VerifyToken(ctx context.Context, in *TokenArgs, opts ...grpc.CallOption) (*TokenValidity, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TokenValidity)
+	err := c.cc.Invoke(ctx, LockService_VerifyToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lockServiceClient) ClientClose(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Int, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Int)
@@ -95,15 +390,143 @@ func (c *lockServiceClient) ClientClose(ctx context.Context, in *Int, opts ...gr
 	return out, nil
 }
 
+func (c *lockServiceClient) Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Pong, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Pong)
+	err := c.cc.Invoke(ctx, LockService_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) ReplicateState(ctx context.Context, in *ReplicateArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_ReplicateState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) Promote(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_Promote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) AdminQuarantineClient(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_AdminQuarantineClient_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) AdminUnquarantineClient(ctx context.Context, in *Int, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_AdminUnquarantineClient_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) AdminForceRelease(ctx context.Context, in *AdminArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_AdminForceRelease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lockServiceClient) AdminSetReadOnly(ctx context.Context, in *ReadOnlyArgs, opts ...grpc.CallOption) (*Response, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Response)
+	err := c.cc.Invoke(ctx, LockService_AdminSetReadOnly_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LockServiceServer is the server API for LockService service.
 // All implementations must embed UnimplementedLockServiceServer
 // for forward compatibility.
 type LockServiceServer interface {
-	ClientInit(context.Context, *Int) (*Int, error)
+	ClientInit(context.Context, *InitArgs) (*Int, error)
 	LockAcquire(context.Context, *LockArgs) (*Response, error)
 	LockRelease(context.Context, *LockArgs) (*Response, error)
+	// releases every lock client rc holds, in either mode, waking each lock's
+	// next waiter; SUCCESS even if it held none. Its waiting acquires are left alone
+	LockReleaseAll(context.Context, *Int) (*Response, error)
+	// non-blocking acquire: LOCK_BUSY instead of waiting when the lock is taken
+	LockTryAcquire(context.Context, *LockArgs) (*Response, error)
+	// atomic holder swap: PRECONDITION_FAILED when the current holder isn't expected_holder
+	LockCompareAndAcquire(context.Context, *CasArgs) (*Response, error)
+	// holder-initiated handoff that skips the queue: PERMISSION_DENIED when from_client_id doesn't hold the lock
+	LockTransfer(context.Context, *TransferArgs) (*Response, error)
 	FileAppend(context.Context, *FileArgs) (*Response, error)
+	// appends to several files in one call; every entry is checked before any is written
+	FileAppendBatch(context.Context, *BatchArgs) (*Response, error)
+	// replaces the file's contents atomically; same lock rules as file_append
+	FileWrite(context.Context, *FileArgs) (*Response, error)
+	// empties the file in place; same lock rules as file_append, content is ignored
+	FileTruncate(context.Context, *FileArgs) (*Response, error)
+	// requires the file's lock or the global lock, in either mode
+	FileRead(context.Context, *FileArgs) (*FileContent, error)
+	// metadata only, no lock required; last_writer is -1 if unknown
+	FileStats(context.Context, *FileArgs) (*FileStats, error)
+	// every data file that exists with its size, no lock required; files not yet created are left out
+	ListFiles(context.Context, *Empty) (*FileList, error)
+	// dry run of the file name checks file_append makes; writes nothing and needs no lock
+	ValidateFilenames(context.Context, *FilenamesArgs) (*ValidationResult, error)
+	// liveness ping (rc = client id); a holder that stops sending these loses its locks once its lease runs out
+	KeepAlive(context.Context, *Int) (*Response, error)
+	// streams every data file in chunks, in file number order
+	BackupStream(*BackupArgs, grpc.ServerStreamingServer[BackupChunk]) error
+	// admin only: writes the files from a backup stream while file operations are paused
+	RestoreStream(grpc.ClientStreamingServer[RestoreArgs, RestoreResult]) error
+	// streams every grant, release and lease expiry on any lock as it happens
+	WatchLock(*Empty, grpc.ServerStreamingServer[LockEvent]) error
+	// read-only view of the global lock; doesn't acquire anything
+	GetLockStatus(context.Context, *Empty) (*LockStatusResponse, error)
+	// read-only view of every lock at one point in time; doesn't acquire anything
+	GetAllLocks(context.Context, *Empty) (*AllLocksResponse, error)
+	// where client_id stands in the queue for resource; doesn't acquire anything
+	GetQueuePosition(context.Context, *LockArgs) (*PositionResponse, error)
+	// cheap fencing check: valid only while the client still holds the lock under that token
+	VerifyToken(context.Context, *TokenArgs) (*TokenValidity, error)
 	ClientClose(context.Context, *Int) (*Int, error)
+	// cheap health check: is the data directory writable? Answers even while shutting down
+	Ping(context.Context, *Empty) (*Pong, error)
+	// admin only, between servers: a primary pushes its lock state to a backup;
+	// PRECONDITION_FAILED unless the receiver is a backup that hasn't been promoted
+	ReplicateState(context.Context, *ReplicateArgs) (*Response, error)
+	// admin only: a backup takes over the last lock state replicated to it and
+	// starts serving lock requests
+	Promote(context.Context, *Empty) (*Response, error)
+	// admin only: refuse every RPC naming client rc with PermissionDenied, release
+	// its locks and turn away its waiting acquires, until it is unquarantined
+	AdminQuarantineClient(context.Context, *Int) (*Response, error)
+	// admin only: lift a quarantine
+	AdminUnquarantineClient(context.Context, *Int) (*Response, error)
+	// admin only: break a lock whoever holds it, moving its fencing token on and
+	// waking the next waiter; PRECONDITION_FAILED if nobody holds it
+	AdminForceRelease(context.Context, *AdminArgs) (*Response, error)
+	// admin only: make the server refuse appends, writes and truncates with READ_ONLY,
+	// or accept them again
+	AdminSetReadOnly(context.Context, *ReadOnlyArgs) (*Response, error)
 	mustEmbedUnimplementedLockServiceServer()
 }
 
@@ -114,7 +537,7 @@ type LockServiceServer interface {
 // pointer dereference when methods are called.
 type UnimplementedLockServiceServer struct{}
 
-func (UnimplementedLockServiceServer) ClientInit(context.Context, *Int) (*Int, error) {
+func (UnimplementedLockServiceServer) ClientInit(context.Context, *InitArgs) (*Int, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ClientInit not implemented")
 }
 func (UnimplementedLockServiceServer) LockAcquire(context.Context, *LockArgs) (*Response, error) {
@@ -123,12 +546,90 @@ func (UnimplementedLockServiceServer) LockAcquire(context.Context, *LockArgs) (*
 func (UnimplementedLockServiceServer) LockRelease(context.Context, *LockArgs) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LockRelease not implemented")
 }
+func (UnimplementedLockServiceServer) LockReleaseAll(context.Context, *Int) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockReleaseAll not implemented")
+}
+func (UnimplementedLockServiceServer) LockTryAcquire(context.Context, *LockArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockTryAcquire not implemented")
+}
+func (UnimplementedLockServiceServer) LockCompareAndAcquire(context.Context, *CasArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockCompareAndAcquire not implemented")
+}
+func (UnimplementedLockServiceServer) LockTransfer(context.Context, *TransferArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockTransfer not implemented")
+}
 func (UnimplementedLockServiceServer) FileAppend(context.Context, *FileArgs) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FileAppend not implemented")
 }
+func (UnimplementedLockServiceServer) FileAppendBatch(context.Context, *BatchArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileAppendBatch not implemented")
+}
+func (UnimplementedLockServiceServer) FileWrite(context.Context, *FileArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileWrite not implemented")
+}
+func (UnimplementedLockServiceServer) FileTruncate(context.Context, *FileArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileTruncate not implemented")
+}
+func (UnimplementedLockServiceServer) FileRead(context.Context, *FileArgs) (*FileContent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileRead not implemented")
+}
+func (UnimplementedLockServiceServer) FileStats(context.Context, *FileArgs) (*FileStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileStats not implemented")
+}
+func (UnimplementedLockServiceServer) ListFiles(context.Context, *Empty) (*FileList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFiles not implemented")
+}
+func (UnimplementedLockServiceServer) ValidateFilenames(context.Context, *FilenamesArgs) (*ValidationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateFilenames not implemented")
+}
+func (UnimplementedLockServiceServer) KeepAlive(context.Context, *Int) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KeepAlive not implemented")
+}
+func (UnimplementedLockServiceServer) BackupStream(*BackupArgs, grpc.ServerStreamingServer[BackupChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method BackupStream not implemented")
+}
+func (UnimplementedLockServiceServer) RestoreStream(grpc.ClientStreamingServer[RestoreArgs, RestoreResult]) error {
+	return status.Errorf(codes.Unimplemented, "method RestoreStream not implemented")
+}
+func (UnimplementedLockServiceServer) WatchLock(*Empty, grpc.ServerStreamingServer[LockEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchLock not implemented")
+}
+func (UnimplementedLockServiceServer) GetLockStatus(context.Context, *Empty) (*LockStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLockStatus not implemented")
+}
+func (UnimplementedLockServiceServer) GetAllLocks(context.Context, *Empty) (*AllLocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllLocks not implemented")
+}
+func (UnimplementedLockServiceServer) GetQueuePosition(context.Context, *LockArgs) (*PositionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQueuePosition not implemented")
+}
+func (UnimplementedLockServiceServer) VerifyToken(context.Context, *TokenArgs) (*TokenValidity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyToken not implemented")
+}
 func (UnimplementedLockServiceServer) ClientClose(context.Context, *Int) (*Int, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ClientClose not implemented")
 }
+func (UnimplementedLockServiceServer) Ping(context.Context, *Empty) (*Pong, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedLockServiceServer) ReplicateState(context.Context, *ReplicateArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicateState not implemented")
+}
+func (UnimplementedLockServiceServer) Promote(context.Context, *Empty) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Promote not implemented")
+}
+func (UnimplementedLockServiceServer) AdminQuarantineClient(context.Context, *Int) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminQuarantineClient not implemented")
+}
+func (UnimplementedLockServiceServer) AdminUnquarantineClient(context.Context, *Int) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminUnquarantineClient not implemented")
+}
+func (UnimplementedLockServiceServer) AdminForceRelease(context.Context, *AdminArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminForceRelease not implemented")
+}
+func (UnimplementedLockServiceServer) AdminSetReadOnly(context.Context, *ReadOnlyArgs) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminSetReadOnly not implemented")
+}
 func (UnimplementedLockServiceServer) mustEmbedUnimplementedLockServiceServer() {}
 func (UnimplementedLockServiceServer) testEmbeddedByValue()                     {}
 
@@ -151,7 +652,7 @@ func RegisterLockServiceServer(s grpc.ServiceRegistrar, srv LockServiceServer) {
 }
 
 func _LockService_ClientInit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Int)
+	in := new(InitArgs)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -163,7 +664,7 @@ func _LockService_ClientInit_Handler(srv interface{}, ctx context.Context, dec f
 		FullMethod: LockService_ClientInit_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(LockServiceServer).ClientInit(ctx, req.(*Int))
+		return srv.(LockServiceServer).ClientInit(ctx, req.(*InitArgs))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -204,70 +705,621 @@ func _LockService_LockRelease_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
-func _LockService_FileAppend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(FileArgs)
+func _LockService_LockReleaseAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Int)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(LockServiceServer).FileAppend(ctx, in)
+		return srv.(LockServiceServer).LockReleaseAll(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: LockService_FileAppend_FullMethodName,
+		FullMethod: LockService_LockReleaseAll_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(LockServiceServer).FileAppend(ctx, req.(*FileArgs))
+		return srv.(LockServiceServer).LockReleaseAll(ctx, req.(*Int))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _LockService_ClientClose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Int)
+func _LockService_LockTryAcquire_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockArgs)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(LockServiceServer).ClientClose(ctx, in)
+		return srv.(LockServiceServer).LockTryAcquire(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: LockService_ClientClose_FullMethodName,
+		FullMethod: LockService_LockTryAcquire_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(LockServiceServer).ClientClose(ctx, req.(*Int))
+		return srv.(LockServiceServer).LockTryAcquire(ctx, req.(*LockArgs))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// LockService_ServiceDesc is the grpc.ServiceDesc for LockService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var LockService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "lock_service.LockService",
-	HandlerType: (*LockServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "client_init",
-			Handler:    _LockService_ClientInit_Handler,
-		},
-		{
-			MethodName: "lock_acquire",
+func _LockService_LockCompareAndAcquire_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CasArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).LockCompareAndAcquire(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_LockCompareAndAcquire_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).LockCompareAndAcquire(ctx, req.(*CasArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_LockTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).LockTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_LockTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).LockTransfer(ctx, req.(*TransferArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_FileAppend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).FileAppend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_FileAppend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).FileAppend(ctx, req.(*FileArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_FileAppendBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).FileAppendBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_FileAppendBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).FileAppendBatch(ctx, req.(*BatchArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_FileWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).FileWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_FileWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).FileWrite(ctx, req.(*FileArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_FileTruncate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).FileTruncate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_FileTruncate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).FileTruncate(ctx, req.(*FileArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_FileRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).FileRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_FileRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).FileRead(ctx, req.(*FileArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_FileStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).FileStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_FileStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).FileStats(ctx, req.(*FileArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_ListFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).ListFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_ListFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).ListFiles(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_ValidateFilenames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FilenamesArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).ValidateFilenames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_ValidateFilenames_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).ValidateFilenames(ctx, req.(*FilenamesArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_KeepAlive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Int)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).KeepAlive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_KeepAlive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).KeepAlive(ctx, req.(*Int))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_BackupStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupArgs)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LockServiceServer).BackupStream(m, &grpc.GenericServerStream[BackupArgs, BackupChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LockService_BackupStreamServer = grpc.ServerStreamingServer[BackupChunk]
+
+func _LockService_RestoreStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LockServiceServer).RestoreStream(&grpc.GenericServerStream[RestoreArgs, RestoreResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LockService_RestoreStreamServer = grpc.ClientStreamingServer[RestoreArgs, RestoreResult]
+
+func _LockService_WatchLock_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LockServiceServer).WatchLock(m, &grpc.GenericServerStream[Empty, LockEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LockService_WatchLockServer = grpc.ServerStreamingServer[LockEvent]
+
+func _LockService_GetLockStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).GetLockStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_GetLockStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).GetLockStatus(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_GetAllLocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).GetAllLocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_GetAllLocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).GetAllLocks(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_GetQueuePosition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).GetQueuePosition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_GetQueuePosition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).GetQueuePosition(ctx, req.(*LockArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_VerifyToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TokenArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).VerifyToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_VerifyToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).VerifyToken(ctx, req.(*TokenArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_ClientClose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Int)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).ClientClose(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_ClientClose_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).ClientClose(ctx, req.(*Int))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).Ping(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_ReplicateState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicateArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).ReplicateState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_ReplicateState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).ReplicateState(ctx, req.(*ReplicateArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_Promote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).Promote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_Promote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).Promote(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_AdminQuarantineClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Int)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).AdminQuarantineClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_AdminQuarantineClient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).AdminQuarantineClient(ctx, req.(*Int))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_AdminUnquarantineClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Int)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).AdminUnquarantineClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_AdminUnquarantineClient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).AdminUnquarantineClient(ctx, req.(*Int))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_AdminForceRelease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).AdminForceRelease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_AdminForceRelease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).AdminForceRelease(ctx, req.(*AdminArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LockService_AdminSetReadOnly_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadOnlyArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LockServiceServer).AdminSetReadOnly(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LockService_AdminSetReadOnly_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LockServiceServer).AdminSetReadOnly(ctx, req.(*ReadOnlyArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LockService_ServiceDesc is the grpc.ServiceDesc for LockService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LockService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lock_service.LockService",
+	HandlerType: (*LockServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "client_init",
+			Handler:    _LockService_ClientInit_Handler,
+		},
+		{
+			MethodName: "lock_acquire",
 			Handler:    _LockService_LockAcquire_Handler,
 		},
 		{
 			MethodName: "lock_release",
 			Handler:    _LockService_LockRelease_Handler,
 		},
+		{
+			MethodName: "lock_release_all",
+			Handler:    _LockService_LockReleaseAll_Handler,
+		},
+		{
+			MethodName: "lock_try_acquire",
+			Handler:    _LockService_LockTryAcquire_Handler,
+		},
+		{
+			MethodName: "lock_compare_and_acquire",
+			Handler:    _LockService_LockCompareAndAcquire_Handler,
+		},
+		{
+			MethodName: "lock_transfer",
+			Handler:    _LockService_LockTransfer_Handler,
+		},
 		{
 			MethodName: "file_append",
 			Handler:    _LockService_FileAppend_Handler,
 		},
+		{
+			MethodName: "file_append_batch",
+			Handler:    _LockService_FileAppendBatch_Handler,
+		},
+		{
+			MethodName: "file_write",
+			Handler:    _LockService_FileWrite_Handler,
+		},
+		{
+			MethodName: "file_truncate",
+			Handler:    _LockService_FileTruncate_Handler,
+		},
+		{
+			MethodName: "file_read",
+			Handler:    _LockService_FileRead_Handler,
+		},
+		{
+			MethodName: "file_stats",
+			Handler:    _LockService_FileStats_Handler,
+		},
+		{
+			MethodName: "list_files",
+			Handler:    _LockService_ListFiles_Handler,
+		},
+		{
+			MethodName: "validate_filenames",
+			Handler:    _LockService_ValidateFilenames_Handler,
+		},
+		{
+			MethodName: "keep_alive",
+			Handler:    _LockService_KeepAlive_Handler,
+		},
+		{
+			MethodName: "get_lock_status",
+			Handler:    _LockService_GetLockStatus_Handler,
+		},
+		{
+			MethodName: "get_all_locks",
+			Handler:    _LockService_GetAllLocks_Handler,
+		},
+		{
+			MethodName: "get_queue_position",
+			Handler:    _LockService_GetQueuePosition_Handler,
+		},
+		{
+			MethodName: "verify_token",
+			Handler:    _LockService_VerifyToken_Handler,
+		},
 		{
 			MethodName: "client_close",
 			Handler:    _LockService_ClientClose_Handler,
 		},
+		{
+			MethodName: "ping",
+			Handler:    _LockService_Ping_Handler,
+		},
+		{
+			MethodName: "replicate_state",
+			Handler:    _LockService_ReplicateState_Handler,
+		},
+		{
+			MethodName: "promote",
+			Handler:    _LockService_Promote_Handler,
+		},
+		{
+			MethodName: "admin_quarantine_client",
+			Handler:    _LockService_AdminQuarantineClient_Handler,
+		},
+		{
+			MethodName: "admin_unquarantine_client",
+			Handler:    _LockService_AdminUnquarantineClient_Handler,
+		},
+		{
+			MethodName: "admin_force_release",
+			Handler:    _LockService_AdminForceRelease_Handler,
+		},
+		{
+			MethodName: "admin_set_read_only",
+			Handler:    _LockService_AdminSetReadOnly_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "backup_stream",
+			Handler:       _LockService_BackupStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "restore_stream",
+			Handler:       _LockService_RestoreStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "watch_lock",
+			Handler:       _LockService_WatchLock_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/lock.proto",
 }